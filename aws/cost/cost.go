@@ -0,0 +1,325 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package awscost estimates the monthly cost delta of template commands
+// from an offline price table
+package awscost
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/wallix/awless/cloud/properties"
+	"gopkg.in/yaml.v2"
+)
+
+// HoursPerMonth is the number of hours hourly prices are multiplied by
+const HoursPerMonth = 730
+
+// PriceTable holds on-demand prices in USD per region. Instances, databases
+// (single AZ, by class), NAT gateways and load balancers are priced per hour,
+// volumes and databases storage (by storage type) per GB-month
+type PriceTable struct {
+	Updated string                   `yaml:"updated"`
+	Regions map[string]*RegionPrices `yaml:"regions"`
+}
+
+type RegionPrices struct {
+	Instance        map[string]float64 `yaml:"instance"`
+	Database        map[string]float64 `yaml:"database"`
+	Volume          map[string]float64 `yaml:"volume"`
+	DatabaseStorage map[string]float64 `yaml:"database-storage"`
+	NatGateway      float64            `yaml:"natgateway"`
+	LoadBalancer    float64            `yaml:"loadbalancer"`
+}
+
+// ParsePriceTable reads a YAML price table
+func ParsePriceTable(content []byte) (*PriceTable, error) {
+	table := &PriceTable{}
+	if err := yaml.UnmarshalStrict(content, table); err != nil {
+		return nil, fmt.Errorf("invalid price table: %s", err)
+	}
+	return table, nil
+}
+
+// DefaultPriceTable returns the price table shipped with awless
+func DefaultPriceTable() *PriceTable {
+	table, err := ParsePriceTable([]byte(defaultPriceTable))
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// Merge overrides the prices of the table with the ones of other
+func (t *PriceTable) Merge(other *PriceTable) {
+	if other.Updated != "" {
+		t.Updated = other.Updated
+	}
+	if t.Regions == nil {
+		t.Regions = make(map[string]*RegionPrices)
+	}
+	for region, prices := range other.Regions {
+		current, ok := t.Regions[region]
+		if !ok {
+			t.Regions[region] = prices
+			continue
+		}
+		current.Instance = mergePrices(current.Instance, prices.Instance)
+		current.Database = mergePrices(current.Database, prices.Database)
+		current.Volume = mergePrices(current.Volume, prices.Volume)
+		current.DatabaseStorage = mergePrices(current.DatabaseStorage, prices.DatabaseStorage)
+		if prices.NatGateway > 0 {
+			current.NatGateway = prices.NatGateway
+		}
+		if prices.LoadBalancer > 0 {
+			current.LoadBalancer = prices.LoadBalancer
+		}
+	}
+}
+
+func mergePrices(current, other map[string]float64) map[string]float64 {
+	if current == nil {
+		current = make(map[string]float64)
+	}
+	for k, v := range other {
+		current[k] = v
+	}
+	return current
+}
+
+// Command is a template command to estimate
+type Command struct {
+	Action, Entity string
+	Params         map[string]interface{}
+}
+
+// LookupFunc returns the properties of an existing resource given its ID
+type LookupFunc func(id string) (map[string]interface{}, bool)
+
+// Estimate is the monthly cost delta of commands
+type Estimate struct {
+	Region  string
+	Updated string
+	Lines   []*Line
+}
+
+// Line is the monthly cost delta of a command. Unknown explains why it could not be priced
+type Line struct {
+	Command  string
+	Resource string
+	Monthly  float64
+	Unknown  string
+}
+
+func (e *Estimate) Total() (total float64) {
+	for _, l := range e.Lines {
+		total += l.Monthly
+	}
+	return
+}
+
+// Estimate returns the monthly cost delta of the commands in the region. Deleted
+// and updated resources are priced from their properties given by lookup.
+// Commands without cost are ignored
+func (t *PriceTable) Estimate(region string, cmds []*Command, lookup LookupFunc) *Estimate {
+	estimate := &Estimate{Region: region, Updated: t.Updated}
+	prices, ok := t.Regions[region]
+	if !ok {
+		prices = &RegionPrices{}
+	}
+	if lookup == nil {
+		lookup = func(string) (map[string]interface{}, bool) { return nil, false }
+	}
+	for _, cmd := range cmds {
+		estimate.Lines = append(estimate.Lines, prices.estimate(cmd, lookup)...)
+	}
+	return estimate
+}
+
+func (p *RegionPrices) estimate(cmd *Command, lookup LookupFunc) (lines []*Line) {
+	name := fmt.Sprintf("%s %s", cmd.Action, cmd.Entity)
+	hourly := func(resource string, table map[string]float64, key string, count int, sign float64) *Line {
+		price, ok := table[key]
+		if !ok {
+			return &Line{Command: name, Resource: resource, Unknown: fmt.Sprintf("no price for '%s'", key)}
+		}
+		return &Line{Command: name, Resource: resource, Monthly: sign * price * HoursPerMonth * float64(count)}
+	}
+
+	switch name {
+	case "create instance":
+		typ, count := str(cmd.Params["type"]), integer(cmd.Params["count"], 1)
+		lines = append(lines, hourly(fmt.Sprintf("%s x %d", typ, count), p.Instance, typ, count, 1))
+	case "delete instance":
+		for _, id := range strs(cmd.Params["ids"]) {
+			props, ok := lookup(id)
+			if !ok {
+				lines = append(lines, &Line{Command: name, Resource: id, Unknown: "unknown resource"})
+				continue
+			}
+			typ := str(props[properties.Type])
+			lines = append(lines, hourly(fmt.Sprintf("%s %s", id, typ), p.Instance, typ, 1, -1))
+		}
+	case "update instance":
+		typ, ok := cmd.Params["type"]
+		if !ok {
+			return
+		}
+		id := str(cmd.Params["id"])
+		props, ok := lookup(id)
+		if !ok {
+			return append(lines, &Line{Command: name, Resource: id, Unknown: "unknown resource"})
+		}
+		current := str(props[properties.Type])
+		lines = append(lines, hourly(fmt.Sprintf("%s %s", id, current), p.Instance, current, 1, -1))
+		lines = append(lines, hourly(fmt.Sprintf("%s %s", id, typ), p.Instance, str(typ), 1, 1))
+	case "create volume":
+		lines = append(lines, p.volume(name, "gp2", integer(cmd.Params["size"], 0), 1))
+	case "delete volume":
+		id := str(cmd.Params["id"])
+		props, ok := lookup(id)
+		if !ok {
+			return append(lines, &Line{Command: name, Resource: id, Unknown: "unknown resource"})
+		}
+		typ := str(props[properties.Type])
+		if typ == "" {
+			typ = "gp2"
+		}
+		line := p.volume(name, typ, integer(props[properties.Size], 0), -1)
+		line.Resource = fmt.Sprintf("%s %s", id, line.Resource)
+		lines = append(lines, line)
+	case "create database":
+		class, storage := str(cmd.Params["type"]), str(cmd.Params["storagetype"])
+		if storage == "" {
+			storage = "gp2"
+		}
+		lines = append(lines, p.database(name, class, storage, integer(cmd.Params["size"], 0), cmd.Params["multiaz"], 1))
+	case "delete database":
+		id := str(cmd.Params["id"])
+		props, ok := lookup(id)
+		if !ok {
+			return append(lines, &Line{Command: name, Resource: id, Unknown: "unknown resource"})
+		}
+		line := p.database(name, str(props[properties.Class]), str(props[properties.StorageType]), integer(props[properties.Storage], 0), props[properties.MultiAZ], -1)
+		line.Resource = fmt.Sprintf("%s %s", id, line.Resource)
+		lines = append(lines, line)
+	case "create natgateway", "delete natgateway":
+		lines = append(lines, p.flat(name, p.NatGateway, cmd.Action))
+	case "create loadbalancer", "delete loadbalancer":
+		lines = append(lines, p.flat(name, p.LoadBalancer, cmd.Action))
+	}
+	return
+}
+
+func (p *RegionPrices) volume(name, typ string, size int, sign float64) *Line {
+	resource := fmt.Sprintf("%s %dGB", typ, size)
+	price, ok := p.Volume[typ]
+	if !ok {
+		return &Line{Command: name, Resource: resource, Unknown: fmt.Sprintf("no price for '%s'", typ)}
+	}
+	return &Line{Command: name, Resource: resource, Monthly: sign * price * float64(size)}
+}
+
+func (p *RegionPrices) database(name, class, storage string, size int, multiaz interface{}, sign float64) *Line {
+	resource := fmt.Sprintf("%s %s %dGB", class, storage, size)
+	instances := 1.0
+	if fmt.Sprint(multiaz) == "true" {
+		instances = 2
+		resource += " multi-AZ"
+	}
+	classPrice, ok := p.Database[class]
+	if !ok {
+		return &Line{Command: name, Resource: resource, Unknown: fmt.Sprintf("no price for '%s'", class)}
+	}
+	storagePrice, ok := p.DatabaseStorage[storage]
+	if !ok {
+		return &Line{Command: name, Resource: resource, Unknown: fmt.Sprintf("no price for '%s' storage", storage)}
+	}
+	return &Line{Command: name, Resource: resource, Monthly: sign * instances * (classPrice*HoursPerMonth + storagePrice*float64(size))}
+}
+
+func (p *RegionPrices) flat(name string, hourly float64, action string) *Line {
+	if hourly == 0 {
+		return &Line{Command: name, Unknown: "no price"}
+	}
+	sign := 1.0
+	if action == "delete" {
+		sign = -1
+	}
+	return &Line{Command: name, Monthly: sign * hourly * HoursPerMonth}
+}
+
+// Print displays the estimate as a table, with the total delta
+func (e *Estimate) Print(w io.Writer) {
+	tabw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, l := range e.Lines {
+		if l.Unknown != "" {
+			fmt.Fprintf(tabw, "  %s\t%s\t?\t(%s)\n", l.Command, l.Resource, l.Unknown)
+		} else {
+			fmt.Fprintf(tabw, "  %s\t%s\t%s\n", l.Command, l.Resource, formatDelta(l.Monthly))
+		}
+	}
+	fmt.Fprintf(tabw, "  Total\t\t%s/month\n", formatDelta(e.Total()))
+	tabw.Flush()
+}
+
+func formatDelta(v float64) string {
+	if v < 0 {
+		return fmt.Sprintf("-$%.2f", -v)
+	}
+	return fmt.Sprintf("+$%.2f", v)
+}
+
+func str(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func strs(v interface{}) (out []string) {
+	switch vv := v.(type) {
+	case []interface{}:
+		for _, e := range vv {
+			out = append(out, fmt.Sprint(e))
+		}
+	case []string:
+		out = vv
+	case nil:
+	default:
+		out = append(out, fmt.Sprint(vv))
+	}
+	return
+}
+
+func integer(v interface{}, def int) int {
+	switch vv := v.(type) {
+	case int:
+		return vv
+	case int64:
+		return int(vv)
+	case float64:
+		return int(vv)
+	case string:
+		if i, err := strconv.Atoi(vv); err == nil {
+			return i
+		}
+	}
+	return def
+}
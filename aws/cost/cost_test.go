@@ -0,0 +1,119 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awscost
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	table, err := ParsePriceTable([]byte(`updated: "2017-12-01"
+regions:
+  eu-west-1:
+    instance:
+      t2.micro: 0.01
+      t2.small: 0.02
+    database:
+      db.t2.micro: 0.02
+    volume:
+      gp2: 0.1
+      io1: 0.2
+    database-storage:
+      gp2: 0.1
+    natgateway: 0.05
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := map[string]map[string]interface{}{
+		"i-1":   {"Type": "t2.small"},
+		"vol-1": {"Type": "io1", "Size": 10},
+		"db-1":  {"Class": "db.t2.micro", "StorageType": "gp2", "Storage": 20, "MultiAZ": true},
+	}
+	lookup := func(id string) (map[string]interface{}, bool) {
+		props, ok := existing[id]
+		return props, ok
+	}
+
+	tcases := []struct {
+		cmd      *Command
+		expect   []float64
+		unknowns int
+	}{
+		{&Command{"create", "instance", map[string]interface{}{"type": "t2.micro", "count": 2}}, []float64{14.6}, 0},
+		{&Command{"create", "instance", map[string]interface{}{"type": "m5.large"}}, []float64{0}, 1},
+		{&Command{"delete", "instance", map[string]interface{}{"ids": []interface{}{"i-1", "i-unknown"}}}, []float64{-14.6, 0}, 1},
+		{&Command{"update", "instance", map[string]interface{}{"id": "i-1", "type": "t2.micro"}}, []float64{-14.6, 7.3}, 0},
+		{&Command{"update", "instance", map[string]interface{}{"id": "i-1", "lock": true}}, nil, 0},
+		{&Command{"create", "volume", map[string]interface{}{"size": 5}}, []float64{0.5}, 0},
+		{&Command{"delete", "volume", map[string]interface{}{"id": "vol-1"}}, []float64{-2}, 0},
+		{&Command{"create", "database", map[string]interface{}{"type": "db.t2.micro", "size": 10}}, []float64{15.6}, 0},
+		{&Command{"delete", "database", map[string]interface{}{"id": "db-1"}}, []float64{-33.2}, 0},
+		{&Command{"create", "natgateway", map[string]interface{}{"subnet": "sub-1"}}, []float64{36.5}, 0},
+		{&Command{"create", "loadbalancer", map[string]interface{}{"name": "lb"}}, []float64{0}, 1},
+		{&Command{"create", "subnet", map[string]interface{}{"cidr": "10.0.0.0/24"}}, nil, 0},
+	}
+
+	for i, tcase := range tcases {
+		estimate := table.Estimate("eu-west-1", []*Command{tcase.cmd}, lookup)
+		if got, want := len(estimate.Lines), len(tcase.expect); got != want {
+			t.Fatalf("%d: got %d lines, want %d", i+1, got, want)
+		}
+		var unknowns int
+		for j, l := range estimate.Lines {
+			if l.Unknown != "" {
+				unknowns++
+			}
+			if got, want := l.Monthly, tcase.expect[j]; math.Abs(got-want) > 0.001 {
+				t.Fatalf("%d: line %d: got %f, want %f", i+1, j+1, got, want)
+			}
+		}
+		if got, want := unknowns, tcase.unknowns; got != want {
+			t.Fatalf("%d: got %d unknown prices, want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestEstimatePrint(t *testing.T) {
+	table := DefaultPriceTable()
+	table.Merge(&PriceTable{Regions: map[string]*RegionPrices{"eu-west-1": {Instance: map[string]float64{"t2.micro": 1}}}})
+	estimate := table.Estimate("eu-west-1", []*Command{
+		{"create", "instance", map[string]interface{}{"type": "t2.micro"}},
+		{"delete", "natgateway", map[string]interface{}{"id": "nat-1"}},
+		{"delete", "volume", map[string]interface{}{"id": "vol-1"}},
+	}, nil)
+
+	var buff bytes.Buffer
+	estimate.Print(&buff)
+	for _, expect := range []string{"+$730.00", "-$35.04", "unknown resource", "Total", "+$694.96/month"} {
+		if !strings.Contains(buff.String(), expect) {
+			t.Fatalf("got %s, want it to contain %s", buff.String(), expect)
+		}
+	}
+	if got, want := table.Regions["eu-west-1"].Instance["t2.small"], 0.025; got != want {
+		t.Fatalf("got %f, want %f", got, want)
+	}
+}
+
+func TestParseInvalidPriceTable(t *testing.T) {
+	if _, err := ParsePriceTable([]byte("regions:\n  eu-west-1:\n    instances: {}\n")); err == nil {
+		t.Fatal("expected error got none")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awscost
+
+// defaultPriceTable holds approximate on-demand Linux and MySQL prices.
+// Users update or complete them with their own price table file
+const defaultPriceTable = `updated: "2017-12-01"
+regions:
+  us-east-1:
+    instance:
+      t2.nano: 0.0058
+      t2.micro: 0.0116
+      t2.small: 0.023
+      t2.medium: 0.0464
+      t2.large: 0.0928
+      t2.xlarge: 0.1856
+      m4.large: 0.1
+      m4.xlarge: 0.2
+      m5.large: 0.096
+      m5.xlarge: 0.192
+      c4.large: 0.1
+      c5.large: 0.085
+      c5.xlarge: 0.17
+      r4.large: 0.133
+    database:
+      db.t2.micro: 0.017
+      db.t2.small: 0.034
+      db.t2.medium: 0.068
+      db.t2.large: 0.136
+      db.m4.large: 0.175
+      db.m4.xlarge: 0.35
+      db.r4.large: 0.24
+    volume:
+      standard: 0.05
+      gp2: 0.1
+      io1: 0.125
+      st1: 0.045
+      sc1: 0.025
+    database-storage:
+      standard: 0.1
+      gp2: 0.115
+      io1: 0.125
+    natgateway: 0.045
+    loadbalancer: 0.0225
+  eu-west-1:
+    instance:
+      t2.nano: 0.0063
+      t2.micro: 0.0126
+      t2.small: 0.025
+      t2.medium: 0.05
+      t2.large: 0.101
+      t2.xlarge: 0.202
+      m4.large: 0.111
+      m4.xlarge: 0.222
+      m5.large: 0.107
+      m5.xlarge: 0.214
+      c4.large: 0.113
+      c5.large: 0.096
+      c5.xlarge: 0.192
+      r4.large: 0.148
+    database:
+      db.t2.micro: 0.018
+      db.t2.small: 0.036
+      db.t2.medium: 0.072
+      db.t2.large: 0.145
+      db.m4.large: 0.193
+      db.m4.xlarge: 0.386
+      db.r4.large: 0.265
+    volume:
+      standard: 0.055
+      gp2: 0.11
+      io1: 0.138
+      st1: 0.05
+      sc1: 0.028
+    database-storage:
+      standard: 0.11
+      gp2: 0.127
+      io1: 0.138
+    natgateway: 0.048
+    loadbalancer: 0.0252
+`
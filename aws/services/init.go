@@ -30,13 +30,13 @@ var (
 	AccessService, InfraService, StorageService, MessagingService, DnsService, LambdaService, MonitoringService, CdnService, CloudformationService cloud.Service
 )
 
-func Init(profile, region string, extraConf map[string]interface{}, log *logger.Logger, profileSetterCallback func(val string) error, enableNetworkMonitor bool) error {
+func Init(profile, region string, extraConf map[string]interface{}, log *logger.Logger, profileSetterCallback func(val string) error, enableNetworkMonitor, nonInteractive bool) error {
 	if region == "" {
 		return errors.New("empty AWS region. Set it with `awless config set aws.region`")
 	}
 
 	sb := newSessionResolver().withRegion(region).withProfile(profile).withNetworkMonitor(enableNetworkMonitor)
-	sb = sb.withProfileSetter(profileSetterCallback).withLogger(log).withCredentialResolvers()
+	sb = sb.withProfileSetter(profileSetterCallback).withLogger(log).withCredentialResolvers().withNonInteractive(nonInteractive)
 
 	sess, err := sb.resolve()
 	if err != nil {
@@ -67,6 +67,7 @@ type sessionResolver struct {
 	enableRequestsFullLogging            bool
 	enableNetworkMonitorRequestsHandlers bool
 	enableCredentialResolvers            bool
+	nonInteractive                       bool
 }
 
 func newSessionResolver() *sessionResolver {
@@ -93,6 +94,11 @@ func (s *sessionResolver) withCredentialResolvers() *sessionResolver {
 	return s
 }
 
+func (s *sessionResolver) withNonInteractive(nonInteractive bool) *sessionResolver {
+	s.nonInteractive = nonInteractive
+	return s
+}
+
 func (s *sessionResolver) withProfileSetter(f func(val string) error) *sessionResolver {
 	s.profileSetterCallback = f
 	return s
@@ -143,24 +149,26 @@ func (s *sessionResolver) resolve() (*session.Session, error) {
 	}
 
 	if s.enableCredentialResolvers {
-		session.Config.Credentials = credentials.NewCredentials(
-			&credentials.ChainProvider{
-				VerboseErrors: true,
-				Providers: []credentials.Provider{
-					&fileCacheProvider{
-						creds:   session.Config.Credentials,
-						profile: s.profile,
-						log:     s.logger,
-					},
-					&credentialsPrompterProvider{
-						profile: s.profile,
-						out:     os.Stderr,
-						profileSetterCallback: s.profileSetterCallback,
-					},
-				},
+		providers := []credentials.Provider{
+			&fileCacheProvider{
+				creds:   session.Config.Credentials,
+				profile: s.profile,
+				log:     s.logger,
+			},
+		}
+		if !s.nonInteractive {
+			providers = append(providers, &credentialsPrompterProvider{
+				profile:               s.profile,
+				out:                   os.Stderr,
+				profileSetterCallback: s.profileSetterCallback,
 			})
+		}
+		session.Config.Credentials = credentials.NewCredentials(&credentials.ChainProvider{VerboseErrors: true, Providers: providers})
 
 		if _, err = session.Config.Credentials.Get(); err != nil {
+			if s.nonInteractive {
+				return session, &MissingCredentialsError{Profile: s.profile, Err: err}
+			}
 			return session, err
 		}
 	}
@@ -169,3 +177,14 @@ func (s *sessionResolver) resolve() (*session.Session, error) {
 
 	return session, nil
 }
+
+// MissingCredentialsError is returned in non-interactive mode when no credentials
+// could be resolved for a profile, instead of prompting for them
+type MissingCredentialsError struct {
+	Profile string
+	Err     error
+}
+
+func (e *MissingCredentialsError) Error() string {
+	return fmt.Sprintf("cannot resolve AWS credentials for profile '%s': %s", e.Profile, e.Err)
+}
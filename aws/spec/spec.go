@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/wallix/awless/template/env"
 	"github.com/wallix/awless/template/params"
 
@@ -219,7 +220,28 @@ func BoolValue(v *bool) bool {
 
 func decorateAWSError(err error) error {
 	if aerr, ok := err.(awserr.Error); ok {
-		return fmt.Errorf("%s: %s", aerr.Code(), aerr.Message())
+		return &awsError{aerr}
 	}
 	return err
 }
+
+// awsError is an AWS error as reported by the commands, telling the template
+// run policies whether it is transient
+type awsError struct {
+	err awserr.Error
+}
+
+func (e *awsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.err.Code(), e.err.Message())
+}
+
+// Retryable returns true on throttling, server and transient request errors
+func (e *awsError) Retryable() bool {
+	if reqErr, ok := e.err.(awserr.RequestFailure); ok && reqErr.StatusCode() >= 500 {
+		return true
+	}
+	if request.IsErrorExpiredCreds(e.err) {
+		return false
+	}
+	return request.IsErrorThrottle(e.err) || request.IsErrorRetryable(e.err)
+}
@@ -3,6 +3,8 @@ package awsspec
 import (
 	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
 )
 
 func checkErrs(t *testing.T, errs []error, length int, expected ...string) {
@@ -54,3 +56,30 @@ func TestEnumValidator(t *testing.T) {
 		}
 	}
 }
+
+func TestDecoratedAWSErrorsRetryable(t *testing.T) {
+	tcases := []struct {
+		err       error
+		retryable bool
+	}{
+		{awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil), true},
+		{awserr.New("Throttling", "Rate exceeded", nil), true},
+		{awserr.NewRequestFailure(awserr.New("InternalError", "An internal error has occurred", nil), 503, "req-1"), true},
+		{awserr.New("AccessDenied", "Access Denied", nil), false},
+		{awserr.NewRequestFailure(awserr.New("InvalidParameterValue", "Invalid value", nil), 400, "req-2"), false},
+		{awserr.New("ExpiredToken", "The security token included in the request is expired", nil), false},
+	}
+	for i, tcase := range tcases {
+		err := decorateAWSError(tcase.err)
+		retryable, ok := err.(interface{ Retryable() bool })
+		if !ok {
+			t.Fatalf("%d: expected retryable error", i+1)
+		}
+		if got, want := retryable.Retryable(), tcase.retryable; got != want {
+			t.Fatalf("%d: got %t, want %t", i+1, got, want)
+		}
+		if !strings.HasPrefix(err.Error(), tcase.err.(awserr.Error).Code()+": ") {
+			t.Fatalf("%d: got %s", i+1, err)
+		}
+	}
+}
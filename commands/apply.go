@@ -0,0 +1,168 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/cloud/properties"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/logger"
+	"github.com/wallix/awless/sync"
+	"github.com/wallix/awless/template"
+)
+
+func init() {
+	RootCmd.AddCommand(applyCmd)
+}
+
+var applyCmd = &cobra.Command{
+	Use:               "apply PLAN",
+	Short:             "Run a plan saved with `awless run --plan-out`, exactly as planned",
+	Long:              "Run a plan saved with `awless run --plan-out` without prompting: its holes, aliases, functions and exists conditions are the ones resolved when planned. The plan is refused if made for another profile or region, or if the locally synced resources it references changed since it was made.",
+	Example:           "  awless run infra.aws --plan-out infra.plan\n  awless apply infra.plan",
+	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook, initCloudServicesHook, initSyncerHook, firstInstallDoneHook),
+	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade, networkMonitorHook),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("missing PLAN arg (filepath)")
+		}
+
+		content, err := ioutil.ReadFile(args[0])
+		exitOn(err)
+		plan, err := template.ParsePlan(content)
+		exitOn(err)
+
+		if plan.Profile != config.GetAWSProfile() || plan.Region != config.GetAWSRegion() {
+			exitOn(fmt.Errorf("plan was made for profile '%s' in region '%s' (apply it with `-p %s -r %s`)", plan.Profile, plan.Region, plan.Profile, plan.Region))
+		}
+
+		g, err := sync.LoadLocalGraphs(plan.Profile, plan.Region)
+		exitOn(err)
+		exitOn(checkPlanResources(plan, g))
+
+		tpl, err := template.Parse(plan.Template)
+		exitOn(err)
+
+		runner := NewRunnerRequiredParamsOnly(tpl, plan.Message, plan.Path)
+		runner.MissingHolesFunc = nil
+		runner.ExistsFunc = plan.ExistsFunc()
+		runner.BeforeRun = func(tplExec *template.TemplateExecution) (bool, error) {
+			if compiled := tplExec.Template.String(); compiled != plan.Template {
+				return false, fmt.Errorf("refusing to apply plan: template compiles differently than when planned on %s:\n%s", plan.Created.Format(time.RFC3339), compiled)
+			}
+			if err := enforcePolicy(tplExec); err != nil {
+				return false, err
+			}
+			resolveTemplateAuthor(tplExec)
+			return true, nil
+		}
+		exitOn(runner.Run())
+
+		return nil
+	},
+}
+
+func writePlan(tplExec *template.TemplateExecution, path string, exists map[string]bool) error {
+	plan := &template.Plan{
+		Version:  config.Version,
+		Created:  time.Now().UTC(),
+		Profile:  config.GetAWSProfile(),
+		Region:   config.GetAWSRegion(),
+		Path:     tplExec.Path,
+		Message:  tplExec.Message,
+		Template: tplExec.Template.String(),
+		Exists:   exists,
+	}
+	g, err := sync.LoadLocalGraphs(plan.Profile, plan.Region)
+	if err != nil {
+		return fmt.Errorf("plan: %s", err)
+	}
+	if plan.Resources, err = resourcesFingerprints(g, tplExec.Template.ParamValues()); err != nil {
+		return fmt.Errorf("plan: %s", err)
+	}
+
+	content, err := plan.Marshal()
+	if err != nil {
+		return fmt.Errorf("plan: %s", err)
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("plan: %s", err)
+	}
+	logger.Infof("Plan saved in %s. Run it with `awless apply %s`", path, path)
+	return nil
+}
+
+// checkPlanResources fails listing the resources referenced by the plan
+// that were removed or modified in the local graph since it was made
+func checkPlanResources(plan *template.Plan, g cloud.GraphAPI) error {
+	var ids []string
+	for id := range plan.Resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	current, err := resourcesFingerprints(g, ids)
+	if err != nil {
+		return err
+	}
+
+	var changed bytes.Buffer
+	for _, id := range ids {
+		fingerprint, ok := current[id]
+		switch {
+		case !ok:
+			fmt.Fprintf(&changed, "\n\t- %s: removed", id)
+		case fingerprint != plan.Resources[id]:
+			fmt.Fprintf(&changed, "\n\t- %s: modified", id)
+		}
+	}
+	if changed.Len() > 0 {
+		return fmt.Errorf("refusing to apply plan: referenced resources changed since planned on %s:%s", plan.Created.Format(time.RFC3339), changed.String())
+	}
+	return nil
+}
+
+// resourcesFingerprints returns the fingerprints of the properties
+// of the resources of the graph with the given IDs, if they exist
+func resourcesFingerprints(g cloud.GraphAPI, ids []string) (map[string]string, error) {
+	fingerprints := make(map[string]string)
+	for _, id := range ids {
+		resources, err := g.FindWithProperties(map[string]interface{}{properties.ID: id})
+		if err != nil {
+			return fingerprints, err
+		}
+		if len(resources) != 1 {
+			continue
+		}
+		b, err := json.Marshal(resources[0].Properties())
+		if err != nil {
+			return fingerprints, err
+		}
+		fingerprints[id] = fmt.Sprintf("%x", sha256.Sum256(b))
+	}
+	return fingerprints, nil
+}
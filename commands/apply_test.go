@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/graph"
+	"github.com/wallix/awless/graph/resourcetest"
+	"github.com/wallix/awless/template"
+)
+
+func TestCheckPlanResources(t *testing.T) {
+	planned := graph.NewGraph()
+	planned.AddResource(
+		resourcetest.Subnet("sub_1").Prop("CIDR", "10.0.0.0/24").Build(),
+		resourcetest.Subnet("sub_2").Prop("CIDR", "10.0.1.0/24").Build(),
+		resourcetest.Instance("inst_1").Prop("State", "running").Build(),
+	)
+	fingerprints, err := resourcesFingerprints(planned, []string{"sub_1", "sub_2", "inst_1", "10.0.0.0/24", "t2.micro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(fingerprints), 3; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+	plan := &template.Plan{Resources: fingerprints}
+
+	if err := checkPlanResources(plan, planned); err != nil {
+		t.Fatal(err)
+	}
+
+	current := graph.NewGraph()
+	current.AddResource(
+		resourcetest.Subnet("sub_1").Prop("CIDR", "10.0.0.0/24").Build(),
+		resourcetest.Instance("inst_1").Prop("State", "stopped").Build(),
+	)
+	err = checkPlanResources(plan, current)
+	if err == nil {
+		t.Fatal("expected error got none")
+	}
+	if got, want := err.Error(), "\n\t- inst_1: modified\n\t- sub_2: removed"; !strings.HasSuffix(got, want) {
+		t.Fatalf("got %s, want suffix %s", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/cloud/properties"
+	"github.com/wallix/awless/cloud/rdf"
+	"github.com/wallix/awless/graph"
+)
+
+var cascadeFlag bool
+
+// cascadeDeleteOrder is the order in which the resources of a VPC are deleted:
+// instances and gateways holding network interfaces in the subnets first,
+// the main route table and default security group being deleted with the VPC
+var cascadeDeleteOrder = []string{
+	cloud.Instance,
+	cloud.LoadBalancer,
+	cloud.TargetGroup,
+	cloud.NatGateway,
+	cloud.InternetGateway,
+	cloud.Subnet,
+	cloud.RouteTable,
+	cloud.SecurityGroup,
+}
+
+// cascadeDeleteVpcTemplate returns the template deleting the VPC of the local graph
+// given by ID or @name, along with its children and the resources depending on them.
+// It fails listing the resources it cannot delete, the VPC deletion failing otherwise
+func cascadeDeleteVpcTemplate(g *graph.Graph, ref string) (string, error) {
+	vpc, err := findLocalResource(g, ref, cloud.Vpc)
+	if err != nil {
+		return "", fmt.Errorf("cascade: %s", err)
+	}
+
+	byType := make(map[string][]*graph.Resource)
+	seen := make(map[string]bool)
+	collect := func(res *graph.Resource, depth int) error {
+		if seen[res.Id()] {
+			return nil
+		}
+		seen[res.Id()] = true
+		byType[res.Type()] = append(byType[res.Type()], res)
+		return nil
+	}
+	if err := g.Accept(&graph.ChildrenVisitor{From: vpc, Relation: rdf.ParentOf, Each: collect}); err != nil {
+		return "", err
+	}
+	dependedOn := append([]*graph.Resource{vpc}, byType[cloud.Subnet]...)
+	for _, res := range append(dependedOn, byType[cloud.SecurityGroup]...) {
+		dependents, err := g.ListResourcesDependingOn(res)
+		if err != nil {
+			return "", err
+		}
+		for _, dep := range dependents {
+			collect(dep, 0)
+		}
+	}
+	for _, resources := range byType {
+		sort.Slice(resources, func(i, j int) bool { return resources[i].Id() < resources[j].Id() })
+	}
+	if unhandled := unhandledCascadeResources(byType, seen); len(unhandled) > 0 {
+		return "", fmt.Errorf("cascade: cannot delete resources of vpc %s: %s", vpc.Id(), strings.Join(unhandled, ", "))
+	}
+
+	var buff bytes.Buffer
+	for _, typ := range cascadeDeleteOrder {
+		for _, res := range byType[typ] {
+			switch typ {
+			case cloud.Instance:
+				if state, _ := res.Property(properties.State); state == "terminated" {
+					continue
+				}
+				fmt.Fprintf(&buff, "delete instance id=%s\n", res.Id())
+				fmt.Fprintf(&buff, "check instance id=%s state=terminated timeout=300\n", res.Id())
+			case cloud.LoadBalancer:
+				fmt.Fprintf(&buff, "delete loadbalancer id=%s\n", res.Id())
+				fmt.Fprintf(&buff, "check loadbalancer id=%s state=not-found timeout=180\n", res.Id())
+			case cloud.NatGateway:
+				if state, _ := res.Property(properties.State); state == "deleted" {
+					continue
+				}
+				fmt.Fprintf(&buff, "delete natgateway id=%s\n", res.Id())
+				fmt.Fprintf(&buff, "check natgateway id=%s state=deleted timeout=300\n", res.Id())
+			case cloud.InternetGateway:
+				fmt.Fprintf(&buff, "detach internetgateway id=%s vpc=%s\n", res.Id(), vpc.Id())
+				fmt.Fprintf(&buff, "delete internetgateway id=%s\n", res.Id())
+			case cloud.RouteTable:
+				if main, _ := res.Property(properties.Default); main == true {
+					continue
+				}
+				fmt.Fprintf(&buff, "delete routetable id=%s\n", res.Id())
+			case cloud.SecurityGroup:
+				if name, _ := res.Property(properties.Name); name == "default" {
+					continue
+				}
+				fmt.Fprintf(&buff, "delete securitygroup id=%s\n", res.Id())
+			default:
+				fmt.Fprintf(&buff, "delete %s id=%s\n", typ, res.Id())
+			}
+		}
+	}
+	fmt.Fprintf(&buff, "delete vpc id=%s", vpc.Id())
+
+	return buff.String(), nil
+}
+
+// unhandledCascadeResources returns the collected resources the cascade does not
+// delete, except those deleted along with others: load balancer listeners and the
+// network interfaces of instances, NAT gateways and load balancers
+func unhandledCascadeResources(byType map[string][]*graph.Resource, seen map[string]bool) (unhandled []string) {
+	handled := make(map[string]bool)
+	for _, typ := range cascadeDeleteOrder {
+		handled[typ] = true
+	}
+	notFound := graph.NotFoundResource("").Type()
+	for typ, resources := range byType {
+		if handled[typ] || typ == cloud.Listener || typ == notFound {
+			continue
+		}
+		for _, res := range resources {
+			if typ == cloud.NetworkInterface && deletedWithOwner(res, byType, seen) {
+				continue
+			}
+			unhandled = append(unhandled, fmt.Sprintf("%s %s", typ, res.Id()))
+		}
+	}
+	sort.Strings(unhandled)
+	return
+}
+
+func deletedWithOwner(networkInterface *graph.Resource, byType map[string][]*graph.Resource, seen map[string]bool) bool {
+	if instance, ok := networkInterface.Property(properties.Instance); ok {
+		return seen[fmt.Sprint(instance)]
+	}
+	typ, _ := networkInterface.Property(properties.Type)
+	description, _ := networkInterface.Property(properties.Description)
+	switch {
+	case typ == "natGateway":
+		return len(byType[cloud.NatGateway]) > 0
+	case strings.HasPrefix(fmt.Sprint(description), "ELB "):
+		return len(byType[cloud.LoadBalancer]) > 0
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/graph"
+	"github.com/wallix/awless/graph/resourcetest"
+	"github.com/wallix/awless/template"
+)
+
+func TestCascadeDeleteVpcTemplate(t *testing.T) {
+	g := graph.NewGraph()
+	vpc := resourcetest.VPC("vpc_1").Prop("Name", "my-vpc").Build()
+	igw := resourcetest.InternetGw("igw_1").Build()
+	sub1 := resourcetest.Subnet("sub_1").Build()
+	nat := resourcetest.NatGw("nat_1").Prop("State", "available").Build()
+	g.AddResource(
+		vpc, igw, sub1, nat,
+		resourcetest.VPC("vpc_2").Build(),
+		resourcetest.Subnet("sub_2").Build(),
+		resourcetest.Instance("inst_1").Prop("State", "running").Build(),
+		resourcetest.Instance("inst_2").Prop("State", "terminated").Build(),
+		resourcetest.RouteTable("rt_main").Prop("Default", true).Build(),
+		resourcetest.RouteTable("rt_1").Prop("Default", false).Build(),
+		resourcetest.SecurityGroup("sg_default").Prop("Name", "default").Build(),
+		resourcetest.SecurityGroup("sg_1").Prop("Name", "web").Build(),
+		resourcetest.LoadBalancer("lb_1").Build(),
+		resourcetest.Subnet("sub_other").Build(),
+	)
+	resourcetest.AddParents(g,
+		"vpc_1 -> sub_1", "vpc_1 -> sub_2", "vpc_1 -> rt_main", "vpc_1 -> rt_1", "vpc_1 -> sg_default",
+		"vpc_1 -> sg_1", "vpc_1 -> lb_1", "vpc_1 -> nat_1", "sub_1 -> inst_1", "sub_2 -> inst_2",
+		"vpc_2 -> sub_other",
+	)
+	g.AddAppliesOnRelation(igw, vpc)
+	g.AddAppliesOnRelation(nat, sub1)
+
+	exp := `delete instance id=inst_1
+check instance id=inst_1 state=terminated timeout=300
+delete loadbalancer id=lb_1
+check loadbalancer id=lb_1 state=not-found timeout=180
+delete natgateway id=nat_1
+check natgateway id=nat_1 state=deleted timeout=300
+detach internetgateway id=igw_1 vpc=vpc_1
+delete internetgateway id=igw_1
+delete subnet id=sub_1
+delete subnet id=sub_2
+delete routetable id=rt_1
+delete securitygroup id=sg_1
+delete vpc id=vpc_1`
+
+	for _, ref := range []string{"vpc_1", "@my-vpc"} {
+		text, err := cascadeDeleteVpcTemplate(g, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := text, exp; got != want {
+			t.Fatalf("got\n%s\n\nwant\n%s", got, want)
+		}
+		if _, err := template.Parse(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := cascadeDeleteVpcTemplate(g, "vpc_3"); err == nil || !strings.Contains(err.Error(), "found 0 vpc(s)") {
+		t.Fatalf("got %v, want not found error", err)
+	}
+}
+
+func TestCascadeDeleteVpcWithUnhandledResources(t *testing.T) {
+	g := graph.NewGraph()
+	vpc := resourcetest.VPC("vpc_1").Build()
+	sg := resourcetest.SecurityGroup("sg_1").Prop("Name", "db").Build()
+	db := resourcetest.Database("db_1").Build()
+	g.AddResource(
+		vpc, sg, db,
+		resourcetest.Subnet("sub_1").Build(),
+		resourcetest.Instance("inst_1").Prop("State", "running").Build(),
+		resourcetest.NetworkInterface("eni_1").Prop("Instance", "inst_1").Build(),
+		resourcetest.NetworkInterface("eni_2").Prop("State", "available").Build(),
+		resourcetest.LoadBalancer("lb_1").Build(),
+		resourcetest.Listener("lst_1").Build(),
+	)
+	resourcetest.AddParents(g, "vpc_1 -> sub_1", "vpc_1 -> sg_1", "vpc_1 -> lb_1", "lb_1 -> lst_1", "sub_1 -> inst_1", "sub_1 -> eni_1", "sub_1 -> eni_2")
+	g.AddAppliesOnRelation(db, sg)
+
+	_, err := cascadeDeleteVpcTemplate(g, "vpc_1")
+	if err == nil {
+		t.Fatal("expected error for unhandled resources")
+	}
+	if got, want := err.Error(), "cascade: cannot delete resources of vpc vpc_1: database db_1, networkinterface eni_2"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
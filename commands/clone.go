@@ -0,0 +1,638 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/cloud/match"
+	"github.com/wallix/awless/cloud/properties"
+	"github.com/wallix/awless/cloud/rdf"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/graph"
+	"github.com/wallix/awless/sync"
+	"github.com/wallix/awless/template"
+)
+
+var exportChildrenFlag bool
+
+func init() {
+	RootCmd.AddCommand(cloneCmd)
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportTemplateCmd)
+
+	exportTemplateCmd.Flags().BoolVar(&exportChildrenFlag, "children", false, "Export also the children of the resource. A VPC is exported with its whole network: gateways, subnets, route tables, security groups with their rules and instances")
+}
+
+var cloneCmd = &cobra.Command{
+	Use:               "clone ENTITY REF [param=value ...]",
+	Short:             "Create a copy of a resource of the local graph, overriding its params if needed",
+	Long:              fmt.Sprintf("Create a copy of a resource given by ID or @name, reading its properties from the local graph. Cloneable entities: %s", strings.Join(cloneEntities, ", ")),
+	Example:           "  awless clone instance @web-1 name=web-2\n  awless clone subnet subnet-12345678 cidr=10.0.2.0/24 name=backend",
+	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook, initCloudServicesHook, initSyncerHook, firstInstallDoneHook),
+	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade, networkMonitorHook),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("missing ENTITY and REF (ex: awless clone instance @web-1 name=web-2)")
+		}
+		if _, ok := cloneProperties[args[0]]; !ok {
+			return fmt.Errorf("cannot clone '%s', expecting one of: %s", args[0], strings.Join(cloneEntities, ", "))
+		}
+
+		g, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+		exitOn(err)
+		res, err := findLocalResource(g.(*graph.Graph), args[1], args[0])
+		exitOn(err)
+		text, err := cloneTemplate(res, args[2:])
+		exitOn(err)
+
+		templ, err := template.Parse(text)
+		exitOn(err)
+
+		tplExec := &template.TemplateExecution{
+			Template: templ,
+			Locale:   config.GetAWSRegion(),
+			Profile:  config.GetAWSProfile(),
+			Source:   templ.String(),
+		}
+
+		exitOn(NewRunner(tplExec.Template, tplExec.Message, tplExec.Path).Run())
+		return nil
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export resources of the local graph",
+}
+
+var exportTemplateCmd = &cobra.Command{
+	Use:               "template REF",
+	Short:             "Print the template recreating a resource of the local graph given by ID or @name",
+	Long:              "Print the template recreating a resource of the local graph given by ID or @name. Resources are created in dependency order, referencing each other through template variables instead of IDs",
+	Example:           "  awless export template @my-vpc --children > my-vpc.aws\n  awless export template i-1234567890abcdef0",
+	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook),
+	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("missing REF (resource ID or @name)")
+		}
+
+		g, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+		exitOn(err)
+		res, err := findLocalResource(g.(*graph.Graph), args[0], exportOrder...)
+		exitOn(err)
+		text, err := exportTemplate(g.(*graph.Graph), res, exportChildrenFlag)
+		exitOn(err)
+
+		fmt.Fprintln(os.Stdout, text)
+		return nil
+	},
+}
+
+// cloneProperties maps, per entity that can be cloned, the create params
+// to the properties of the resources in the local graph holding their values
+var cloneProperties = map[string]map[string]string{
+	cloud.Instance: {
+		"type":          properties.Type,
+		"image":         properties.Image,
+		"subnet":        properties.Subnet,
+		"keypair":       properties.KeyPair,
+		"securitygroup": properties.SecurityGroups,
+		"name":          properties.Name,
+	},
+	cloud.Subnet: {
+		"cidr":             properties.CIDR,
+		"vpc":              properties.Vpc,
+		"availabilityzone": properties.AvailabilityZone,
+		"public":           properties.Public,
+		"name":             properties.Name,
+	},
+	cloud.Vpc: {
+		"cidr": properties.CIDR,
+		"name": properties.Name,
+	},
+	cloud.SecurityGroup: {
+		"name":        properties.Name,
+		"description": properties.Description,
+		"vpc":         properties.Vpc,
+	},
+	cloud.Volume: {
+		"availabilityzone": properties.AvailabilityZone,
+		"size":             properties.Size,
+		"encrypted":        properties.Encrypted,
+	},
+	cloud.TargetGroup: {
+		"name":                properties.Name,
+		"port":                properties.Port,
+		"protocol":            properties.Protocol,
+		"vpc":                 properties.Vpc,
+		"healthcheckinterval": properties.CheckInterval,
+		"healthcheckpath":     properties.CheckPath,
+		"healthcheckport":     properties.CheckPort,
+		"healthcheckprotocol": properties.CheckProtocol,
+		"healthchecktimeout":  properties.CheckTimeout,
+		"healthythreshold":    properties.HealthyThresholdCount,
+		"unhealthythreshold":  properties.UnhealthyThresholdCount,
+		"matcher":             properties.CheckHTTPCode,
+	},
+	cloud.LoadBalancer: {
+		"name":    properties.Name,
+		"subnets": properties.Subnets,
+		"scheme":  properties.Scheme,
+		"type":    properties.Type,
+		"iptype":  properties.IPType,
+	},
+}
+
+// cloneDefaults holds the required create params not found in the graph
+var cloneDefaults = map[string]map[string]string{
+	cloud.Instance: {"count": "1"},
+}
+
+// cloneEntities lists the entities that can be cloned
+var cloneEntities = []string{
+	cloud.Instance,
+	cloud.LoadBalancer,
+	cloud.SecurityGroup,
+	cloud.Subnet,
+	cloud.TargetGroup,
+	cloud.Volume,
+	cloud.Vpc,
+}
+
+// findLocalResource returns the resource of the given types in the local graph
+// with the ref as ID, or as name when prefixed with @
+func findLocalResource(g *graph.Graph, ref string, entities ...string) (*graph.Resource, error) {
+	matcher := match.Property(properties.ID, ref)
+	if strings.HasPrefix(ref, "@") {
+		matcher = match.Property(properties.Name, strings.TrimPrefix(ref, "@"))
+	}
+	var resources []cloud.Resource
+	for _, entity := range entities {
+		found, err := g.Find(cloud.NewQuery(entity).Match(matcher))
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, found...)
+	}
+	if len(resources) != 1 {
+		label := "resource"
+		if len(entities) == 1 {
+			label = entities[0]
+		}
+		return nil, fmt.Errorf("found %d %s(s) for '%s' in local graph, expecting 1 (try `awless sync`)", len(resources), label, ref)
+	}
+	return resources[0].(*graph.Resource), nil
+}
+
+// cloneTemplate returns the command creating a copy of the resource,
+// with its params overridden by the given param=value ones
+func cloneTemplate(res *graph.Resource, overrides []string) (string, error) {
+	params := createParams(res, nil)
+	for _, o := range overrides {
+		splits := strings.SplitN(o, "=", 2)
+		if len(splits) != 2 || splits[0] == "" {
+			return "", fmt.Errorf("invalid param '%s', expecting param=value", o)
+		}
+		params[splits[0]] = splits[1]
+	}
+	return fmt.Sprintf("create %s %s", res.Type(), joinParams(params)), nil
+}
+
+// exportTemplate returns the template creating the resource, along with its
+// exportable children when requested. Exporting a VPC with its children
+// reverse engineers its network: internet and NAT gateways, route tables with
+// their routes and subnet associations, security groups with their rules.
+// Params referencing exported resources are wired to the declarations creating them
+func exportTemplate(g *graph.Graph, res *graph.Resource, withChildren bool) (string, error) {
+	resources, err := exportedResources(g, res, withChildren)
+	if err != nil {
+		return "", err
+	}
+
+	e := &templateExporter{g: g, vars: make(map[string]string), used: make(map[string]bool), taken: make(map[string]bool)}
+	for _, r := range resources {
+		e.vars[r.Id()] = declarationName(r, e.taken)
+	}
+	var lines, rules []*exportLine
+	for i, r := range resources {
+		lines = append(lines, e.commands(r, resources)...)
+		if r.Type() == cloud.SecurityGroup {
+			rules = append(rules, e.rules(r)...)
+		}
+		// rules are authorized once all security groups they may reference are created
+		if i == len(resources)-1 || resources[i+1].Type() != cloud.SecurityGroup {
+			lines = append(lines, rules...)
+			rules = nil
+		}
+	}
+
+	var all []string
+	for _, l := range lines {
+		switch {
+		case l.declare != "":
+			all = append(all, fmt.Sprintf("%s = %s", l.declare, l.text))
+		case l.id != "" && e.used[l.id]:
+			all = append(all, fmt.Sprintf("%s = %s", e.vars[l.id], l.text))
+		default:
+			all = append(all, l.text)
+		}
+	}
+	return strings.Join(all, "\n"), nil
+}
+
+// exportOrder is the order in which exported resources are created,
+// the resources being created after the ones they reference
+var exportOrder = []string{
+	cloud.Vpc,
+	cloud.InternetGateway,
+	cloud.Subnet,
+	cloud.NatGateway,
+	cloud.RouteTable,
+	cloud.SecurityGroup,
+	cloud.TargetGroup,
+	cloud.LoadBalancer,
+	cloud.Volume,
+	cloud.Instance,
+}
+
+// exportedResources returns, sorted in creation order, the resource and when
+// requested its children, along with the gateways and route tables depending
+// on a VPC and its subnets. Resources created along with a VPC (main route table
+// without routes to recreate, default security group) or no longer existing are
+// left out
+func exportedResources(g *graph.Graph, res *graph.Resource, withChildren bool) ([]*graph.Resource, error) {
+	resources := []*graph.Resource{res}
+	seen := map[string]bool{res.Id(): true}
+	collect := func(r *graph.Resource, depth int) error {
+		if !seen[r.Id()] && isExported(r) {
+			seen[r.Id()] = true
+			resources = append(resources, r)
+		}
+		return nil
+	}
+	if withChildren {
+		if err := g.Accept(&graph.ChildrenVisitor{From: res, Relation: rdf.ParentOf, Each: collect}); err != nil {
+			return nil, err
+		}
+		if res.Type() == cloud.Vpc {
+			for _, r := range resources {
+				if r.Type() != cloud.Vpc && r.Type() != cloud.Subnet {
+					continue
+				}
+				dependents, err := g.ListResourcesDependingOn(r)
+				if err != nil {
+					return nil, err
+				}
+				for _, dep := range dependents {
+					collect(dep, 0)
+				}
+			}
+		}
+	}
+
+	rank := make(map[string]int)
+	for i, typ := range exportOrder {
+		rank[typ] = i
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i] == res || resources[j] == res {
+			return resources[i] == res && resources[j] != res
+		}
+		if ri, rj := rank[resources[i].Type()], rank[resources[j].Type()]; ri != rj {
+			return ri < rj
+		}
+		return resources[i].Id() < resources[j].Id()
+	})
+	return resources, nil
+}
+
+func isExported(r *graph.Resource) bool {
+	state, _ := r.Property(properties.State)
+	switch r.Type() {
+	case cloud.Instance:
+		return state != "terminated" && state != "shutting-down"
+	case cloud.NatGateway:
+		return state != "deleted" && state != "deleting" && state != "failed"
+	case cloud.SecurityGroup:
+		name, _ := r.Property(properties.Name)
+		return name != "default"
+	case cloud.RouteTable:
+		if main, _ := r.Property(properties.Default); main == true {
+			return len(exportedRoutes(r)) > 0
+		}
+		return true
+	}
+	_, ok := cloneProperties[r.Type()]
+	return ok || r.Type() == cloud.InternetGateway
+}
+
+// exportedRoutes returns the routes of the route table, but the local ones
+func exportedRoutes(r *graph.Resource) (routes []*graph.Route) {
+	all, _ := r.Property(properties.Routes)
+	list, _ := all.([]*graph.Route)
+	for _, route := range list {
+		local := false
+		for _, t := range route.Targets {
+			if t.Type == graph.GatewayTarget && t.Ref == "local" {
+				local = true
+			}
+		}
+		if !local {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].String() < routes[j].String() })
+	return
+}
+
+type exportLine struct {
+	declare string // variable always declared, for resources not in the graph
+	id      string // ID of the resource whose variable is declared if referenced
+	text    string
+}
+
+type templateExporter struct {
+	g     *graph.Graph
+	vars  map[string]string
+	used  map[string]bool
+	taken map[string]bool
+}
+
+// ref returns the reference to the declaration creating the resource with the ID.
+// An empty reference is returned for the default security groups of the exported
+// VPCs, created along with them
+func (e *templateExporter) ref(id string) (string, bool) {
+	if name, ok := e.vars[id]; ok {
+		e.used[id] = true
+		return "$" + name, true
+	}
+	if r, err := e.g.FindResource(id); err == nil && r != nil && r.Type() == cloud.SecurityGroup {
+		name, _ := r.Property(properties.Name)
+		vpc, _ := r.Property(properties.Vpc)
+		if _, exported := e.vars[fmt.Sprint(vpc)]; exported && name == "default" {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+func (e *templateExporter) value(id string) string {
+	if ref, ok := e.ref(id); ok && ref != "" {
+		return ref
+	}
+	return template.QuoteParamIfNeeded(id)
+}
+
+// commands returns the commands creating the resource and, for gateways and
+// route tables, attaching them
+func (e *templateExporter) commands(r *graph.Resource, resources []*graph.Resource) (lines []*exportLine) {
+	self := e.vars[r.Id()]
+	switch r.Type() {
+	case cloud.InternetGateway:
+		lines = append(lines, &exportLine{id: r.Id(), text: "create internetgateway"})
+		vpcs, _ := r.Property(properties.Vpcs)
+		list, _ := vpcs.([]string)
+		for _, vpc := range list {
+			e.used[r.Id()] = true
+			lines = append(lines, &exportLine{text: fmt.Sprintf("attach internetgateway id=$%s vpc=%s", self, e.value(vpc))})
+		}
+	case cloud.NatGateway:
+		eip := self + "-ip"
+		for i := 2; e.taken[eip]; i++ {
+			eip = fmt.Sprintf("%s-ip_%d", self, i)
+		}
+		e.taken[eip] = true
+		e.used[r.Id()] = true
+		subnet, _ := r.Property(properties.Subnet)
+		lines = append(lines,
+			&exportLine{declare: eip, text: "create elasticip domain=vpc"},
+			&exportLine{id: r.Id(), text: fmt.Sprintf("create natgateway elasticip-id=$%s subnet=%s", eip, e.value(fmt.Sprint(subnet)))},
+			&exportLine{text: fmt.Sprintf("check natgateway id=$%s state=available timeout=180", self)},
+		)
+	case cloud.RouteTable:
+		vpc, _ := r.Property(properties.Vpc)
+		lines = append(lines, &exportLine{id: r.Id(), text: fmt.Sprintf("create routetable vpc=%s", e.value(fmt.Sprint(vpc)))})
+		for _, route := range exportedRoutes(r) {
+			lines = append(lines, e.route(r, route))
+		}
+		for _, subnet := range e.associatedSubnets(r, resources) {
+			e.used[r.Id()] = true
+			lines = append(lines, &exportLine{text: fmt.Sprintf("attach routetable id=$%s subnet=%s", self, e.value(subnet))})
+		}
+	default:
+		lines = append(lines, &exportLine{id: r.Id(), text: fmt.Sprintf("create %s %s", r.Type(), joinParams(createParams(r, e.ref)))})
+	}
+	return
+}
+
+func (e *templateExporter) route(table *graph.Resource, route *graph.Route) *exportLine {
+	var target string
+	var refs []string
+	for _, t := range route.Targets {
+		if t.Type == graph.GatewayTarget || t.Type == graph.NatTarget {
+			target = t.Ref
+		}
+		refs = append(refs, t.Ref)
+	}
+	if route.Destination == nil || target == "" {
+		dest := route.DestinationPrefixListId
+		if route.Destination != nil {
+			dest = route.Destination.String()
+		} else if route.DestinationIPv6 != nil {
+			dest = route.DestinationIPv6.String()
+		}
+		return &exportLine{text: fmt.Sprintf("# route to %s through %s not exported: only IPv4 routes through internet or NAT gateways are", dest, strings.Join(refs, ","))}
+	}
+	e.used[table.Id()] = true
+	return &exportLine{text: fmt.Sprintf("create route cidr=%s gateway=%s table=$%s", route.Destination.String(), e.value(target), e.vars[table.Id()])}
+}
+
+// associatedSubnets returns the subnets explicitly associated to the route table.
+// The exported subnets implicitly associated to a main route table are associated
+// explicitly to its copy, the main route table of the new VPC being empty
+func (e *templateExporter) associatedSubnets(table *graph.Resource, resources []*graph.Resource) (subnets []string) {
+	associated := func(r *graph.Resource) (ids []string) {
+		assocs, _ := r.Property(properties.Associations)
+		list, _ := assocs.([]*graph.KeyValue)
+		for _, kv := range list {
+			if kv.Value != "" {
+				ids = append(ids, kv.Value)
+			}
+		}
+		return
+	}
+	subnets = associated(table)
+	if main, _ := table.Property(properties.Default); main != true {
+		sort.Strings(subnets)
+		return
+	}
+	explicit := make(map[string]bool)
+	tables, _ := e.g.GetAllResources(cloud.RouteTable)
+	for _, t := range tables {
+		for _, id := range associated(t) {
+			explicit[id] = true
+		}
+	}
+	for _, r := range resources {
+		if r.Type() == cloud.Subnet && !explicit[r.Id()] {
+			subnets = append(subnets, r.Id())
+		}
+	}
+	sort.Strings(subnets)
+	return
+}
+
+// rules returns the commands authorizing the inbound and outbound rules of the
+// security group, one per CIDR or source group. The outbound rule allowing all
+// traffic is left out, new security groups having it already
+func (e *templateExporter) rules(sg *graph.Resource) (lines []*exportLine) {
+	for _, direction := range []string{"inbound", "outbound"} {
+		prop := properties.InboundRules
+		if direction == "outbound" {
+			prop = properties.OutboundRules
+		}
+		v, _ := sg.Property(prop)
+		rules, _ := v.([]*graph.FirewallRule)
+		rules = append([]*graph.FirewallRule{}, rules...)
+		sort.Slice(rules, func(i, j int) bool { return rules[i].String() < rules[j].String() })
+		for _, rule := range rules {
+			params := fmt.Sprintf("%s=authorize protocol=%s", direction, template.QuoteParamIfNeeded(rule.Protocol))
+			if rule.Protocol != "any" {
+				params += " portrange=" + exportPortRange(rule.PortRange)
+			}
+			for _, cidr := range rule.IPRanges {
+				if cidr.IP.To4() == nil {
+					lines = append(lines, &exportLine{text: fmt.Sprintf("# %s rule from %s not exported: IPv6 ranges are not supported", direction, cidr)})
+					continue
+				}
+				if direction == "outbound" && rule.Protocol == "any" && cidr.String() == "0.0.0.0/0" {
+					continue
+				}
+				e.used[sg.Id()] = true
+				lines = append(lines, &exportLine{text: fmt.Sprintf("update securitygroup cidr=%s id=$%s %s", cidr, e.vars[sg.Id()], params)})
+			}
+			for _, source := range rule.Sources {
+				ref, ok := e.ref(source)
+				switch {
+				case ok && ref == "":
+					lines = append(lines, &exportLine{text: fmt.Sprintf("# %s rule from default security group %s not exported", direction, source)})
+					continue
+				case !ok:
+					ref = template.QuoteParamIfNeeded(source)
+				}
+				e.used[sg.Id()] = true
+				lines = append(lines, &exportLine{text: fmt.Sprintf("update securitygroup id=$%s %s securitygroup=%s", e.vars[sg.Id()], params, ref)})
+			}
+		}
+	}
+	return
+}
+
+func exportPortRange(p graph.PortRange) string {
+	switch {
+	case p.Any:
+		return "any"
+	case p.FromPort == p.ToPort:
+		return fmt.Sprint(p.FromPort)
+	default:
+		return fmt.Sprintf("%d-%d", p.FromPort, p.ToPort)
+	}
+}
+
+// createParams returns the create params of the resource formatted as template values.
+// The ref func returns the template reference replacing a resource ID, if any;
+// an empty reference drops the ID
+func createParams(res *graph.Resource, ref func(id string) (string, bool)) map[string]string {
+	if ref == nil {
+		ref = func(string) (string, bool) { return "", false }
+	}
+	format := func(v interface{}) (string, bool) {
+		s := fmt.Sprint(v)
+		if r, ok := ref(s); ok {
+			return r, r != ""
+		}
+		return template.QuoteParamIfNeeded(s), true
+	}
+
+	params := make(map[string]string)
+	for param, prop := range cloneProperties[res.Type()] {
+		v, ok := res.Property(prop)
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		if list, isList := v.([]string); isList {
+			sorted := append([]string{}, list...)
+			sort.Strings(sorted)
+			var elems []string
+			for _, e := range sorted {
+				if s, keep := format(e); keep {
+					elems = append(elems, s)
+				}
+			}
+			if len(elems) > 0 {
+				params[param] = "[" + strings.Join(elems, ",") + "]"
+			}
+			continue
+		}
+		if s, keep := format(v); keep {
+			params[param] = s
+		}
+	}
+	for param, v := range cloneDefaults[res.Type()] {
+		params[param] = v
+	}
+	return params
+}
+
+func joinParams(params map[string]string) string {
+	var all []string
+	for k, v := range params {
+		all = append(all, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(all)
+	return strings.Join(all, " ")
+}
+
+var invalidDeclarationChars = regexp.MustCompile(`[^a-zA-Z0-9-_.]+`)
+
+// declarationName returns a unique template variable name for the resource,
+// derived from its name or else its type
+func declarationName(res *graph.Resource, taken map[string]bool) string {
+	base := res.Type()
+	if name, ok := res.Property(properties.Name); ok {
+		if s := invalidDeclarationChars.ReplaceAllString(fmt.Sprint(name), "_"); strings.Trim(s, "_") != "" {
+			base = s
+		}
+	}
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	taken[name] = true
+	return name
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/graph"
+	"github.com/wallix/awless/graph/resourcetest"
+	"github.com/wallix/awless/template"
+)
+
+func TestCloneAndExportTemplates(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddResource(
+		resourcetest.VPC("vpc_1").Prop("Name", "my-vpc").Prop("CIDR", "10.0.0.0/16").Build(),
+		resourcetest.Subnet("sub_1").Prop("Name", "my subnet").Prop("CIDR", "10.0.1.0/24").Prop("Vpc", "vpc_1").Prop("AvailabilityZone", "eu-west-1a").Prop("Public", true).Build(),
+		resourcetest.SecurityGroup("sg_1").Prop("Name", "web").Prop("Description", "web servers").Prop("Vpc", "vpc_1").Build(),
+		resourcetest.Instance("inst_1").Prop("Name", "web-1").Prop("Type", "t2.micro").Prop("Image", "ami-123").Prop("Subnet", "sub_1").
+			Prop("SecurityGroups", []string{"sg_1", "sg_other"}).Prop("KeyPair", "my-key").Build(),
+		resourcetest.RouteTable("rt_1").Prop("Default", true).Build(),
+	)
+	resourcetest.AddParents(g, "vpc_1 -> sub_1", "vpc_1 -> sg_1", "vpc_1 -> rt_1", "sub_1 -> inst_1")
+
+	tcases := []struct {
+		ref          string
+		withChildren bool
+		exp          string
+	}{
+		{ref: "@web-1", exp: "create instance count=1 image=ami-123 keypair=my-key name=web-1 securitygroup=[sg_1,sg_other] subnet=sub_1 type=t2.micro"},
+		{ref: "sub_1", exp: "create subnet availabilityzone=eu-west-1a cidr=10.0.1.0/24 name='my subnet' public=true vpc=vpc_1"},
+		{ref: "@my-vpc", withChildren: true, exp: `my-vpc = create vpc cidr=10.0.0.0/16 name=my-vpc
+my_subnet = create subnet availabilityzone=eu-west-1a cidr=10.0.1.0/24 name='my subnet' public=true vpc=$my-vpc
+web = create securitygroup description='web servers' name=web vpc=$my-vpc
+create instance count=1 image=ami-123 keypair=my-key name=web-1 securitygroup=[$web,sg_other] subnet=$my_subnet type=t2.micro`},
+	}
+
+	for i, tcase := range tcases {
+		res, err := findLocalResource(g, tcase.ref, exportOrder...)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		text, err := exportTemplate(g, res, tcase.withChildren)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := text, tcase.exp; got != want {
+			t.Fatalf("%d: got\n%s\n\nwant\n%s", i+1, got, want)
+		}
+		if _, err := template.Parse(text); err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+	}
+
+	res, err := findLocalResource(g, "@web-1", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := cloneTemplate(res, []string{"name=web-2", "ip=10.0.1.12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := text, "create instance count=1 image=ami-123 ip=10.0.1.12 keypair=my-key name=web-2 securitygroup=[sg_1,sg_other] subnet=sub_1 type=t2.micro"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if _, err := cloneTemplate(res, []string{"name"}); err == nil {
+		t.Fatal("expected error for invalid override")
+	}
+	if _, err := findLocalResource(g, "@web-1", "subnet"); err == nil || !strings.Contains(err.Error(), "found 0 subnet(s)") {
+		t.Fatalf("got %v, want not found error", err)
+	}
+}
+
+func TestExportVpcNetworkTemplate(t *testing.T) {
+	cidr := func(s string) *net.IPNet {
+		_, n, _ := net.ParseCIDR(s)
+		return n
+	}
+	g := graph.NewGraph()
+	vpc := resourcetest.VPC("vpc_1").Prop("Name", "prod").Prop("CIDR", "10.0.0.0/16").Build()
+	igw := resourcetest.InternetGw("igw_1").Prop("Vpcs", []string{"vpc_1"}).Build()
+	pub := resourcetest.Subnet("sub_pub").Prop("Name", "public").Prop("CIDR", "10.0.1.0/24").Prop("Vpc", "vpc_1").Prop("AvailabilityZone", "eu-west-1a").Build()
+	priv := resourcetest.Subnet("sub_priv").Prop("Name", "private").Prop("CIDR", "10.0.2.0/24").Prop("Vpc", "vpc_1").Prop("AvailabilityZone", "eu-west-1a").Build()
+	nat := resourcetest.NatGw("nat_1").Prop("Subnet", "sub_pub").Prop("Vpc", "vpc_1").Prop("State", "available").Build()
+	rtMain := resourcetest.RouteTable("rt_main").Prop("Vpc", "vpc_1").Prop("Default", true).Prop("Routes", []*graph.Route{
+		{Destination: cidr("10.0.0.0/16"), Targets: []*graph.RouteTarget{{Type: graph.GatewayTarget, Ref: "local"}}},
+		{Destination: cidr("0.0.0.0/0"), Targets: []*graph.RouteTarget{{Type: graph.NatTarget, Ref: "nat_1"}}},
+	}).Prop("Associations", []*graph.KeyValue{{KeyName: "rtbassoc-main", Value: ""}}).Build()
+	rtPub := resourcetest.RouteTable("rt_pub").Prop("Vpc", "vpc_1").Prop("Default", false).Prop("Routes", []*graph.Route{
+		{Destination: cidr("10.0.0.0/16"), Targets: []*graph.RouteTarget{{Type: graph.GatewayTarget, Ref: "local"}}},
+		{Destination: cidr("0.0.0.0/0"), Targets: []*graph.RouteTarget{{Type: graph.GatewayTarget, Ref: "igw_1"}}},
+		{Destination: cidr("172.16.0.0/16"), Targets: []*graph.RouteTarget{{Type: graph.VpcPeeringConnectionTarget, Ref: "pcx_1"}}},
+	}).Prop("Associations", []*graph.KeyValue{{KeyName: "rtbassoc-1", Value: "sub_pub"}}).Build()
+	g.AddResource(
+		vpc, igw, pub, priv, nat, rtMain, rtPub,
+		resourcetest.SecurityGroup("sg_default").Prop("Name", "default").Prop("Vpc", "vpc_1").Build(),
+		resourcetest.SecurityGroup("sg_web").Prop("Name", "web").Prop("Description", "web servers").Prop("Vpc", "vpc_1").
+			Prop("InboundRules", []*graph.FirewallRule{
+				{Protocol: "tcp", PortRange: graph.PortRange{FromPort: 443, ToPort: 443}, IPRanges: []*net.IPNet{cidr("0.0.0.0/0")}},
+				{Protocol: "tcp", PortRange: graph.PortRange{FromPort: 22, ToPort: 22}, Sources: []string{"sg_default"}},
+			}).
+			Prop("OutboundRules", []*graph.FirewallRule{
+				{Protocol: "any", PortRange: graph.PortRange{Any: true}, IPRanges: []*net.IPNet{cidr("0.0.0.0/0")}},
+			}).Build(),
+		resourcetest.SecurityGroup("sg_db").Prop("Name", "db").Prop("Description", "databases").Prop("Vpc", "vpc_1").
+			Prop("InboundRules", []*graph.FirewallRule{
+				{Protocol: "tcp", PortRange: graph.PortRange{FromPort: 5432, ToPort: 5433}, Sources: []string{"sg_web"}},
+			}).Build(),
+		resourcetest.Instance("inst_web").Prop("Name", "web-1").Prop("Type", "t2.micro").Prop("Image", "ami-123").Prop("Subnet", "sub_pub").
+			Prop("SecurityGroups", []string{"sg_default", "sg_web"}).Prop("State", "running").Build(),
+		resourcetest.Instance("inst_old").Prop("Name", "old").Prop("State", "terminated").Build(),
+	)
+	resourcetest.AddParents(g,
+		"vpc_1 -> sub_pub", "vpc_1 -> sub_priv", "vpc_1 -> nat_1", "vpc_1 -> rt_main", "vpc_1 -> rt_pub",
+		"vpc_1 -> sg_default", "vpc_1 -> sg_web", "vpc_1 -> sg_db", "sub_pub -> inst_web", "sub_pub -> inst_old",
+	)
+	g.AddAppliesOnRelation(igw, vpc)
+	g.AddAppliesOnRelation(nat, pub)
+	g.AddAppliesOnRelation(rtPub, pub)
+
+	exp := `prod = create vpc cidr=10.0.0.0/16 name=prod
+internetgateway = create internetgateway
+attach internetgateway id=$internetgateway vpc=$prod
+private = create subnet availabilityzone=eu-west-1a cidr=10.0.2.0/24 name=private vpc=$prod
+public = create subnet availabilityzone=eu-west-1a cidr=10.0.1.0/24 name=public vpc=$prod
+natgateway-ip = create elasticip domain=vpc
+natgateway = create natgateway elasticip-id=$natgateway-ip subnet=$public
+check natgateway id=$natgateway state=available timeout=180
+routetable = create routetable vpc=$prod
+create route cidr=0.0.0.0/0 gateway=$natgateway table=$routetable
+attach routetable id=$routetable subnet=$private
+routetable_2 = create routetable vpc=$prod
+create route cidr=0.0.0.0/0 gateway=$internetgateway table=$routetable_2
+# route to 172.16.0.0/16 through pcx_1 not exported: only IPv4 routes through internet or NAT gateways are
+attach routetable id=$routetable_2 subnet=$public
+db = create securitygroup description=databases name=db vpc=$prod
+web = create securitygroup description='web servers' name=web vpc=$prod
+update securitygroup id=$db inbound=authorize protocol=tcp portrange=5432-5433 securitygroup=$web
+# inbound rule from default security group sg_default not exported
+update securitygroup cidr=0.0.0.0/0 id=$web inbound=authorize protocol=tcp portrange=443
+create instance count=1 image=ami-123 name=web-1 securitygroup=[$web] subnet=$public type=t2.micro`
+
+	text, err := exportTemplate(g, vpc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := text, exp; got != want {
+		t.Fatalf("got\n%s\n\nwant\n%s", got, want)
+	}
+	cenv := template.NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+		if newCommandFunc == nil {
+			return nil
+		}
+		return newCommandFunc()
+	}).Build()
+	diags, err := template.Lint(text, cenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diags {
+		if d.Severity == template.LintError {
+			t.Fatalf("unexpected lint error: %v", d)
+		}
+	}
+}
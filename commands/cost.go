@@ -0,0 +1,95 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/wallix/awless/aws/cost"
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/cloud/properties"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/logger"
+	"github.com/wallix/awless/sync"
+	"github.com/wallix/awless/template"
+)
+
+// loadPriceTable returns the default price table overridden
+// by the prices of the price table file, if there is one
+func loadPriceTable() (*awscost.PriceTable, error) {
+	table := awscost.DefaultPriceTable()
+	content, err := ioutil.ReadFile(config.PricesPath)
+	if os.IsNotExist(err) {
+		return table, nil
+	}
+	if err != nil {
+		return table, fmt.Errorf("prices: %s", err)
+	}
+	prices, err := awscost.ParsePriceTable(content)
+	if err != nil {
+		return table, fmt.Errorf("%s: %s", config.PricesPath, err)
+	}
+	table.Merge(prices)
+	return table, nil
+}
+
+// estimateCost returns the monthly cost delta of the commands of the template,
+// the commands of loops being priced once per item and the branches not taken left out.
+// Deleted and updated resources are priced from the local graph
+func estimateCost(tpl *template.Template, g cloud.GraphAPI) (*awscost.Estimate, error) {
+	table, err := loadPriceTable()
+	if err != nil {
+		return nil, err
+	}
+	var cmds []*awscost.Command
+	for _, cmd := range tpl.ExpandedCommandNodesIterator() {
+		cmds = append(cmds, &awscost.Command{Action: cmd.Action, Entity: cmd.Entity, Params: cmd.ToDriverParams()})
+	}
+	lookup := func(id string) (map[string]interface{}, bool) {
+		if g == nil {
+			return nil, false
+		}
+		resources, err := g.FindWithProperties(map[string]interface{}{properties.ID: id})
+		if err != nil || len(resources) != 1 {
+			return nil, false
+		}
+		return resources[0].Properties(), true
+	}
+	return table.Estimate(config.GetAWSRegion(), cmds, lookup), nil
+}
+
+// printCostEstimate displays the estimate of the template if it has commands with a cost
+func printCostEstimate(w io.Writer, tpl *template.Template) {
+	g, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		logger.Verbosef("cost estimate: %s", err)
+	}
+	estimate, err := estimateCost(tpl, g)
+	if err != nil {
+		logger.Warningf("cost estimate: %s", err)
+		return
+	}
+	if len(estimate.Lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Estimated monthly cost delta in %s (prices of %s, update them in %s):\n", estimate.Region, estimate.Updated, config.PricesPath)
+	estimate.Print(w)
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
+)
+
+func TestEstimateCostOfLoopsAndConditions(t *testing.T) {
+	defer func(region interface{}) { config.Config[config.RegionConfigKey] = region }(config.Config[config.RegionConfigKey])
+	config.Config[config.RegionConfigKey] = "eu-west-1"
+	cenv := template.NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+		if newCommandFunc == nil {
+			return nil
+		}
+		return newCommandFunc()
+	}).WithExistsFunc(func(entity, name string) (bool, error) {
+		return name == "existing", nil
+	}).Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"env": "dev"})
+
+	tcases := []struct {
+		tpl   string
+		lines int
+		total float64
+	}{
+		{tpl: "create instance type=t2.micro count=2 image=ami-1234 name=web subnet=sub-1234", lines: 1, total: 2 * 0.0126 * 730},
+		{tpl: "for name in [a, b, c] {\n  create instance type=t2.micro count=1 image=ami-1234 name=$name subnet=sub-1234\n}", lines: 3, total: 3 * 0.0126 * 730},
+		{tpl: "if {env} == prod {\n  create instance type=t2.micro count=1 image=ami-1234 name=web subnet=sub-1234\n}\ncreate volume availabilityzone=eu-west-1a size=10", lines: 1, total: 10 * 0.11},
+		{tpl: "unless exists instance @existing {\n  create instance type=t2.micro count=1 image=ami-1234 name=existing subnet=sub-1234\n}", lines: 0},
+		{tpl: "unless exists instance @missing {\n  create instance type=t2.micro count=1 image=ami-1234 name=missing subnet=sub-1234\n}", lines: 1, total: 0.0126 * 730},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := template.Parse(tcase.tpl)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		compiled, _, err := template.Compile(tpl, cenv, template.NewRunnerCompileMode)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		estimate, err := estimateCost(compiled, nil)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := len(estimate.Lines), tcase.lines; got != want {
+			t.Fatalf("%d: got %d lines, want %d", i+1, got, want)
+		}
+		if got, want := estimate.Total(), tcase.total; math.Abs(got-want) > 0.01 {
+			t.Fatalf("%d: got %f, want %f", i+1, got, want)
+		}
+	}
+}
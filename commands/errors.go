@@ -17,15 +17,63 @@ limitations under the License.
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/wallix/awless/aws/services"
+	"github.com/wallix/awless/template"
 )
 
+// nonInteractiveExitCode is the exit code when values would have been prompted for in non-interactive mode
+const nonInteractiveExitCode = 3
+
 func exitOn(err error) {
 	if err != nil {
+		if missing, ok := asMissingValuesError(err); ok {
+			fmt.Fprintln(os.Stderr, color.RedString("[error]  "), missing)
+			os.Exit(nonInteractiveExitCode)
+		}
 		fmt.Fprintln(os.Stderr, color.RedString("[error]  "), err)
 		os.Exit(1)
 	}
 }
+
+// missingValuesError lists all the values that would have been prompted for
+// when running with --non-interactive
+type missingValuesError struct {
+	values []string
+}
+
+func (e *missingValuesError) Error() string {
+	var buf bytes.Buffer
+	buf.WriteString("non-interactive mode: missing values:")
+	for _, v := range e.values {
+		buf.WriteString("\n\t- ")
+		buf.WriteString(v)
+	}
+	return buf.String()
+}
+
+func asMissingValuesError(err error) (*missingValuesError, bool) {
+	switch e := err.(type) {
+	case *missingValuesError:
+		return e, true
+	case *template.UnresolvedHolesError:
+		if !nonInteractiveGlobalFlag {
+			return nil, false
+		}
+		missing := &missingValuesError{}
+		for _, hole := range e.Holes {
+			missing.values = append(missing.values, fmt.Sprintf("hole {%s} (%s)", hole, strings.Join(e.ParamPaths[hole], ", ")))
+		}
+		return missing, true
+	case *awsservices.MissingCredentialsError:
+		return &missingValuesError{values: []string{
+			fmt.Sprintf("AWS credentials for profile '%s' (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)", e.Profile),
+		}}, true
+	}
+	return nil, false
+}
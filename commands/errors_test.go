@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wallix/awless/aws/services"
+	"github.com/wallix/awless/template"
+)
+
+func TestAsMissingValuesError(t *testing.T) {
+	defer func(f bool) { nonInteractiveGlobalFlag = f }(nonInteractiveGlobalFlag)
+
+	holesErr := &template.UnresolvedHolesError{
+		Holes:      []string{"instance.type", "subnet"},
+		ParamPaths: map[string][]string{"instance.type": {"create.instance.type"}, "subnet": {"create.instance.subnet", "create.subnet.name"}},
+	}
+	tcases := []struct {
+		err            error
+		nonInteractive bool
+		exp            string
+	}{
+		{err: errors.New("any error"), nonInteractive: true},
+		{err: holesErr, nonInteractive: false},
+		{err: holesErr, nonInteractive: true, exp: "non-interactive mode: missing values:\n\t- hole {instance.type} (create.instance.type)\n\t- hole {subnet} (create.instance.subnet, create.subnet.name)"},
+		{err: &awsservices.MissingCredentialsError{Profile: "prod", Err: errors.New("no provider")}, nonInteractive: true, exp: "non-interactive mode: missing values:\n\t- AWS credentials for profile 'prod' (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)"},
+		{err: &missingValuesError{values: []string{"confirmation"}}, exp: "non-interactive mode: missing values:\n\t- confirmation"},
+	}
+	for i, tcase := range tcases {
+		nonInteractiveGlobalFlag = tcase.nonInteractive
+		missing, ok := asMissingValuesError(tcase.err)
+		if got, want := ok, tcase.exp != ""; got != want {
+			t.Fatalf("%d: got %t, want %t", i+1, got, want)
+		}
+		if ok {
+			if got, want := missing.Error(), tcase.exp; got != want {
+				t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+			}
+		}
+	}
+}
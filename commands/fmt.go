@@ -0,0 +1,67 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wallix/awless/template"
+)
+
+func init() {
+	RootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolVarP(&writeFormattedFlag, "write", "w", false, "Write result to FILE instead of stdout")
+}
+
+var writeFormattedFlag bool
+
+var fmtCmd = &cobra.Command{
+	Use:               "fmt FILE",
+	Short:             "Rewrite a template in canonical form",
+	Long:              "Reparse a template and print it in canonical form: sorted params, normalized quoting, tab indented blocks, aligned consecutive declarations, preserved comments and single blank lines between groups of statements.",
+	Example:           "  awless fmt ~/templates/my-infra.aws\n  awless fmt -w ~/templates/my-infra.aws",
+	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook),
+	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("missing FILE arg (filepath or url)")
+		}
+
+		content, fullPath, err := getTemplateText(args[0])
+		exitOn(err)
+
+		formatted, err := template.Format(string(content))
+		exitOn(err)
+
+		if !writeFormattedFlag {
+			fmt.Print(formatted)
+			return nil
+		}
+		if strings.HasPrefix(fullPath, "http") {
+			exitOn(fmt.Errorf("cannot write formatted template to remote '%s'", fullPath))
+		}
+		if formatted != string(content) {
+			exitOn(ioutil.WriteFile(fullPath, []byte(formatted), 0644))
+		}
+		return nil
+	},
+}
@@ -84,7 +84,7 @@ func initCloudServicesHook(cmd *cobra.Command, args []string) error {
 
 	logger.Verbosef("awless %s - loading AWS session with profile '%s' and region '%s'", config.Version, profile, region)
 
-	if err := awsservices.Init(profile, region, config.GetConfigWithPrefix("aws."), logger.DefaultLogger, config.SetProfileCallback, networkMonitorFlag); err != nil {
+	if err := awsservices.Init(profile, region, config.GetConfigWithPrefix("aws."), logger.DefaultLogger, config.SetProfileCallback, networkMonitorFlag, nonInteractiveGlobalFlag); err != nil {
 		return err
 	}
 
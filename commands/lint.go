@@ -0,0 +1,75 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
+)
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:               "lint FILE",
+	Short:             "Check a template for errors and bad practices without running it nor accessing your cloud",
+	Long:              "Check a template for syntax errors, unknown commands or params, undeclared references, unused declarations, holes without default values and non revertible commands.\n\nIssues are printed as FILE:LINE:COLUMN: SEVERITY: MESSAGE. The command exits with status 1 if any error is found.",
+	Example:           "  awless lint ~/templates/my-infra.aws\n  awless lint repo:create_vpc",
+	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook),
+	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("missing FILE arg (filepath or url)")
+		}
+
+		content, fullPath, err := getTemplateText(args[0])
+		exitOn(err)
+
+		cenv := template.NewEnv().WithIncludeFunc(getTemplateText).WithTemplatePath(fullPath).
+			WithLookupCommandFunc(func(tokens ...string) interface{} {
+				newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+				if newCommandFunc == nil {
+					return nil
+				}
+				return newCommandFunc()
+			}).Build()
+		cenv.Push(env.DEFAULTS, config.Defaults)
+
+		diags, err := template.Lint(string(content), cenv)
+		exitOn(err)
+
+		var hasErrors bool
+		for _, d := range diags {
+			fmt.Printf("%s:%s\n", args[0], d)
+			hasErrors = hasErrors || d.Severity == template.LintError
+		}
+		if hasErrors {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
@@ -31,6 +31,7 @@ var (
 	limitLogCountFlag             int
 	rawJSONLogFlag, idOnlyLogFlag bool
 	fullLogFlag, shortLogFlag     bool
+	outputsLogFlag                string
 )
 
 func init() {
@@ -43,6 +44,8 @@ func init() {
 	logCmd.Flags().BoolVar(&shortLogFlag, "short", false, "Display one or more template log with less info")
 	logCmd.Flags().BoolVar(&fullLogFlag, "full", false, "Display template logs with full info")
 	logCmd.Flags().BoolVar(&idOnlyLogFlag, "id-only", false, "Show only log template IDs (i.e. revert IDs)")
+	logCmd.Flags().StringVar(&outputsLogFlag, "outputs", "", "Show only the template outputs as json, yaml or env (ex: --outputs=env)")
+	logCmd.Flags().Lookup("outputs").NoOptDefVal = "json"
 }
 
 var logCmd = &cobra.Command{
@@ -133,6 +136,8 @@ func getPrinter(args []string) logPrinter {
 		return &rawJSONPrinter{os.Stdout}
 	case idOnlyLogFlag:
 		return &idOnlyPrinter{os.Stdout}
+	case outputsLogFlag != "":
+		return &outputsPrinter{os.Stdout, outputsLogFlag}
 	case shortLogFlag:
 		return &shortLogPrinter{os.Stdout}
 	case fullLogFlag:
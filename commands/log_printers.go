@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/wallix/awless/console"
 	"github.com/wallix/awless/logger"
 	"github.com/wallix/awless/template"
+	"gopkg.in/yaml.v2"
 )
 
 type logPrinter interface {
@@ -27,9 +32,11 @@ func (p *fullLogPrinter) print(t *template.TemplateExecution) error {
 		fmt.Fprintf(p.w, "\t%s\n\n", t.Message)
 	}
 
-	for _, cmd := range t.CommandNodesIterator() {
+	for i, cmd := range t.CommandNodesIterator() {
 		var status string
-		if cmd.CmdErr != nil {
+		if cmd.CmdSkipped {
+			status = renderYellowFn("SKIP")
+		} else if cmd.CmdErr != nil {
 			status = renderRedFn("KO")
 		} else {
 			status = renderGreenFn("OK")
@@ -37,9 +44,12 @@ func (p *fullLogPrinter) print(t *template.TemplateExecution) error {
 
 		var line string
 		if v, ok := cmd.CmdResult.(string); ok && v != "" {
-			line = fmt.Sprintf("    %s\t%s\t[%s]", status, cmd.String(), v)
+			line = fmt.Sprintf("    %s\t%d. %s\t[%s]", status, i+1, cmd.String(), v)
 		} else {
-			line = fmt.Sprintf("    %s\t%s", status, cmd.String())
+			line = fmt.Sprintf("    %s\t%d. %s", status, i+1, cmd.String())
+		}
+		if cmd.CmdAttempts > 1 {
+			line = fmt.Sprintf("%s (%d attempts)", line, cmd.CmdAttempts)
 		}
 
 		fmt.Fprintln(p.w, line)
@@ -82,6 +92,90 @@ func (p *rawJSONPrinter) print(t *template.TemplateExecution) error {
 	return nil
 }
 
+type outputsPrinter struct {
+	w      io.Writer
+	format string
+}
+
+func (p *outputsPrinter) print(t *template.TemplateExecution) error {
+	outputs := t.Outputs()
+	switch p.format {
+	case "json":
+		if err := json.NewEncoder(p.w).Encode(outputs); err != nil {
+			return fmt.Errorf("outputs printer: %s", err)
+		}
+	case "yaml":
+		b, err := yaml.Marshal(outputs)
+		if err != nil {
+			return fmt.Errorf("outputs printer: %s", err)
+		}
+		p.w.Write(b)
+	case "env":
+		var keys []string
+		for k := range outputs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(p.w, "%s=%s\n", envVarName(k), envVarValue(outputs[k]))
+		}
+	default:
+		return fmt.Errorf("outputs printer: unknown format '%s', expecting json, yaml or env", p.format)
+	}
+	return nil
+}
+
+type reportPrinter struct {
+	w      io.Writer
+	format string
+}
+
+func (p *reportPrinter) print(report *template.Report) error {
+	var err error
+	switch p.format {
+	case "json":
+		err = report.WriteJSON(p.w)
+	case "junit":
+		err = report.WriteJUnit(p.w)
+	default:
+		return fmt.Errorf("report printer: unknown format '%s', expecting json or junit", p.format)
+	}
+	if err != nil {
+		return fmt.Errorf("report printer: %s", err)
+	}
+	return nil
+}
+
+func writeReport(report *template.Report) error {
+	f, err := os.Create(runReportFileFlag)
+	if err != nil {
+		return fmt.Errorf("report printer: %s", err)
+	}
+	defer f.Close()
+	return (&reportPrinter{f, runReportFlag}).print(report)
+}
+
+var nonEnvVarCharRegex = regexp.MustCompile("[^A-Z0-9_]")
+
+func envVarName(s string) string {
+	return nonEnvVarCharRegex.ReplaceAllString(strings.ToUpper(s), "_")
+}
+
+func envVarValue(v interface{}) string {
+	var str string
+	switch vv := v.(type) {
+	case []interface{}:
+		var all []string
+		for _, e := range vv {
+			all = append(all, fmt.Sprint(e))
+		}
+		str = strings.Join(all, ",")
+	default:
+		str = fmt.Sprint(v)
+	}
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
 type idOnlyPrinter struct {
 	w io.Writer
 }
@@ -112,7 +206,9 @@ func writeLogHeader(t *template.TemplateExecution, w io.Writer) {
 	if t.Locale != "" {
 		fmt.Fprintf(w, " in %s", renderBlueFn(t.Locale))
 	}
-	if !template.IsRevertible(t.Template) {
+	if t.RolledBackBy != "" {
+		fmt.Fprintf(w, " (rolled back by %s)", renderYellowFn(t.RolledBackBy))
+	} else if !template.IsRevertible(t.Template) {
 		fmt.Fprintf(w, " (not revertible)")
 	}
 }
@@ -135,5 +231,11 @@ func writeMultilineLogHeader(t *template.TemplateExecution, w io.Writer) {
 	if t.Locale != "" {
 		fmt.Fprintf(w, "Region: %s\n", t.Locale)
 	}
+	if t.RollbackOf != "" {
+		fmt.Fprintf(w, "Rollback of: %s\n", t.RollbackOf)
+	}
+	if t.RolledBackBy != "" {
+		fmt.Fprintf(w, "Rolled back by: %s\n", t.RolledBackBy)
+	}
 	fmt.Fprintln(w)
 }
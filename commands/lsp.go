@@ -0,0 +1,92 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wallix/awless/aws/doc"
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/lsp"
+	"github.com/wallix/awless/sync"
+	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
+)
+
+func init() {
+	RootCmd.AddCommand(lspCmd)
+}
+
+var lspCmd = &cobra.Command{
+	Use:              "lsp",
+	Short:            "Start a language server for templates speaking the Language Server Protocol over stdio",
+	Long:             "Start a language server for templates to be run by your editor. It speaks the Language Server Protocol over stdin/stdout and offers completion of actions, entities, params and aliases of your locally synced resources, hover documentation and diagnostics as given by `awless lint`.",
+	PersistentPreRun: applyHooks(initLoggerHook, initAwlessEnvHook),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cenvFunc := func(path string) env.Compiling {
+			cenv := template.NewEnv().WithIncludeFunc(getTemplateText).WithTemplatePath(path).
+				WithLookupCommandFunc(func(tokens ...string) interface{} {
+					newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+					if newCommandFunc == nil {
+						return nil
+					}
+					return newCommandFunc()
+				}).Build()
+			cenv.Push(env.DEFAULTS, config.Defaults)
+			return cenv
+		}
+
+		exitOn(lsp.New(cenvFunc, localAliases).Serve(os.Stdin, os.Stdout))
+		return nil
+	},
+}
+
+// localAliases returns the names, as aliases, of the resources
+// in the local graph of the current region matching a param path
+func localAliases(paramPath string) (aliases []string) {
+	splits := strings.Split(paramPath, ".")
+	if len(splits) != 3 {
+		return
+	}
+	entity, key := splits[1], splits[2]
+	resType := key
+	if typedParam, has := awsdoc.ParamTypeDoc[paramPath]; has {
+		resType = typedParam.ResourceType
+	} else if strings.Contains(key, "id") {
+		resType = entity
+	}
+
+	gph, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return
+	}
+	resources, err := gph.Find(cloud.NewQuery(resType))
+	if err != nil {
+		return
+	}
+	for _, res := range resources {
+		aliases = appendWithNameAliases(aliases, res)
+	}
+	sort.Strings(aliases)
+	return
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/template"
+)
+
+// loadPolicy returns the policy of the policy file, or nil if there is none
+func loadPolicy() (*template.Policy, error) {
+	content, err := ioutil.ReadFile(config.PolicyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("policy: %s", err)
+	}
+	policy, err := template.ParsePolicy(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", config.PolicyPath, err)
+	}
+	return policy, nil
+}
+
+// loadRules returns the validator of the rules of the rules file, or nil if there is none
+func loadRules() (*template.RulesValidator, error) {
+	content, err := ioutil.ReadFile(config.RulesPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rules: %s", err)
+	}
+	rules, err := template.ParseRules(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", config.RulesPath, err)
+	}
+	return rules, nil
+}
+
+// enforcePolicy fails if the policy file denies commands of the template,
+// if approvals are missing or if the confirmations it requires are not typed.
+// The --force flag does not bypass the policy
+func enforcePolicy(tplExec *template.TemplateExecution) error {
+	policy, err := loadPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+	return checkPolicy(policy.Match(config.GetAWSProfile(), config.GetAWSRegion(), tplExec.Template), approveGlobalFlag, askPolicyConfirmation)
+}
+
+func checkPolicy(matches []*template.PolicyMatch, approval string, confirm func(string) (bool, error)) error {
+	var denied, unapproved bytes.Buffer
+	var confirmations []string
+	toConfirm := make(map[string]bool)
+	for _, m := range matches {
+		switch m.Rule.Effect {
+		case template.PolicyDeny:
+			fmt.Fprintf(&denied, "\n\t- %s", m)
+		case template.PolicyApprove:
+			if !m.Rule.IsApprovedBy(approval) {
+				fmt.Fprintf(&unapproved, "\n\t- %s", m)
+			}
+		case template.PolicyConfirm:
+			if phrase := fmt.Sprintf("%s %s", m.Action, m.Entity); !toConfirm[phrase] {
+				toConfirm[phrase] = true
+				confirmations = append(confirmations, phrase)
+			}
+		}
+	}
+	if denied.Len() > 0 {
+		return fmt.Errorf("denied by policy:%s", denied.String())
+	}
+	if unapproved.Len() > 0 {
+		if approval == "" {
+			return fmt.Errorf("approval required by policy (use --approve TOKEN):%s", unapproved.String())
+		}
+		return fmt.Errorf("invalid approval token for:%s", unapproved.String())
+	}
+	if len(confirmations) == 0 {
+		return nil
+	}
+	if nonInteractiveGlobalFlag {
+		missing := &missingValuesError{}
+		for _, phrase := range confirmations {
+			missing.values = append(missing.values, fmt.Sprintf("typed confirmation '%s' required by policy", phrase))
+		}
+		return missing
+	}
+	for _, phrase := range confirmations {
+		ok, err := confirm(phrase)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("policy confirmation of '%s' failed", phrase)
+		}
+	}
+	return nil
+}
+
+func askPolicyConfirmation(phrase string) (bool, error) {
+	l, err := readline.NewEx(&readline.Config{
+		Prompt:          renderYellowFn(fmt.Sprintf("Policy requires confirmation. Type '%s' to proceed:", phrase)) + " ",
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		return false, err
+	}
+	defer l.Close()
+
+	line, err := l.Readline()
+	if err == readline.ErrInterrupt {
+		return false, errors.New("interrupted")
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(line) == phrase, nil
+}
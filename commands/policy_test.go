@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/template"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	defer func(f bool) { nonInteractiveGlobalFlag = f }(nonInteractiveGlobalFlag)
+
+	deny := &template.PolicyRule{Effect: template.PolicyDeny}
+	confirm := &template.PolicyRule{Effect: template.PolicyConfirm}
+	approve := &template.PolicyRule{Effect: template.PolicyApprove, ApprovalTokens: []string{"2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"}}
+
+	var asked []string
+	typed := func(answer bool) func(string) (bool, error) {
+		return func(phrase string) (bool, error) {
+			asked = append(asked, phrase)
+			return answer, nil
+		}
+	}
+	tcases := []struct {
+		matches        []*template.PolicyMatch
+		approval       string
+		confirmed      bool
+		nonInteractive bool
+		expErr         string
+		expAsked       []string
+	}{
+		{},
+		{matches: []*template.PolicyMatch{{Rule: confirm, Action: "delete", Entity: "bucket"}, {Rule: deny, Action: "delete", Entity: "database"}}, expErr: "denied by policy:\n\t- delete database (deny)"},
+		{matches: []*template.PolicyMatch{{Rule: approve, Action: "update", Entity: "policy"}}, expErr: "approval required by policy (use --approve TOKEN)"},
+		{matches: []*template.PolicyMatch{{Rule: approve, Action: "update", Entity: "policy"}}, approval: "wrong", expErr: "invalid approval token"},
+		{matches: []*template.PolicyMatch{{Rule: approve, Action: "update", Entity: "policy"}}, approval: "secret"},
+		{matches: []*template.PolicyMatch{{Rule: confirm, Action: "delete", Entity: "bucket"}, {Rule: confirm, Action: "delete", Entity: "bucket"}}, confirmed: true, expAsked: []string{"delete bucket"}},
+		{matches: []*template.PolicyMatch{{Rule: confirm, Action: "delete", Entity: "bucket"}}, expErr: "policy confirmation of 'delete bucket' failed", expAsked: []string{"delete bucket"}},
+		{matches: []*template.PolicyMatch{{Rule: confirm, Action: "delete", Entity: "bucket"}}, nonInteractive: true, expErr: "typed confirmation 'delete bucket' required by policy"},
+	}
+	for i, tcase := range tcases {
+		asked = nil
+		nonInteractiveGlobalFlag = tcase.nonInteractive
+		err := checkPolicy(tcase.matches, tcase.approval, typed(tcase.confirmed))
+		if tcase.expErr == "" && err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if tcase.expErr != "" && (err == nil || !strings.Contains(err.Error(), tcase.expErr)) {
+			t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+		}
+		if got, want := strings.Join(asked, ","), strings.Join(tcase.expAsked, ","); got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/wallix/awless/aws/services"
+	"github.com/wallix/awless/cloud"
+	"github.com/wallix/awless/cloud/match"
+	"github.com/wallix/awless/cloud/properties"
+	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/sync"
+	"github.com/wallix/awless/template"
+)
+
+// updatedProperties maps, per entity, the update params to the properties
+// of the locally synced resources holding their current values.
+// Params not synced (instance lock, scalinggroup subnets, targetgroup
+// deregistrationdelay, stickiness and stickinessduration) have no prior
+// value: their updates are not revertible
+var updatedProperties = map[string]map[string]string{
+	"instance": {"type": properties.Type},
+	"record":   {"values": properties.Records, "ttl": properties.TTL},
+	"scalinggroup": {
+		"cooldown":                 properties.DefaultCooldown,
+		"desired-capacity":         properties.DesiredCapacity,
+		"healthcheck-grace-period": properties.HealthCheckGracePeriod,
+		"healthcheck-type":         properties.HealthCheckType,
+		"launchconfiguration":      properties.LaunchConfigurationName,
+		"max-size":                 properties.MaxSize,
+		"min-size":                 properties.MinSize,
+		"new-instances-protected":  properties.NewInstancesProtected,
+	},
+	"subnet": {"public": properties.Public},
+	"targetgroup": {
+		"healthcheckinterval": properties.CheckInterval,
+		"healthcheckpath":     properties.CheckPath,
+		"healthcheckport":     properties.CheckPort,
+		"healthcheckprotocol": properties.CheckProtocol,
+		"healthchecktimeout":  properties.CheckTimeout,
+		"healthythreshold":    properties.HealthyThresholdCount,
+		"unhealthythreshold":  properties.UnhealthyThresholdCount,
+		"matcher":             properties.CheckHTTPCode,
+	},
+}
+
+// priorStateFunc returns the values of the updated params before the update.
+// They are read from the local graph, or described for buckets whose website
+// configuration is not synced. It returns nil unless all the updated params
+// have a prior value, a partial restore not reverting the update
+func priorStateFunc(entity string, params map[string]interface{}) (map[string]interface{}, error) {
+	prior, err := updatePriorState(entity, params)
+	if err != nil || !template.IsCompletePriorState(entity, params, prior) {
+		return nil, err
+	}
+	return prior, nil
+}
+
+func updatePriorState(entity string, params map[string]interface{}) (map[string]interface{}, error) {
+	if entity == "bucket" {
+		storage, ok := awsservices.StorageService.(*awsservices.Storage)
+		if !ok {
+			return nil, nil
+		}
+		return bucketWebsiteState(storage, fmt.Sprint(params["name"]), params)
+	}
+
+	props, ok := updatedProperties[entity]
+	if !ok {
+		return nil, nil
+	}
+	g := sync.LoadLocalGraphForService(awsservices.ServicePerResourceType[entity], config.GetAWSProfile(), config.GetAWSRegion())
+	res, err := findUpdatedResource(g, entity, params)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	prior := make(map[string]interface{})
+	for param, prop := range props {
+		_, updated := params[param]
+		if !updated && entity != "record" { // records updates require both their values and ttl
+			continue
+		}
+		if v, ok := res.Property(prop); ok {
+			prior[param] = v
+		}
+	}
+	return prior, nil
+}
+
+// findUpdatedResource returns the resource updated by the command,
+// or nil if it is not found without ambiguity in the graph
+func findUpdatedResource(g cloud.GraphAPI, entity string, params map[string]interface{}) (cloud.Resource, error) {
+	var matcher cloud.Matcher
+	switch entity {
+	case "record":
+		name := strings.TrimSuffix(fmt.Sprint(params["name"]), ".")
+		matcher = match.And(
+			match.Or(match.Property(properties.Name, name), match.Property(properties.Name, name+".")),
+			match.Property(properties.Type, params["type"]),
+		)
+	case "scalinggroup":
+		matcher = match.Property(properties.Name, params["name"])
+	default:
+		matcher = match.Property(properties.ID, params["id"])
+	}
+	resources, err := g.Find(cloud.NewQuery(entity).Match(matcher))
+	if err != nil || len(resources) != 1 {
+		return nil, err
+	}
+	return resources[0], nil
+}
+
+// bucketWebsiteState returns the prior website configuration of the bucket
+// when the update sets it. Canned ACLs cannot be inferred from the bucket grants
+func bucketWebsiteState(api s3iface.S3API, bucket string, params map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := params["public-website"]; !ok {
+		return nil, nil
+	}
+	website, err := api.GetBucketWebsite(&s3.GetBucketWebsiteInput{Bucket: aws.String(bucket)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchWebsiteConfiguration" {
+		return map[string]interface{}{"public-website": false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prior := map[string]interface{}{"public-website": true}
+	switch {
+	case website.RedirectAllRequestsTo != nil:
+		prior["redirect-hostname"] = aws.StringValue(website.RedirectAllRequestsTo.HostName)
+		if aws.StringValue(website.RedirectAllRequestsTo.Protocol) == "https" {
+			prior["enforce-https"] = true
+		}
+	case website.IndexDocument != nil:
+		prior["index-suffix"] = aws.StringValue(website.IndexDocument.Suffix)
+	}
+	return prior, nil
+}
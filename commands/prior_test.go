@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/wallix/awless/graph"
+	"github.com/wallix/awless/graph/resourcetest"
+)
+
+func TestFindUpdatedResource(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddResource(
+		resourcetest.Subnet("sub_1").Prop("Public", true).Build(),
+		resourcetest.Record("rec_1").Prop("Name", "www.example.com.").Prop("Type", "A").Build(),
+		resourcetest.Record("rec_2").Prop("Name", "www.example.com.").Prop("Type", "CNAME").Build(),
+		resourcetest.ScalingGroup("asg_arn").Prop("Name", "my-group").Build(),
+	)
+
+	tcases := []struct {
+		entity string
+		params map[string]interface{}
+		expect string
+	}{
+		{"subnet", map[string]interface{}{"id": "sub_1", "public": false}, "sub_1"},
+		{"subnet", map[string]interface{}{"id": "sub_2", "public": false}, ""},
+		{"record", map[string]interface{}{"name": "www.example.com", "type": "A", "zone": "Z1"}, "rec_1"},
+		{"record", map[string]interface{}{"name": "www.example.com.", "type": "CNAME", "zone": "Z1"}, "rec_2"},
+		{"scalinggroup", map[string]interface{}{"name": "my-group", "max-size": 3}, "asg_arn"},
+	}
+	for i, tcase := range tcases {
+		res, err := findUpdatedResource(g, tcase.entity, tcase.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if res != nil {
+			got = res.Id()
+		}
+		if want := tcase.expect; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+type mockWebsiteS3 struct {
+	s3iface.S3API
+	website *s3.GetBucketWebsiteOutput
+}
+
+func (m *mockWebsiteS3) GetBucketWebsite(*s3.GetBucketWebsiteInput) (*s3.GetBucketWebsiteOutput, error) {
+	if m.website == nil {
+		return nil, awserr.New("NoSuchWebsiteConfiguration", "The specified bucket does not have a website configuration", nil)
+	}
+	return m.website, nil
+}
+
+func TestBucketWebsiteState(t *testing.T) {
+	tcases := []struct {
+		website *s3.GetBucketWebsiteOutput
+		params  map[string]interface{}
+		expect  map[string]interface{}
+	}{
+		{nil, map[string]interface{}{"acl": "private"}, nil},
+		{nil, map[string]interface{}{"public-website": true}, map[string]interface{}{"public-website": false}},
+		{
+			&s3.GetBucketWebsiteOutput{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.htm")}},
+			map[string]interface{}{"public-website": false},
+			map[string]interface{}{"public-website": true, "index-suffix": "index.htm"},
+		},
+		{
+			&s3.GetBucketWebsiteOutput{RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{HostName: aws.String("example.com"), Protocol: aws.String("https")}},
+			map[string]interface{}{"public-website": false},
+			map[string]interface{}{"public-website": true, "redirect-hostname": "example.com", "enforce-https": true},
+		},
+	}
+	for i, tcase := range tcases {
+		prior, err := bucketWebsiteState(&mockWebsiteS3{website: tcase.website}, "my-bucket", tcase.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(prior), len(tcase.expect); got != want {
+			t.Fatalf("%d: got %v, want %v", i+1, prior, tcase.expect)
+		}
+		for k, v := range tcase.expect {
+			if got, want := prior[k], v; got != want {
+				t.Fatalf("%d: %s: got %v, want %v", i+1, k, got, want)
+			}
+		}
+	}
+}
@@ -19,9 +19,12 @@ package commands
 import (
 	"errors"
 	"fmt"
-
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/chzyer/readline"
 	"github.com/spf13/cobra"
 	"github.com/wallix/awless/config"
 	"github.com/wallix/awless/database"
@@ -29,14 +32,22 @@ import (
 	"github.com/wallix/awless/template"
 )
 
+var (
+	revertOnlyFlag, revertExceptFlag []int
+	revertSelectFlag                 bool
+)
+
 func init() {
 	RootCmd.AddCommand(revertCmd)
+	revertCmd.Flags().IntSliceVar(&revertOnlyFlag, "only", nil, "Revert only the commands at the given positions (as numbered by awless log REVERTID). Ex: --only 2,3")
+	revertCmd.Flags().IntSliceVar(&revertExceptFlag, "except", nil, "Revert all the commands except the ones at the given positions. Ex: --except 1")
+	revertCmd.Flags().BoolVar(&revertSelectFlag, "select", false, "Interactively select the commands to revert")
 }
 
 var revertCmd = &cobra.Command{
 	Use:               "revert REVERTID",
 	Short:             "Revert a template execution given a revert ID (see `awless log` to list revert ids)",
-	Example:           "  awless revert 01BA7RV6ES86PZYCM3H28WM6KZ",
+	Example:           "  awless revert 01BA7RV6ES86PZYCM3H28WM6KZ\n  awless revert 01BA7RV6ES86PZYCM3H28WM6KZ --only 2,3\n  awless revert 01BA7RV6ES86PZYCM3H28WM6KZ --select",
 	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook, initCloudServicesHook, initSyncerHook, firstInstallDoneHook),
 	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade, networkMonitorHook),
 
@@ -63,7 +74,15 @@ var revertCmd = &cobra.Command{
 			logger.Warningf("This template was originally run with profile %s", prof)
 		}
 
-		reverted, err := loaded.Template.Revert()
+		positions, err := revertPositions(loaded.Template)
+		exitOn(err)
+
+		var reverted *template.Template
+		if positions == nil {
+			reverted, err = loaded.Template.Revert()
+		} else {
+			reverted, err = loaded.Template.RevertCommands(positions)
+		}
 		exitOn(err)
 
 		tplExec := &template.TemplateExecution{
@@ -79,3 +98,102 @@ var revertCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// revertPositions returns the positions of the commands to revert given the flags,
+// or nil to revert them all
+func revertPositions(tpl *template.Template) ([]int, error) {
+	var set int
+	for _, isSet := range []bool{len(revertOnlyFlag) > 0, len(revertExceptFlag) > 0, revertSelectFlag} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, errors.New("--only, --except and --select are mutually exclusive")
+	}
+
+	var positions []int
+	var err error
+	switch {
+	case len(revertOnlyFlag) > 0:
+		positions = revertOnlyFlag
+	case len(revertExceptFlag) > 0:
+		positions = exceptPositions(tpl.RevertiblePositions(), revertExceptFlag)
+	case revertSelectFlag:
+		if nonInteractiveGlobalFlag {
+			return nil, &missingValuesError{values: []string{"selection of the commands to revert (use --only or --except instead of --select)"}}
+		}
+		if positions, err = selectRevertPositions(tpl); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+	if len(positions) == 0 {
+		return nil, errors.New("no command selected to revert")
+	}
+	return positions, nil
+}
+
+func exceptPositions(positions, except []int) (out []int) {
+	excluded := make(map[int]bool)
+	for _, p := range except {
+		excluded[p] = true
+	}
+	for _, p := range positions {
+		if !excluded[p] {
+			out = append(out, p)
+		}
+	}
+	return
+}
+
+// selectRevertPositions lists the commands of the template and prompts for the ones to revert
+func selectRevertPositions(tpl *template.Template) ([]int, error) {
+	revertible := make(map[int]bool)
+	for _, p := range tpl.RevertiblePositions() {
+		revertible[p] = true
+	}
+	for i, cmd := range tpl.CommandNodesIterator() {
+		line := fmt.Sprintf("%3d. %s", i+1, cmd)
+		if !revertible[i+1] {
+			line = renderYellowFn(line + " (not revertible)")
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	l, err := readline.NewEx(&readline.Config{
+		Prompt:          "Commands to revert (ex: 2,3; empty for all): ",
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	line, err := l.Readline()
+	if err == readline.ErrInterrupt {
+		return nil, errors.New("interrupted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(line) == "" {
+		return tpl.RevertiblePositions(), nil
+	}
+	return parsePositions(line)
+}
+
+func parsePositions(s string) (positions []int, err error) {
+	for _, split := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		p, err := strconv.Atoi(split)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command position '%s'", split)
+		}
+		positions = append(positions, p)
+	}
+	sort.Ints(positions)
+	return
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRevertPositions(t *testing.T) {
+	if got, want := exceptPositions([]int{1, 2, 4, 5}, []int{2, 5, 7}), []int{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	tcases := []struct {
+		in     string
+		expect []int
+		err    bool
+	}{
+		{in: "3", expect: []int{3}},
+		{in: "4,2", expect: []int{2, 4}},
+		{in: " 1, 3 5 ", expect: []int{1, 3, 5}},
+		{in: "1,a", err: true},
+	}
+	for i, tcase := range tcases {
+		positions, err := parsePositions(tcase.in)
+		if tcase.err {
+			if err == nil {
+				t.Fatalf("%d: expected error got none", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := positions, tcase.expect; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %v, want %v", i+1, got, want)
+		}
+	}
+}
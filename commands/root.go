@@ -22,17 +22,19 @@ import (
 )
 
 var (
-	verboseGlobalFlag      bool
-	extraVerboseGlobalFlag bool
-	silentGlobalFlag       bool
-	localGlobalFlag        bool
-	noSyncGlobalFlag       bool
-	forceGlobalFlag        bool
-	versionGlobalFlag      bool
-	awsRegionGlobalFlag    string
-	awsProfileGlobalFlag   string
-	awsColorGlobalFlag     string
-	networkMonitorFlag     bool
+	verboseGlobalFlag        bool
+	extraVerboseGlobalFlag   bool
+	silentGlobalFlag         bool
+	localGlobalFlag          bool
+	noSyncGlobalFlag         bool
+	forceGlobalFlag          bool
+	nonInteractiveGlobalFlag bool
+	approveGlobalFlag        string
+	versionGlobalFlag        bool
+	awsRegionGlobalFlag      string
+	awsProfileGlobalFlag     string
+	awsColorGlobalFlag       string
+	networkMonitorFlag       bool
 
 	renderGreenFn    = color.New(color.FgGreen).SprintFunc()
 	renderRedFn      = color.New(color.FgRed).SprintFunc()
@@ -47,6 +49,8 @@ func init() {
 	RootCmd.PersistentFlags().BoolVar(&silentGlobalFlag, "silent", false, "Turn on silent mode for all commands: disable logging, etc...")
 	RootCmd.PersistentFlags().BoolVarP(&localGlobalFlag, "local", "l", false, "Work offline only using locally synced resources")
 	RootCmd.PersistentFlags().BoolVarP(&forceGlobalFlag, "force", "f", false, "Force the command and bypass confirmation prompts")
+	RootCmd.PersistentFlags().BoolVar(&nonInteractiveGlobalFlag, "non-interactive", false, "Never prompt: fail listing all the missing values (holes, confirmation, credentials) with exit code 3")
+	RootCmd.PersistentFlags().StringVar(&approveGlobalFlag, "approve", "", "Approval token for the commands the policy file requires approval for")
 	RootCmd.PersistentFlags().BoolVar(&noSyncGlobalFlag, "no-sync", false, "Do not run any sync on command")
 	RootCmd.PersistentFlags().StringVarP(&awsRegionGlobalFlag, "aws-region", "r", "", "Override AWS region temporarily for the current command")
 	RootCmd.PersistentFlags().SetAnnotation("aws-region", cobra.BashCompCustom, []string{"__awless_region_list"})
@@ -45,9 +45,11 @@ import (
 	"github.com/wallix/awless/cloud/match"
 	"github.com/wallix/awless/cloud/properties"
 	"github.com/wallix/awless/config"
+	"github.com/wallix/awless/graph"
 	"github.com/wallix/awless/logger"
 	"github.com/wallix/awless/sync"
 	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
 	"github.com/wallix/awless/template/params"
 )
 
@@ -58,6 +60,16 @@ var (
 	listRemoteTemplatesFlag bool
 	noSuggestedParamsFlag   bool
 	allSuggestedParamsFlag  bool
+	runWorkersFlag          int
+	runOnErrorFlag          string
+	runOutputsFlag          string
+	runReportFlag           string
+	runReportFileFlag       string
+	runPlanOutFlag          string
+	helpTemplateFlag        bool
+	runValuesFlag           []string
+	runValuesEnvFlag        string
+	allowLocalFunctionsFlag bool
 )
 
 func init() {
@@ -66,6 +78,17 @@ func init() {
 	runCmd.Flags().StringVar(&scheduleRunInFlag, "run-in", "", "Postpone the execution of this template")
 	runCmd.Flags().StringVar(&scheduleRevertInFlag, "revert-in", "", "Schedule the revertion of this template")
 	runCmd.Flags().StringVarP(&runLogMessage, "message", "m", "", "Add a message for this template execution to be persisted in your logs")
+	runCmd.Flags().StringVar(&runOnErrorFlag, "on-error", template.StopOnError, "Behaviour when a command fails: 'stop' the run, 'continue' with the commands not depending on it, or 'rollback' the executed commands")
+	runCmd.Flags().StringVar(&runOutputsFlag, "outputs", "", "Print the template outputs once run as json, yaml or env (ex: --outputs=env)")
+	runCmd.Flags().Lookup("outputs").NoOptDefVal = "json"
+	runCmd.Flags().StringVar(&runReportFlag, "report", "", "Write a report of the run with each command params, result, error, duration and revert ID as json or junit to the file given by --report-file")
+	runCmd.Flags().StringVar(&runPlanOutFlag, "plan-out", "", "Compile, resolve and dry run the template, saving it as a plan in this file instead of running it. Run the plan with `awless apply`")
+	runCmd.Flags().StringVar(&runReportFileFlag, "report-file", "", "Write the report given by --report to this file, required with --report")
+	runCmd.Flags().StringSliceVar(&runValuesFlag, "values", nil, "YAML or JSON files of holes values, the later overriding the former (ex: --values base.yml --values prod.yml)")
+	runCmd.Flags().StringVar(&runValuesEnvFlag, "values-env", "", "Read holes values from the environment variables with this prefix, overriding values files (ex: --values-env AWLESS_ fills {instance.type} with $AWLESS_INSTANCE_TYPE)")
+	runCmd.Flags().BoolVar(&helpTemplateFlag, "help-template", false, "List the params declared by the template and the holes it has without running it")
+	runCmd.Flags().BoolVar(&allowLocalFunctionsFlag, "allow-local-functions", false, "Allow the env() and file() functions, reading your environment variables and files, in remote templates")
+	runCmd.Flags().IntVar(&runWorkersFlag, "workers", 1, "Maximum number of template commands without dependencies between them run concurrently")
 
 	var actions []string
 	for a := range awsspec.DriverSupportedActions {
@@ -86,10 +109,16 @@ func init() {
 const maxMsgLen = 140
 
 var runCmd = &cobra.Command{
-	Use:               "run PATH",
-	Short:             "Run a template given a filepath or URL",
-	Example:           "  awless run ~/templates/my-infra.txt\n  awless run https://raw.githubusercontent.com/wallix/awless-templates/master/create_vpc.awls\n  awless run repo:create_vpc",
-	PersistentPreRun:  applyHooks(initLoggerHook, initAwlessEnvHook, initCloudServicesHook, initSyncerHook, firstInstallDoneHook),
+	Use:     "run PATH",
+	Short:   "Run a template given a filepath or URL",
+	Example: "  awless run ~/templates/my-infra.txt\n  awless run https://raw.githubusercontent.com/wallix/awless-templates/master/create_vpc.awls\n  awless run repo:create_vpc\n  awless run --help-template repo:create_vpc",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if helpTemplateFlag { // describing a template does not access the cloud
+			applyHooks(initLoggerHook, initAwlessEnvHook)(cmd, args)
+			return
+		}
+		applyHooks(initLoggerHook, initAwlessEnvHook, initCloudServicesHook, initSyncerHook, firstInstallDoneHook)(cmd, args)
+	},
 	PersistentPostRun: applyHooks(verifyNewVersionHook, onVersionUpgrade, networkMonitorHook),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -104,6 +133,15 @@ var runCmd = &cobra.Command{
 		if len(runLogMessage) > maxMsgLen {
 			exitOn(fmt.Errorf("message to be persisted should not exceed %d characters", maxMsgLen))
 		}
+		if runPlanOutFlag != "" && isSchedulingMode() {
+			exitOn(errors.New("cannot both save a plan and schedule a template"))
+		}
+		if runReportFlag != "" && runReportFlag != "json" && runReportFlag != "junit" {
+			exitOn(fmt.Errorf("unknown report format '%s', expecting json or junit", runReportFlag))
+		}
+		if runReportFlag != "" && runReportFileFlag == "" {
+			exitOn(errors.New("missing --report-file: the report cannot be printed along with the run output on stdout"))
+		}
 
 		content, fullPath, err := getTemplateText(args[0])
 		exitOn(err)
@@ -113,8 +151,27 @@ var runCmd = &cobra.Command{
 		templ, err := template.Parse(string(content))
 		exitOn(err)
 
+		if helpTemplateFlag {
+			printTemplateHelp(templ)
+			return nil
+		}
+
+		var fillers []map[string]interface{}
+		for _, path := range runValuesFlag {
+			content, err := ioutil.ReadFile(path)
+			exitOn(err)
+			values, err := template.ParseFillers(content)
+			if err != nil {
+				exitOn(fmt.Errorf("values file '%s': %s", path, err))
+			}
+			fillers = append(fillers, values)
+		}
+		envValues, err := template.EnvFillers(runValuesEnvFlag, os.Environ())
+		exitOn(err)
+
 		extraParams, err := template.ParseParams(strings.Join(args[1:], " "))
 		exitOn(err)
+		fillers = append(fillers, envValues, extraParams)
 
 		tplExec := &template.TemplateExecution{
 			Template: templ,
@@ -125,15 +182,15 @@ var runCmd = &cobra.Command{
 			Source:   templ.String(),
 		}
 
-		exitOn(NewRunnerRequiredParamsOnly(tplExec.Template, tplExec.Message, tplExec.Path, config.Defaults, extraParams).Run())
+		exitOn(NewRunnerRequiredParamsOnly(tplExec.Template, tplExec.Message, tplExec.Path, fillers...).Run())
 
 		return nil
 	},
 }
 
-func missingHolesStdinFunc() func(string, []string, bool) string {
+func missingHolesStdinFunc() func(string, []string, bool, *env.Param) string {
 	var count int
-	return func(hole string, paramPaths []string, optional bool) (response string) {
+	return func(hole string, paramPaths []string, optional bool, param *env.Param) (response string) {
 		if count < 1 {
 			fmt.Println("Please specify (Ctrl+C to quit, Tab for completion, Enter to skip optionals):")
 		}
@@ -154,6 +211,12 @@ func missingHolesStdinFunc() func(string, []string, bool) string {
 				typedParam = tparam
 			}
 		}
+		if param != nil && param.Description != "" {
+			docs = []string{param.Description}
+		}
+		if param != nil && len(param.Enum) > 0 {
+			enums = param.Enum
+		}
 		if len(docs) > 0 {
 			fmt.Fprintln(os.Stderr, strings.Join(docs, "; ")+":")
 		}
@@ -168,12 +231,15 @@ func missingHolesStdinFunc() func(string, []string, bool) string {
 		}
 
 		var promptSuffix string
-		if optional {
+		hasDefault := param != nil && param.Default != nil
+		if hasDefault {
+			promptSuffix = fmt.Sprintf(" (default: %v)", param.Default)
+		} else if optional {
 			promptSuffix = " (optional)"
 		}
 		var err error
 		for response, err = askHole(hole, promptSuffix, autocomplete); err != nil; response, err = askHole(hole, promptSuffix, autocomplete) {
-			if optional {
+			if optional || hasDefault {
 				return ""
 			}
 			logger.Error(err)
@@ -183,6 +249,36 @@ func missingHolesStdinFunc() func(string, []string, bool) string {
 	}
 }
 
+func printTemplateHelp(tpl *template.Template) {
+	declared := make(map[string]bool)
+	if tplParams := tpl.Params(); len(tplParams) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "PARAM\tTYPE\tDEFAULT\tVALUES\tDESCRIPTION")
+		for _, p := range tplParams {
+			declared[p.Name] = true
+			var def string
+			if p.Default != nil {
+				def = fmt.Sprint(p.Default)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Type, def, strings.Join(p.Enum, ", "), p.Description)
+		}
+		w.Flush()
+	}
+
+	var undeclared []string
+	for _, hole := range tpl.HoleNames() {
+		if !declared[hole] {
+			undeclared = append(undeclared, hole)
+		}
+	}
+	if len(undeclared) > 0 {
+		if len(declared) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Undeclared holes: %s\n", strings.Join(undeclared, ", "))
+	}
+}
+
 func askHole(hole, promptSuffix string, autocomplete readline.AutoCompleter) (string, error) {
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:          renderCyanBoldFn(hole+"?") + renderYellowFn(promptSuffix) + " ",
@@ -279,6 +375,17 @@ func createDriverCommands(action string, entities []string) *cobra.Command {
 		run := func(def awsspec.Definition) func(cmd *cobra.Command, args []string) error {
 			return func(cmd *cobra.Command, args []string) error {
 				text := fmt.Sprintf("%s %s %s", def.Action, def.Entity, strings.Join(args, " "))
+				if cascadeFlag {
+					params, err := template.ParseParams(strings.Join(args, " "))
+					exitOn(err)
+					if _, ok := params["id"]; !ok {
+						exitOn(errors.New("cascade: missing id param (ex: awless delete vpc id=vpc-1234 --cascade)"))
+					}
+					g, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+					exitOn(err)
+					text, err = cascadeDeleteVpcTemplate(g.(*graph.Graph), fmt.Sprint(params["id"]))
+					exitOn(err)
+				}
 
 				templ, err := template.Parse(text)
 				if err != nil {
@@ -297,7 +404,7 @@ func createDriverCommands(action string, entities []string) *cobra.Command {
 					Source:   templ.String(),
 				}
 
-				exitOn(NewRunner(tplExec.Template, tplExec.Message, tplExec.Path, config.Defaults).Run())
+				exitOn(NewRunner(tplExec.Template, tplExec.Message, tplExec.Path).Run())
 				return nil
 			}
 		}
@@ -344,6 +451,9 @@ func createDriverCommands(action string, entities []string) *cobra.Command {
 {{end}}{{if or .Runnable .HasSubCommands}}{{.UsageString}}{{end}}`)
 		currentCmd.Flags().BoolVar(&noSuggestedParamsFlag, "prompt-only-required", false, "Prompt only required parameters")
 		currentCmd.Flags().BoolVarP(&allSuggestedParamsFlag, "prompt-all", "a", false, "Prompt all non-provided parameters")
+		if action == "delete" && templDef.Entity == "vpc" {
+			currentCmd.Flags().BoolVar(&cascadeFlag, "cascade", false, "Delete the VPC along with its instances, load balancers, target groups, gateways, subnets, route tables and security groups as found in the local graph, failing on other resources (ex: databases)")
+		}
 
 		actionCmd.AddCommand(currentCmd)
 	}
@@ -430,6 +540,22 @@ func resolveAliasFunc(paramPath, alias string) string {
 	return matchingResource.Id()
 }
 
+// resourceExistsFunc tells whether a resource of the entity, or of any entity
+// when not given, is named as such in the local graph
+func resourceExistsFunc(entity, name string) (bool, error) {
+	gph, err := sync.LoadLocalGraphs(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return false, fmt.Errorf("cannot load local graphs for region %s: %s", config.GetAWSRegion(), err)
+	}
+	var resources []cloud.Resource
+	if entity == "" {
+		resources, err = gph.FindWithProperties(map[string]interface{}{properties.Name: name})
+	} else {
+		resources, err = gph.Find(cloud.NewQuery(entity).Match(match.Property(properties.Name, name)))
+	}
+	return len(resources) > 0, err
+}
+
 func oneLinerShortDesc(action string, entities []string) string {
 	if len(entities) > 5 {
 		return fmt.Sprintf("%s, \u2026 (see `awless %s -h` for more)", strings.Join(entities[0:5], ", "), action)
@@ -673,6 +799,9 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 `
 
 func promptConfirmDefaultYes(msg string, a ...interface{}) bool {
+	if nonInteractiveGlobalFlag {
+		exitOn(&missingValuesError{values: []string{fmt.Sprintf("confirmation: %s", strings.TrimSpace(fmt.Sprintf(msg, a...)))}})
+	}
 	var yesorno string
 	fmt.Fprintf(os.Stderr, "%s [Y/n] ", fmt.Sprintf(msg, a...))
 	fmt.Scanln(&yesorno)
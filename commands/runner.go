@@ -31,9 +31,19 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 	runner.Log = logger.DefaultLogger
 	runner.Message = msg
 	runner.TemplatePath = tplPath
+	runner.AllowLocalFunctions = allowLocalFunctionsFlag
 	runner.Fillers = fillers
+	runner.Defaults = config.Defaults
 	runner.AliasFunc = resolveAliasFunc
-	runner.MissingHolesFunc = missingHolesStdinFunc()
+	resolvedExists := make(map[string]bool)
+	runner.ExistsFunc = template.RecordExistsFunc(resourceExistsFunc, resolvedExists)
+	if !nonInteractiveGlobalFlag {
+		runner.MissingHolesFunc = missingHolesStdinFunc()
+	}
+	runner.IncludeFunc = getTemplateText
+	runner.PriorStateFunc = priorStateFunc
+	runner.Workers = runWorkersFlag
+	runner.OnError = runOnErrorFlag
 	if allSuggestedParamsFlag {
 		runner.ParamsSuggested = env.ALL_PARAMS
 	}
@@ -48,6 +58,11 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 		}},
 		&template.ParamIsSetValidator{Action: "create", Entity: "instance", Param: "keypair", WarningMessage: "This instance has no access keypair. You might not be able to connect to it. Use `awless create instance keypair=my-keypair ...`"},
 	}
+	if rules, err := loadRules(); err != nil {
+		exitOn(err)
+	} else if rules != nil {
+		runner.Validators = append(runner.Validators, rules)
+	}
 
 	runner.CmdLookuper = func(tokens ...string) interface{} {
 		newCommandFunc := awsspec.CommandFactory.Build(strings.Join(tokens, ""))
@@ -58,11 +73,20 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 	}
 
 	runner.BeforeRun = func(tplExec *template.TemplateExecution) (bool, error) {
+		if runPlanOutFlag != "" {
+			return false, writePlan(tplExec, runPlanOutFlag, resolvedExists)
+		}
+		if err := enforcePolicy(tplExec); err != nil {
+			return false, err
+		}
 		var yesorno string
 		if forceGlobalFlag {
 			yesorno = "y"
+		} else if nonInteractiveGlobalFlag {
+			return false, &missingValuesError{values: []string{"confirmation to run the template (use --force to bypass it)"}}
 		} else {
 			fmt.Printf("%s\n\n", renderGreenFn(tplExec.Template))
+			printCostEstimate(os.Stdout, tplExec.Template)
 			if isSchedulingMode() {
 				fmt.Print("Confirm scheduling? [y/N] ")
 			} else {
@@ -74,13 +98,7 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 		}
 
 		if strings.TrimSpace(strings.ToLower(yesorno)) == "y" {
-			me, err := awsservices.AccessService.(*awsservices.Access).GetIdentity()
-			if err != nil {
-				logger.Warningf("cannot resolve template author identity: %s", err)
-			} else {
-				tplExec.Author = me.ResourcePath
-				logger.ExtraVerbosef("resolved template author: %s", tplExec.Author)
-			}
+			resolveTemplateAuthor(tplExec)
 			if isSchedulingMode() {
 				return false, scheduleTemplate(tplExec.Template, scheduleRunInFlag, scheduleRevertInFlag)
 			}
@@ -90,6 +108,8 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 		return false, nil
 	}
 
+	runner.BeforeRollback = enforcePolicy
+
 	runner.AfterRun = func(tplExec *template.TemplateExecution) error {
 		if tplExec.Message == "" {
 			if tplExec.IsOneLiner() {
@@ -110,15 +130,46 @@ func NewRunner(tpl *template.Template, msg, tplPath string, fillers ...map[strin
 			logger.Errorf("Cannot save executed template in awless logs: %s", err)
 		}
 
-		if template.IsRevertible(tplExec.Template) {
+		if template.IsRevertible(tplExec.Template) && tplExec.RolledBackBy == "" {
 			fmt.Println()
 			logger.Infof("Revert this template with `awless revert %s`", tplExec.Template.ID)
 		}
 
 		runSyncFor(tplExec)
 
+		if runOutputsFlag != "" {
+			printer := &outputsPrinter{os.Stdout, runOutputsFlag}
+			if err := printer.print(tplExec); err != nil {
+				logger.Error(err)
+			}
+		}
+
+		if runReportFlag != "" && tplExec.RollbackOf == "" {
+			if err := writeReport(template.NewReport(tplExec)); err != nil {
+				logger.Error(err)
+			}
+		}
+
 		return nil
 	}
 
+	runner.AfterFailure = func(tplExec *template.TemplateExecution, err error) {
+		if runReportFlag != "" {
+			if err := writeReport(template.NewFailedReport(tplExec, err)); err != nil {
+				logger.Error(err)
+			}
+		}
+	}
+
 	return runner
 }
+
+func resolveTemplateAuthor(tplExec *template.TemplateExecution) {
+	me, err := awsservices.AccessService.(*awsservices.Access).GetIdentity()
+	if err != nil {
+		logger.Warningf("cannot resolve template author identity: %s", err)
+		return
+	}
+	tplExec.Author = me.ResourcePath
+	logger.ExtraVerbosef("resolved template author: %s", tplExec.Author)
+}
@@ -32,6 +32,9 @@ var (
 	DBPath             = filepath.Join(AwlessHome, database.Filename)
 	Dir                = filepath.Join(AwlessHome, "aws")
 	KeysDir            = filepath.Join(AwlessHome, "keys")
+	PolicyPath         = filepath.Join(AwlessHome, "policy.yml")
+	RulesPath          = filepath.Join(AwlessHome, "rules.yml")
+	PricesPath         = filepath.Join(AwlessHome, "prices.yml")
 	AwlessFirstInstall bool
 )
 
@@ -174,6 +174,10 @@ func Certificate(id string) *rBuilder {
 	return new("certificate", id)
 }
 
+func Database(id string) *rBuilder {
+	return new("database", id)
+}
+
 func AccessKey(id string) *rBuilder {
 	return new("accesskey", id)
 }
@@ -0,0 +1,207 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/wallix/awless/aws/doc"
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/template/params"
+)
+
+var declarationRegex = regexp.MustCompile(`^\s*[a-zA-Z0-9-_.]+\s*=\s*`)
+
+// complete proposes actions, entities, params, enum values or aliases
+// for the command being typed on the line before the cursor rune offset
+func complete(line string, offset int, aliasesFunc func(string) []string) []*completionItem {
+	items := []*completionItem{}
+	prefix := prefixOf(line, offset)
+	if strings.Contains(prefix, "#") {
+		return items
+	}
+	words, _ := commandWords(prefix)
+
+	var typed string
+	if len(words) > 0 && strings.TrimRightFunc(prefix, unicode.IsSpace) == prefix {
+		typed, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	switch len(words) {
+	case 0:
+		for _, action := range sortedActions() {
+			if strings.HasPrefix(action, typed) {
+				items = append(items, &completionItem{Label: action, Kind: completionKindKeyword})
+			}
+		}
+	case 1:
+		for _, entity := range awsspec.DriverSupportedActions[words[0]] {
+			if strings.HasPrefix(entity, typed) {
+				items = append(items, &completionItem{Label: entity, Kind: completionKindKeyword, Documentation: awsdoc.AwlessCommandDefinitionsDoc(words[0], entity, "")})
+			}
+		}
+	default:
+		action, entity := words[0], words[1]
+		def, ok := awsspec.AWSLookupDefinitions(action + entity)
+		if !ok {
+			return items
+		}
+		if i := strings.Index(typed, "="); i > -1 {
+			key, value := typed[:i], typed[i+1:]
+			var values []string
+			if strings.HasPrefix(value, "@") {
+				if aliasesFunc != nil {
+					values = aliasesFunc(fmt.Sprintf("%s.%s.%s", action, entity, key))
+				}
+			} else {
+				values = awsdoc.EnumDoc[fmt.Sprintf("%s.%s.%s", action, entity, key)]
+			}
+			for _, v := range values {
+				if v != "" && strings.HasPrefix(v, value) {
+					items = append(items, &completionItem{Label: v, Kind: completionKindValue})
+				}
+			}
+			return items
+		}
+
+		set := make(map[string]bool)
+		for _, w := range words[2:] {
+			set[strings.SplitN(w, "=", 2)[0]] = true
+		}
+		required, optionals, _ := params.List(def.Params)
+		for _, keys := range []struct {
+			detail string
+			keys   []string
+		}{{"required", required}, {"optional", optionals}} {
+			for _, key := range keys.keys {
+				if set[key] || !strings.HasPrefix(key, typed) {
+					continue
+				}
+				paramDoc, _ := awsdoc.TemplateParamsDocWithEnums(action, entity, key)
+				items = append(items, &completionItem{Label: key, Kind: completionKindProperty, Detail: keys.detail, Documentation: paramDoc, InsertText: key + "="})
+			}
+		}
+	}
+	return items
+}
+
+// describe returns the documentation of the command
+// or of the param under the cursor rune offset on the line
+func describe(line string, offset int) string {
+	words, starts := commandWords(line)
+	index := -1
+	for i, start := range starts {
+		if offset >= start && offset <= start+len([]rune(words[i])) {
+			index = i
+		}
+	}
+	if index < 0 || len(words) < 2 {
+		return ""
+	}
+
+	action, entity := words[0], words[1]
+	def, ok := awsspec.AWSLookupDefinitions(action + entity)
+	if !ok {
+		return ""
+	}
+	if index > 1 {
+		key := strings.SplitN(words[index], "=", 2)[0]
+		if paramDoc, ok := awsdoc.TemplateParamsDocWithEnums(action, entity, key); ok {
+			return fmt.Sprintf("%s: %s", key, paramDoc)
+		}
+		return ""
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(awsdoc.AwlessCommandDefinitionsDoc(action, entity, fmt.Sprintf("%s %s", action, entity)))
+	required, optionals, _ := params.List(def.Params)
+	if len(required) > 0 {
+		buf.WriteString(fmt.Sprintf("\n\nRequired params: %s", strings.Join(required, ", ")))
+	}
+	if len(optionals) > 0 {
+		buf.WriteString(fmt.Sprintf("\nOptional params: %s", strings.Join(optionals, ", ")))
+	}
+	if examples := awsdoc.AwlessExamplesDoc(action, entity); examples != "" {
+		buf.WriteString("\n\nExamples:\n")
+		buf.WriteString(examples)
+	}
+	return buf.String()
+}
+
+// commandWords splits a command, possibly declared, in words
+// with the rune offset of each word in the line
+func commandWords(line string) (words []string, starts []int) {
+	runes := []rune(line)
+	offset := 0
+	if loc := declarationRegex.FindStringIndex(line); loc != nil {
+		offset = len([]rune(line[:loc[1]]))
+	}
+	for i := offset; i < len(runes); i++ {
+		if runes[i] == '#' {
+			break
+		}
+		if unicode.IsSpace(runes[i]) {
+			continue
+		}
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		words = append(words, string(runes[start:i]))
+		starts = append(starts, start)
+	}
+	return
+}
+
+func sortedActions() (actions []string) {
+	for action := range awsspec.DriverSupportedActions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return
+}
+
+func prefixOf(line string, offset int) string {
+	runes := []rune(line)
+	if offset > len(runes) {
+		offset = len(runes)
+	}
+	return string(runes[:offset])
+}
+
+func lineAt(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line], "\r")
+}
+
+// wordEnd returns the offset of the end of the word starting at the given offset
+func wordEnd(line string, start int) int {
+	runes := []rune(line)
+	end := start
+	for end < len(runes) && !unicode.IsSpace(runes[end]) {
+		end++
+	}
+	return end
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Subset of the Language Server Protocol messages used by the server,
+// see https://microsoft.github.io/language-server-protocol/specification
+
+const (
+	methodNotFound = -32601
+	invalidParams  = -32602
+)
+
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	completionKindKeyword  = 14
+	completionKindProperty = 10
+	completionKindValue    = 12
+
+	textDocumentSyncFull = 1
+)
+
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *responseError   `json:"error"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// runeOffset converts a position character on the line, counted in UTF-16
+// code units by the protocol, to the rune offset used by the templates positions
+func runeOffset(line string, character int) int {
+	var units int
+	runes := []rune(line)
+	for i, r := range runes {
+		if units >= character {
+			return i
+		}
+		units += utf16Len(r)
+	}
+	return len(runes)
+}
+
+// utf16Offset converts a rune offset on the line to a position character
+func utf16Offset(line string, offset int) (units int) {
+	for i, r := range []rune(line) {
+		if i >= offset {
+			break
+		}
+		units += utf16Len(r)
+	}
+	return
+}
+
+func utf16Len(r rune) int {
+	if r > 0xFFFF {
+		return 2 // surrogate pair
+	}
+	return 1
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string        `json:"uri"`
+	Diagnostics []*diagnostic `json:"diagnostics"`
+}
+
+type completionItem struct {
+	Label         string `json:"label"`
+	Kind          int    `json:"kind,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+	InsertText    string `json:"insertText,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+}
+
+// readMessage reads a message content framed by its Content-Length header
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if splits := strings.SplitN(line, ":", 2); len(splits) == 2 && strings.EqualFold(splits[0], "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(splits[1])); err != nil {
+				return nil, fmt.Errorf("invalid content length: %s", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing content length header")
+	}
+	content := make([]byte, length)
+	_, err := io.ReadFull(r, content)
+	return content, err
+}
+
+func writeMessage(w io.Writer, msg interface{}) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2017 WALLIX
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
+)
+
+type server struct {
+	cenvFunc    func(path string) env.Compiling
+	aliasesFunc func(paramPath string) []string
+
+	documents map[string]string
+	out       io.Writer
+}
+
+// New returns a language server for awless templates. The compile env given
+// for a template path is used to lint the template, the aliases given for
+// a param path, ex: create.subnet.vpc, are proposed as completion of this param
+func New(cenvFunc func(path string) env.Compiling, aliasesFunc func(paramPath string) []string) *server {
+	return &server{cenvFunc: cenvFunc, aliasesFunc: aliasesFunc, documents: make(map[string]string)}
+}
+
+// Serve handles the requests read from in, writing responses and notifications to out,
+// until the client asks to exit or closes the input
+func (s *server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := bufio.NewReader(in)
+	for {
+		content, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("invalid message: %s", err)
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(req.Method, req.Params)
+		if req.ID == nil {
+			continue
+		}
+		if err != nil {
+			rerr, ok := err.(*responseError)
+			if !ok {
+				rerr = &responseError{Code: invalidParams, Message: err.Error()}
+			}
+			err = writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: req.ID, Error: rerr})
+		} else {
+			err = writeMessage(s.out, &response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   textDocumentSyncFull,
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{" ", "=", "@"}},
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]string{"name": "awless"},
+		}, nil
+	case "initialized", "shutdown", "$/cancelRequest":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		s.documents[p.TextDocument.URI] = p.TextDocument.Text
+		return nil, s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) > 0 {
+			s.documents[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		return nil, s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.documents, p.TextDocument.URI)
+		return nil, writeMessage(s.out, &notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: &publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []*diagnostic{}}})
+	case "textDocument/completion":
+		var p textDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		line := lineAt(s.documents[p.TextDocument.URI], p.Position.Line)
+		return complete(line, runeOffset(line, p.Position.Character), s.aliasesFunc), nil
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		line := lineAt(s.documents[p.TextDocument.URI], p.Position.Line)
+		doc := describe(line, runeOffset(line, p.Position.Character))
+		if doc == "" {
+			return nil, nil
+		}
+		return &hover{Contents: markupContent{Kind: "plaintext", Value: doc}}, nil
+	default:
+		return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("method '%s' not supported", method)}
+	}
+}
+
+func (s *server) publishDiagnostics(uri string) error {
+	text := s.documents[uri]
+	diags := []*diagnostic{}
+
+	lints, err := template.Lint(text, s.cenvFunc(pathFromURI(uri)))
+	if err != nil {
+		diags = append(diags, &diagnostic{Severity: severityError, Source: "awless", Message: err.Error()})
+	}
+	for _, l := range lints {
+		d := &diagnostic{Severity: severityError, Source: "awless", Message: l.Message}
+		if l.Severity == template.LintWarning {
+			d.Severity = severityWarning
+		}
+		if l.Line > 0 {
+			line := lineAt(text, l.Line-1)
+			start, end := l.Column-1, wordEnd(line, l.Column-1)
+			d.Range = textRange{
+				Start: position{Line: l.Line - 1, Character: utf16Offset(line, start)},
+				End:   position{Line: l.Line - 1, Character: utf16Offset(line, end)},
+			}
+		}
+		diags = append(diags, d)
+	}
+
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: &publishDiagnosticsParams{URI: uri, Diagnostics: diags}})
+}
+
+func pathFromURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
@@ -0,0 +1,198 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/template"
+	"github.com/wallix/awless/template/env"
+)
+
+func TestServe(t *testing.T) {
+	cenvFunc := func(path string) env.Compiling {
+		return template.NewEnv().WithTemplatePath(path).WithLookupCommandFunc(func(tokens ...string) interface{} {
+			newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+			if newCommandFunc == nil {
+				return nil
+			}
+			return newCommandFunc()
+		}).Build()
+	}
+	aliasesFunc := func(paramPath string) []string {
+		if paramPath == "create.subnet.vpc" {
+			return []string{"@prod-vpc", "@test-vpc"}
+		}
+		return nil
+	}
+
+	text := "vpc = create vpc cidr=10.0.0.0/16 unknown=param\ncreate subnet vpc=@p"
+	var in bytes.Buffer
+	for i, req := range []struct {
+		method string
+		params interface{}
+	}{
+		{"initialize", map[string]interface{}{}},
+		{"textDocument/didOpen", map[string]interface{}{"textDocument": map[string]interface{}{"uri": "file:///tpl/infra.aws", "text": text}}},
+		{"textDocument/completion", &textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///tpl/infra.aws"}, Position: position{Line: 1, Character: 7}}},
+		{"textDocument/completion", &textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///tpl/infra.aws"}, Position: position{Line: 1, Character: 20}}},
+		{"textDocument/completion", &textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///tpl/infra.aws"}, Position: position{Line: 0, Character: 17}}},
+		{"textDocument/hover", &textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///tpl/infra.aws"}, Position: position{Line: 0, Character: 25}}},
+		{"unknown/method", nil},
+		{"exit", nil},
+	} {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": req.method, "params": req.params}
+		if req.method != "textDocument/didOpen" && req.method != "exit" {
+			msg["id"] = i
+		}
+		content, _ := json.Marshal(msg)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+
+	var out bytes.Buffer
+	if err := New(cenvFunc, aliasesFunc).Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		content, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+	if got, want := len(messages), 7; got != want {
+		t.Fatalf("got %d messages, want %d", got, want)
+	}
+
+	diags := messages[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if got, want := len(diags), 3; got != want {
+		t.Fatalf("got %d diagnostics, want %d: %v", got, want, diags)
+	}
+	unknownParam := diags[1].(map[string]interface{})
+	if got, want := unknownParam["message"], "create vpc: unknown param 'unknown'"; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	expRange := map[string]interface{}{"start": map[string]interface{}{"line": 0.0, "character": 34.0}, "end": map[string]interface{}{"line": 0.0, "character": 47.0}}
+	if got, want := unknownParam["range"], expRange; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	labels := func(msg map[string]interface{}) (labels []string) {
+		for _, item := range msg["result"].([]interface{}) {
+			labels = append(labels, item.(map[string]interface{})["label"].(string))
+		}
+		return
+	}
+	if got := labels(messages[2]); !contains(got, "vpc") || !contains(got, "subnet") || contains(got, "registry") {
+		t.Fatalf("unexpected entities completion: %v", got)
+	}
+	if got, want := labels(messages[3]), []string{"@prod-vpc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := labels(messages[4]); !contains(got, "cidr") || !contains(got, "name") {
+		t.Fatalf("unexpected params completion: %v", got)
+	}
+
+	hover := messages[5]["result"].(map[string]interface{})["contents"].(map[string]interface{})["value"].(string)
+	if !strings.HasPrefix(hover, "cidr: ") {
+		t.Fatalf("unexpected hover: %s", hover)
+	}
+	if got, want := messages[6]["error"].(map[string]interface{})["code"], float64(methodNotFound); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCommandWords(t *testing.T) {
+	tcases := []struct {
+		line   string
+		words  []string
+		starts []int
+	}{
+		{line: "create vpc cidr=10.0.0.0/16", words: []string{"create", "vpc", "cidr=10.0.0.0/16"}, starts: []int{0, 7, 11}},
+		{line: "  myvpc =  create vpc # comment", words: []string{"create", "vpc"}, starts: []int{11, 18}},
+		{line: "", words: nil, starts: nil},
+	}
+	for i, tcase := range tcases {
+		words, starts := commandWords(tcase.line)
+		if !reflect.DeepEqual(words, tcase.words) || !reflect.DeepEqual(starts, tcase.starts) {
+			t.Fatalf("%d: got %v %v, want %v %v", i+1, words, starts, tcase.words, tcase.starts)
+		}
+	}
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPositionsInUTF16CodeUnits(t *testing.T) {
+	line := "create tag key=Env value='dév 🚀' resource=vpc-1 unknown=param"
+	tcases := []struct {
+		character, offset int
+	}{
+		{character: 0, offset: 0},
+		{character: 27, offset: 27},
+		{character: 32, offset: 31},
+		{character: 49, offset: 48},
+		{character: 100, offset: 61},
+	}
+	for i, tcase := range tcases {
+		if got, want := runeOffset(line, tcase.character), tcase.offset; got != want {
+			t.Fatalf("%d: got %d, want %d", i+1, got, want)
+		}
+		if tcase.character <= 62 {
+			if got, want := utf16Offset(line, tcase.offset), tcase.character; got != want {
+				t.Fatalf("%d: got %d, want %d", i+1, got, want)
+			}
+		}
+	}
+
+	s := New(func(path string) env.Compiling {
+		return template.NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+			newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+			if newCommandFunc == nil {
+				return nil
+			}
+			return newCommandFunc()
+		}).Build()
+	}, nil)
+	var out bytes.Buffer
+	s.out = &out
+	s.documents["file:///tpl/tags.aws"] = line
+	if err := s.publishDiagnostics("file:///tpl/tags.aws"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := readMessage(bufio.NewReader(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Params publishDiagnosticsParams `json:"params"`
+	}
+	if err := json.Unmarshal(content, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(msg.Params.Diagnostics), 1; got != want {
+		t.Fatalf("got %d diagnostics, want %d: %s", got, want, content)
+	}
+	expRange := textRange{Start: position{Line: 0, Character: 49}, End: position{Line: 0, Character: 62}}
+	if got, want := msg.Params.Diagnostics[0].Range, expRange; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package template
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PolicyDeny forbids running the matching commands
+	PolicyDeny = "deny"
+	// PolicyConfirm requires to type the action and entity of the matching commands to run them
+	PolicyConfirm = "confirm"
+	// PolicyApprove requires an approval token to run the matching commands
+	PolicyApprove = "approve"
+)
+
+// Policy gates the commands run given their action, entity, profile and region
+type Policy struct {
+	Rules []*PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule applies its effect on the commands it matches. Actions, entities, profiles
+// and regions are shell patterns (ex: delete, prod-*), an empty list matching all.
+// ApprovalTokens are the SHA256 hex digests of the tokens approving the commands
+type PolicyRule struct {
+	Actions        []string `yaml:"actions"`
+	Entities       []string `yaml:"entities"`
+	Profiles       []string `yaml:"profiles"`
+	Regions        []string `yaml:"regions"`
+	Effect         string   `yaml:"effect"`
+	Message        string   `yaml:"message"`
+	ApprovalTokens []string `yaml:"approval_tokens_sha256"`
+}
+
+// PolicyMatch is a command of a template matched by a rule
+type PolicyMatch struct {
+	Rule           *PolicyRule
+	Action, Entity string
+}
+
+func (m *PolicyMatch) String() string {
+	var msg string
+	if m.Rule.Message != "" {
+		msg = ": " + m.Rule.Message
+	}
+	return fmt.Sprintf("%s %s (%s)%s", m.Action, m.Entity, m.Rule.Effect, msg)
+}
+
+func ParsePolicy(content []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %s", err)
+	}
+	for i, rule := range policy.Rules {
+		switch rule.Effect {
+		case PolicyDeny, PolicyConfirm:
+		case PolicyApprove:
+			if len(rule.ApprovalTokens) == 0 {
+				return nil, fmt.Errorf("invalid policy: rule %d: expecting approval_tokens_sha256 with effect '%s'", i+1, rule.Effect)
+			}
+		default:
+			return nil, fmt.Errorf("invalid policy: rule %d: invalid effect '%s', expecting %s, %s or %s", i+1, rule.Effect, PolicyDeny, PolicyConfirm, PolicyApprove)
+		}
+		for _, patterns := range [][]string{rule.Actions, rule.Entities, rule.Profiles, rule.Regions} {
+			for _, p := range patterns {
+				if _, err := path.Match(p, ""); err != nil {
+					return nil, fmt.Errorf("invalid policy: rule %d: invalid pattern '%s'", i+1, p)
+				}
+			}
+		}
+	}
+	return policy, nil
+}
+
+// Match returns, in the template order, the commands of the template matched
+// by the rules applying to the profile and region. A command matched by
+// several rules is returned for each of them
+func (p *Policy) Match(profile, region string, tpl *Template) (matches []*PolicyMatch) {
+	for _, cmd := range tpl.CommandNodesIterator() {
+		for _, rule := range p.Rules {
+			if matchAny(rule.Profiles, profile) && matchAny(rule.Regions, region) &&
+				matchAny(rule.Actions, cmd.Action) && matchAny(rule.Entities, cmd.Entity) {
+				matches = append(matches, &PolicyMatch{Rule: rule, Action: cmd.Action, Entity: cmd.Entity})
+			}
+		}
+	}
+	return
+}
+
+// IsApprovedBy returns true if the token is one of the approval tokens of the rule
+func (r *PolicyRule) IsApprovedBy(token string) bool {
+	if token == "" {
+		return false
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+	for _, t := range r.ApprovalTokens {
+		if strings.EqualFold(strings.TrimSpace(t), digest) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
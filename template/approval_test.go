@@ -0,0 +1,76 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+rules:
+  - actions: [delete]
+    entities: [database, bucket]
+    profiles: [prod-*]
+    effect: deny
+    message: production data
+  - actions: [update]
+    entities: [policy]
+    effect: approve
+    approval_tokens_sha256: [2BB80D537B1DA3E38BD30361AA855686BDE0EACD7162FEF6A25FE97BF527A25B]
+  - actions: [delete, stop]
+    regions: [eu-*]
+    effect: confirm
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := MustParse("delete database id=db-1\nupdate policy arn=arn:aws:iam::policy/admin\ndelete instance ids=i-1\ncreate instance name=web")
+	tcases := []struct {
+		profile, region string
+		exp             []string
+	}{
+		{profile: "prod-eu", region: "eu-west-1", exp: []string{"delete database (deny): production data", "delete database (confirm)", "update policy (approve)", "delete instance (confirm)"}},
+		{profile: "dev", region: "eu-west-1", exp: []string{"delete database (confirm)", "update policy (approve)", "delete instance (confirm)"}},
+		{profile: "dev", region: "us-east-1", exp: []string{"update policy (approve)"}},
+	}
+	for i, tcase := range tcases {
+		var got []string
+		for _, m := range policy.Match(tcase.profile, tcase.region, tpl) {
+			got = append(got, m.String())
+		}
+		if want := tcase.exp; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %q, want %q", i+1, got, want)
+		}
+	}
+
+	approve := policy.Rules[1]
+	if !approve.IsApprovedBy("secret") {
+		t.Fatal("expecting approval")
+	}
+	if approve.IsApprovedBy("") || approve.IsApprovedBy("other") {
+		t.Fatal("not expecting approval")
+	}
+}
+
+func TestParseInvalidPolicy(t *testing.T) {
+	tcases := []struct {
+		content string
+		expErr  string
+	}{
+		{content: "rules:\n  - actions: [delete]\n    effect: forbid", expErr: "rule 1: invalid effect 'forbid'"},
+		{content: "rules:\n  - actions: [delete]\n    effect: approve", expErr: "rule 1: expecting approval_tokens_sha256"},
+		{content: "rules:\n  - actions: [delete]\n    effect: deny\n  - entities: ['[']\n    effect: deny", expErr: "rule 2: invalid pattern '['"},
+		{content: "rules:\n  - action: delete\n    effect: deny", expErr: "field action not found"},
+	}
+	for i, tcase := range tcases {
+		_, err := ParsePolicy([]byte(tcase.content))
+		if err == nil {
+			t.Fatalf("%d: expected error got none", i+1)
+		}
+		if !strings.Contains(err.Error(), tcase.expErr) {
+			t.Fatalf("%d: got %s, want %s", i+1, err, tcase.expErr)
+		}
+	}
+}
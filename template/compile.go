@@ -3,6 +3,8 @@ package template
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -15,32 +17,58 @@ type Mode []compileFunc
 
 var (
 	TestCompileMode = []compileFunc{
+		resolveIncludesPass,
 		injectCommandsInNodesPass,
+		extractMetaParamsPass,
 		failOnDeclarationWithNoResultPass,
 		processAndValidateParamsPass,
 		checkInvalidReferenceDeclarationsPass,
+		resolveConditionsPass,
 		resolveHolesPass,
 		resolveMissingHolesPass,
 		removeOptionalHolesPass,
 		resolveAliasPass,
 		inlineVariableValuePass,
+		resolveFunctionsPass,
 	}
 
 	NewRunnerCompileMode = []compileFunc{
+		resolveIncludesPass,
 		injectCommandsInNodesPass,
+		extractMetaParamsPass,
 		failOnDeclarationWithNoResultPass,
 		processAndValidateParamsPass,
 		checkInvalidReferenceDeclarationsPass,
+		resolveConditionsPass,
 		resolveHolesPass,
 		resolveMissingHolesPass,
 		removeOptionalHolesPass,
 		resolveAliasPass,
 		inlineVariableValuePass,
+		resolveFunctionsPass,
 		failOnUnresolvedHolesPass,
 		failOnUnresolvedAliasPass,
 		convertParamsPass,
 		validateCommandsPass,
 	}
+
+	// LintCompileMode checks a template with includes already resolved, without
+	// prompting for missing holes nor failing on holes or alias left unresolved
+	LintCompileMode = []compileFunc{
+		injectCommandsInNodesPass,
+		extractMetaParamsPass,
+		failOnDeclarationWithNoResultPass,
+		processAndValidateParamsPass,
+		checkInvalidReferenceDeclarationsPass,
+		resolveConditionsPass,
+		resolveHolesPass,
+		removeOptionalHolesPass,
+		resolveAliasPass,
+		inlineVariableValuePass,
+		resolveFunctionsPass,
+		convertParamsPass,
+		validateCommandsPass,
+	}
 )
 
 func Compile(tpl *Template, cenv env.Compiling, mode ...Mode) (*Template, env.Compiling, error) {
@@ -78,6 +106,114 @@ func (p *multiPass) compile(tpl *Template, cenv env.Compiling) (newTpl *Template
 	return
 }
 
+func resolveIncludesPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
+	statements, err := resolveIncludes(tpl.Statements, cenv, []string{cenv.TemplatePath()})
+	if err != nil {
+		return tpl, cenv, err
+	}
+	tpl.Statements = statements
+	return tpl, cenv, nil
+}
+
+func resolveIncludes(statements []*ast.Statement, cenv env.Compiling, includers []string) (resolved []*ast.Statement, err error) {
+	for _, st := range statements {
+		switch n := st.Node.(type) {
+		case *ast.IncludeNode:
+			included, err := includeTemplate(n, cenv, includers)
+			if err != nil {
+				return resolved, err
+			}
+			// included statements are located at the include statement in the includer source
+			visitStatements(included, func(inc *ast.Statement) { inc.Pos = st.Pos })
+			resolved = append(resolved, included...)
+			continue
+		case *ast.ForNode:
+			if n.Statements, err = resolveIncludes(n.Statements, cenv, includers); err != nil {
+				return resolved, err
+			}
+		case *ast.IfNode:
+			if n.Statements, err = resolveIncludes(n.Statements, cenv, includers); err != nil {
+				return resolved, err
+			}
+		}
+		resolved = append(resolved, st)
+	}
+	return resolved, nil
+}
+
+func includeTemplate(n *ast.IncludeNode, cenv env.Compiling, includers []string) ([]*ast.Statement, error) {
+	if cenv.IncludeFunc() == nil {
+		return nil, fmt.Errorf("include %s: no template loader defined", n.Path)
+	}
+	path := resolveIncludePath(includers[len(includers)-1], n.Path)
+	content, expanded, err := cenv.IncludeFunc()(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %s", n.Path, err)
+	}
+	for _, includer := range includers {
+		if includer == expanded {
+			return nil, fmt.Errorf("include %s: cycle detected: %s -> %s", n.Path, strings.Join(includers, " -> "), expanded)
+		}
+	}
+	cenv.Log().ExtraVerbosef("include: loaded template '%s'", expanded)
+
+	child, err := Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %s", n.Path, err)
+	}
+	if isRemoteTemplate(expanded) && !cenv.LocalFunctionsAllowed() {
+		for _, expr := range child.expressionNodesIterator() {
+			withFuncs, ok := expr.(ast.WithFunctions)
+			if !ok {
+				continue
+			}
+			for _, name := range withFuncs.GetFunctions() {
+				if localFunctions[name] {
+					return nil, fmt.Errorf("include %s: %s: disabled in remote templates (allow it with --allow-local-functions)", n.Path, name)
+				}
+			}
+		}
+	}
+	statements, err := resolveIncludes(child.Statements, cenv, append(append([]string{}, includers...), expanded))
+	if err != nil {
+		return nil, err
+	}
+	child.Statements = statements
+
+	for key, value := range n.Params {
+		for _, expr := range child.expressionNodesIterator() {
+			if withHoles, ok := expr.(ast.WithHoles); ok {
+				withHoles.ReplaceHole(key, value)
+			}
+		}
+	}
+	return child.Statements, nil
+}
+
+// resolveIncludePath resolves an included path relatively to the path or URL of its includer
+func resolveIncludePath(includer, path string) string {
+	switch {
+	case includer == "", filepath.IsAbs(path), strings.HasPrefix(path, "repo:"), isHTTP(path):
+		return path
+	case isHTTP(includer):
+		base, err := url.Parse(includer)
+		if err != nil {
+			return path
+		}
+		ref, err := url.Parse(path)
+		if err != nil {
+			return path
+		}
+		return base.ResolveReference(ref).String()
+	default:
+		return filepath.Join(filepath.Dir(includer), path)
+	}
+}
+
+func isHTTP(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
 func injectCommandsInNodesPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	if cenv.LookupCommandFunc() == nil {
 		return tpl, cenv, fmt.Errorf("command lookuper is undefined")
@@ -97,6 +233,36 @@ func injectCommandsInNodesPass(tpl *Template, cenv env.Compiling) (*Template, en
 	return tpl, cenv, nil
 }
 
+// extractMetaParamsPass moves the run policy parameters (retry, backoff, timeout)
+// out of the command params, unless the command defines such a param itself
+func extractMetaParamsPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
+	extract := func(node *ast.CommandNode) error {
+		required, optionals, _ := params.List(node.ParamsSpec().Rule())
+		for _, key := range metaParams {
+			value, ok := node.Params[key]
+			if !ok || contains(required, key) || contains(optionals, key) {
+				continue
+			}
+			if node.Meta == nil {
+				node.Meta = make(map[string]ast.CompositeValue)
+			}
+			node.Meta[key] = value
+			delete(node.Params, key)
+		}
+		policy, err := newRunPolicy(node.Meta)
+		if err != nil {
+			return cmdErr(node, err)
+		}
+		if node.Action == "create" && policy.retry > 0 && policy.timeout > 0 {
+			return cmdErr(node, errors.New("timeout: cannot be combined with retry on a creation, the timed out creation keeping running, a retry could create a duplicate"))
+		}
+		return nil
+	}
+
+	err := tpl.visitCommandNodesE(extract)
+	return tpl, cenv, err
+}
+
 func failOnDeclarationWithNoResultPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	failOnDeclarationWithNoResult := func(node *ast.DeclarationNode) error {
 		cmdNode, ok := node.Expr.(*ast.CommandNode)
@@ -130,6 +296,14 @@ func processAndValidateParamsPass(tpl *Template, cenv env.Compiling) (*Template,
 			node.Params[e] = ast.NewHoleValue(normalized)
 		}
 		if err := params.Run(rule, node.Keys()); err != nil {
+			required, optionals, _ := params.List(rule)
+			keys := node.Keys()
+			sort.Strings(keys)
+			for _, key := range keys {
+				if !contains(required, key) && !contains(optionals, key) {
+					return paramErr(node, key, err)
+				}
+			}
 			return cmdErr(node, err)
 		}
 
@@ -189,7 +363,18 @@ func convertParamsPass(tpl *Template, cenv env.Compiling) (*Template, env.Compil
 
 func validateCommandsPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	collectValidationErrs := func(node *ast.CommandNode) error {
-		if err := params.Validate(node.ParamsSpec().Validators(), node.ToDriverParamsExcludingRefs()); err != nil {
+		validators, values := node.ParamsSpec().Validators(), node.ToDriverParamsExcludingRefs()
+		if err := params.Validate(validators, values); err != nil {
+			var keys []string
+			for key := range validators {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if params.Validate(params.Validators{key: validators[key]}, values) != nil {
+					return paramErr(node, key, err)
+				}
+			}
 			return cmdErr(node, err)
 		}
 		return nil
@@ -207,42 +392,84 @@ func checkInvalidReferenceDeclarationsPass(tpl *Template, cenv env.Compiling) (*
 		}
 	}
 
-	knownRefs := make(map[string]bool)
-
-	var each = func(withRef ast.WithRefs) error {
+	var each = func(st *ast.Statement, withRef ast.WithRefs, knownRefs map[string]bool) error {
 		for _, ref := range withRef.GetRefs() {
 			if _, ok := knownRefs[ref]; !ok {
-				return fmt.Errorf("using reference '$%s' but '%s' is undefined in template\n", ref, ref)
+				return statementErr(st, "using reference '$%s' but '%s' is undefined in template\n", ref, ref)
 			}
 		}
 		return nil
 	}
 
-	for _, st := range tpl.Statements {
-		switch n := st.Node.(type) {
-		case ast.WithRefs:
-			if err := each(n); err != nil {
-				return tpl, cenv, err
-			}
-		case *ast.DeclarationNode:
-			expr := st.Node.(*ast.DeclarationNode).Expr
-			switch nn := expr.(type) {
+	outputs := make(map[string]bool)
+
+	var check func([]*ast.Statement, map[string]bool, bool) error
+	check = func(statements []*ast.Statement, knownRefs map[string]bool, inLoop bool) error {
+		for _, st := range statements {
+			switch n := st.Node.(type) {
+			case *ast.OutputNode:
+				if err := each(st, n.Value, knownRefs); err != nil {
+					return err
+				}
+				if inLoop {
+					return statementErr(st, "output '%s' cannot be declared in a loop\n", n.Ident)
+				}
+				if outputs[n.Ident] {
+					return statementErr(st, "output '%s' has already been declared in template\n", n.Ident)
+				}
+				outputs[n.Ident] = true
+			case *ast.ForNode:
+				if err := each(st, n.Items, knownRefs); err != nil {
+					return err
+				}
+				if _, ok := knownRefs[n.Ident]; ok {
+					return statementErr(st, "using reference '$%s' as loop variable but '%s' has already been assigned in template\n", n.Ident, n.Ident)
+				}
+				scoped := map[string]bool{n.Ident: true}
+				for k, v := range knownRefs {
+					scoped[k] = v
+				}
+				if err := check(n.Statements, scoped, true); err != nil {
+					return err
+				}
+			case *ast.IfNode:
+				for _, operand := range n.Condition.Operands() {
+					if err := each(st, operand, knownRefs); err != nil {
+						return err
+					}
+				}
+				scoped := make(map[string]bool)
+				for k, v := range knownRefs {
+					scoped[k] = v
+				}
+				if err := check(n.Statements, scoped, inLoop); err != nil {
+					return err
+				}
 			case ast.WithRefs:
-				if err := each(nn); err != nil {
-					return tpl, cenv, err
+				if err := each(st, n, knownRefs); err != nil {
+					return err
+				}
+			case *ast.DeclarationNode:
+				expr := st.Node.(*ast.DeclarationNode).Expr
+				switch nn := expr.(type) {
+				case ast.WithRefs:
+					if err := each(st, nn, knownRefs); err != nil {
+						return err
+					}
 				}
 			}
-		}
-		if decl, isDecl := st.Node.(*ast.DeclarationNode); isDecl {
-			ref := decl.Ident
-			if _, ok := knownRefs[ref]; ok {
-				return tpl, cenv, fmt.Errorf("using reference '$%s' but '%s' has already been assigned in template\n", ref, ref)
+			if decl, isDecl := st.Node.(*ast.DeclarationNode); isDecl {
+				ref := decl.Ident
+				if _, ok := knownRefs[ref]; ok {
+					return statementErr(st, "using reference '$%s' but '%s' has already been assigned in template\n", ref, ref)
+				}
+				knownRefs[ref] = true
 			}
-			knownRefs[ref] = true
 		}
+		return nil
 	}
 
-	return tpl, cenv, nil
+	return tpl, cenv, check(tpl.Statements, make(map[string]bool), false)
 }
 
 func inlineVariableValuePass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
@@ -258,8 +485,7 @@ func inlineVariableValuePass(tpl *Template, cenv env.Compiling) (*Template, env.
 					cenv.Push(env.RESOLVED_VARS, map[string]interface{}{decl.Ident: val})
 				}
 				for j := i + 1; j < len(tpl.Statements); j++ {
-					expr := extractExpressionNode(tpl.Statements[j])
-					if expr != nil {
+					for _, expr := range extractExpressionNodes(tpl.Statements[j]) {
 						if withRef, ok := expr.(ast.WithRefs); ok {
 							withRef.ReplaceRef(decl.Ident, value.Value)
 						}
@@ -276,6 +502,19 @@ func inlineVariableValuePass(tpl *Template, cenv env.Compiling) (*Template, env.
 }
 
 func resolveHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
+	fillers, declared := cenv.Get(env.FILLERS), tpl.paramDeclarations()
+	defaults := make(map[string]interface{})
+	for k, v := range cenv.Get(env.DEFAULTS) {
+		if _, filled := fillers[k]; filled {
+			continue
+		}
+		if st, ok := declared[k]; ok && st.Node.(*ast.ParamNode).Default != nil {
+			continue
+		}
+		defaults[k] = v
+	}
+	cenv.Push(env.FILLERS, defaults)
+
 	tpl.visitHoles(func(h ast.WithHoles) {
 		processed := h.ProcessHoles(cenv.Get(env.FILLERS))
 		cenv.Push(env.PROCESSED_FILLERS, processed)
@@ -316,10 +555,19 @@ func resolveMissingHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.
 		}
 	})
 
+	declared := tpl.paramDeclarations()
 	for _, hole := range sortedHoles {
 		k := hole.Name
+		var decl *ast.ParamNode
+		if st, ok := declared[k]; ok {
+			decl = st.Node.(*ast.ParamNode)
+		}
 		if cenv.MissingHolesFunc() != nil {
-			actual := cenv.MissingHolesFunc()(k, uniqueHoles[k].ParamPaths, uniqueHoles[k].IsOptional)
+			actual := cenv.MissingHolesFunc()(k, uniqueHoles[k].ParamPaths, uniqueHoles[k].IsOptional, toEnvParam(decl))
+			if actual == "" && decl != nil && decl.Default != nil {
+				cenv.Push(env.FILLERS, map[string]interface{}{k: decl.DefaultValue()})
+				continue
+			}
 			if actual == "" && uniqueHoles[k].IsOptional {
 				continue
 			}
@@ -330,6 +578,22 @@ func resolveMissingHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.
 				}
 			}
 			cenv.Push(env.FILLERS, map[string]interface{}{k: params[k]})
+		} else if decl != nil && decl.Default != nil {
+			cenv.Push(env.FILLERS, map[string]interface{}{k: decl.DefaultValue()})
+		}
+	}
+
+	var names []string
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fillers := cenv.Get(env.FILLERS)
+	for _, name := range names {
+		if v, ok := fillers[name]; ok {
+			if err := declared[name].Node.(*ast.ParamNode).Validate(v); err != nil {
+				return tpl, cenv, statementErr(declared[name], "%s", err)
+			}
 		}
 	}
 
@@ -341,6 +605,13 @@ func resolveMissingHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.
 	return tpl, cenv, nil
 }
 
+func toEnvParam(n *ast.ParamNode) *env.Param {
+	if n == nil {
+		return nil
+	}
+	return &env.Param{Name: n.Name, Type: n.Type, Description: n.Description, Default: n.DefaultValue(), Enum: n.EnumValues()}
+}
+
 func removeOptionalHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	removeOptionalHoles := func(node *ast.CommandNode) error {
 		for key, param := range node.Params {
@@ -366,6 +637,30 @@ func removeOptionalHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.
 	return tpl, cenv, err
 }
 
+// resolveConditionsPass resolves the existence tests, before any other resolution
+// so that the blocks known not to run are left out of the following passes
+func resolveConditionsPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
+	if cenv.ExistsFunc() == nil {
+		return tpl, cenv, nil
+	}
+	lookup := func(entity, alias string) (bool, error) {
+		found, err := cenv.ExistsFunc()(entity, alias)
+		cenv.Log().ExtraVerbosef("condition: '%s' found: %t", alias, found)
+		return found, err
+	}
+
+	var err error
+	visitStatements(tpl.Statements, func(st *ast.Statement) {
+		if n, ok := st.Node.(*ast.IfNode); ok && err == nil {
+			if rerr := n.Condition.ResolveExists(lookup); rerr != nil {
+				err = statementErr(st, "condition '%s': %s", n.Condition, rerr)
+			}
+		}
+	})
+
+	return tpl, cenv, err
+}
+
 func resolveAliasPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	var emptyResolv []string
 	resolvAliasFunc := func(action, entity string, key string) func(string) (string, bool) {
@@ -413,27 +708,69 @@ func resolveAliasPass(tpl *Template, cenv env.Compiling) (*Template, env.Compili
 	return tpl, cenv, nil
 }
 
+// resolveFunctionsPass evaluates the builtin functions whose arguments are resolved,
+// functions depending on references being evaluated when running the template
+func resolveFunctionsPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
+	eval := newFunctionsEvaluator(cenv)
+	for _, expr := range tpl.expressionNodesIterator() {
+		withFuncs, ok := expr.(ast.WithFunctions)
+		if !ok {
+			continue
+		}
+		var cmd *ast.CommandNode
+		if n, isCmd := expr.(*ast.CommandNode); isCmd {
+			cmd = n
+		}
+		for _, name := range withFuncs.GetFunctions() {
+			if _, ok := builtinFunctions[name]; !ok {
+				return tpl, cenv, cmdErr(cmd, "unknown function '%s'", name)
+			}
+		}
+		if err := withFuncs.ResolveFunctions(eval); err != nil {
+			return tpl, cenv, cmdErr(cmd, err)
+		}
+	}
+	return tpl, cenv, nil
+}
+
 func failOnUnresolvedHolesPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
-	uniqueUnresolved := make(map[string]struct{})
+	paramPaths := make(map[string][]string)
 	tpl.visitHoles(func(withHole ast.WithHoles) {
-		for hole := range withHole.GetHoles() {
-			uniqueUnresolved[hole] = struct{}{}
+		for name, hole := range withHole.GetHoles() {
+			paths := paramPaths[name]
+			for _, p := range hole.ParamPaths {
+				if !contains(paths, p) {
+					paths = append(paths, p)
+				}
+			}
+			paramPaths[name] = paths
 		}
 	})
 
 	var unresolved []string
-	for k := range uniqueUnresolved {
+	for k := range paramPaths {
 		unresolved = append(unresolved, k)
 	}
 
 	if len(unresolved) > 0 {
 		sort.Strings(unresolved)
-		return tpl, cenv, fmt.Errorf("template contains unresolved holes: %v", unresolved)
+		return tpl, cenv, &UnresolvedHolesError{Holes: unresolved, ParamPaths: paramPaths}
 	}
 
 	return tpl, cenv, nil
 }
 
+// UnresolvedHolesError lists all the holes left without value once compiled,
+// with the param paths each of them fills
+type UnresolvedHolesError struct {
+	Holes      []string
+	ParamPaths map[string][]string
+}
+
+func (e *UnresolvedHolesError) Error() string {
+	return fmt.Sprintf("template contains unresolved holes: %v", e.Holes)
+}
+
 func failOnUnresolvedAliasPass(tpl *Template, cenv env.Compiling) (*Template, env.Compiling, error) {
 	var unresolved []string
 
@@ -466,10 +803,12 @@ func failOnUnresolvedAliasPass(tpl *Template, cenv env.Compiling) (*Template, en
 }
 
 func cmdErr(cmd *ast.CommandNode, i interface{}, a ...interface{}) error {
-	var prefix string
-	if cmd != nil {
-		prefix = fmt.Sprintf("%s %s: ", cmd.Action, cmd.Entity)
-	}
+	return paramErr(cmd, "", i, a...)
+}
+
+// paramErr is a command error located at the given param
+// in the template source, or at the command if the param is not located
+func paramErr(cmd *ast.CommandNode, key string, i interface{}, a ...interface{}) error {
 	var msg string
 	switch ii := i.(type) {
 	case nil:
@@ -479,10 +818,13 @@ func cmdErr(cmd *ast.CommandNode, i interface{}, a ...interface{}) error {
 	case error:
 		msg = ii.Error()
 	}
-	if len(a) == 0 {
-		return errors.New(prefix + msg)
+	if len(a) > 0 {
+		msg = fmt.Sprintf(msg, a...)
+	}
+	if cmd == nil {
+		return errors.New(msg)
 	}
-	return fmt.Errorf("%s"+msg, append([]interface{}{prefix}, a...)...)
+	return locatedErr(cmd.PosOf(key), fmt.Sprintf("%s %s: %s", cmd.Action, cmd.Entity, msg))
 }
 
 func contains(arr []string, s string) bool {
@@ -510,3 +852,32 @@ func excludeFromSlice(in []string, exclude []string) (out []string) {
 	}
 	return out
 }
+
+func statementErr(st *ast.Statement, format string, a ...interface{}) error {
+	return locatedErr(st.Pos, fmt.Sprintf(format, a...))
+}
+
+// Error is an error located in the template source
+type Error struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func locatedErr(pos ast.Position, msg string) error {
+	if !pos.IsValid() {
+		return errors.New(msg)
+	}
+	return &Error{Line: pos.Line, Column: pos.Column, Msg: msg}
+}
+
+// locate prefixes a message with a position in the template source, if known
+func locate(pos ast.Position, msg string) string {
+	if !pos.IsValid() {
+		return msg
+	}
+	return fmt.Sprintf("line %d, column %d: %s", pos.Line, pos.Column, msg)
+}
@@ -13,14 +13,20 @@ var (
 )
 
 type runEnv struct {
-	log    *logger.Logger
-	dryRun bool
-	ctx    map[string]interface{}
+	log                   *logger.Logger
+	dryRun                bool
+	workers               int
+	continueOnError       bool
+	templatePath          string
+	localFunctionsAllowed bool
+	ctx                   map[string]interface{}
 }
 
 func NewRunEnv(cenv env.Compiling, context ...map[string]interface{}) env.Running {
 	renv := new(runEnv)
 	renv.log = cenv.Log()
+	renv.templatePath = cenv.TemplatePath()
+	renv.localFunctionsAllowed = cenv.LocalFunctionsAllowed()
 	renv.ctx = make(map[string]interface{})
 	for _, m := range context {
 		for k, v := range m {
@@ -41,6 +47,30 @@ func (e *runEnv) SetDryRun(b bool) {
 	e.dryRun = b
 }
 
+func (e *runEnv) Workers() int {
+	return e.workers
+}
+
+func (e *runEnv) SetWorkers(n int) {
+	e.workers = n
+}
+
+func (e *runEnv) ContinueOnError() bool {
+	return e.continueOnError
+}
+
+func (e *runEnv) SetContinueOnError(b bool) {
+	e.continueOnError = b
+}
+
+func (e *runEnv) TemplatePath() string {
+	return e.templatePath
+}
+
+func (e *runEnv) LocalFunctionsAllowed() bool {
+	return e.localFunctionsAllowed
+}
+
 func (e *runEnv) Context() (out map[string]interface{}) {
 	out = make(map[string]interface{})
 	for k, v := range e.ctx {
@@ -55,11 +85,15 @@ func (e *runEnv) Log() *logger.Logger {
 
 type compileEnv struct {
 	*dataMap
-	lookupCommandFunc func(...string) interface{}
-	aliasFunc         func(paramPath, alias string) string
-	missingHolesFunc  func(string, []string, bool) string
-	log               *logger.Logger
-	paramsSuggested   int
+	lookupCommandFunc     func(...string) interface{}
+	aliasFunc             func(paramPath, alias string) string
+	existsFunc            func(entity, name string) (bool, error)
+	missingHolesFunc      func(string, []string, bool, *env.Param) string
+	includeFunc           func(string) ([]byte, string, error)
+	templatePath          string
+	localFunctionsAllowed bool
+	log                   *logger.Logger
+	paramsSuggested       int
 }
 
 func (e *compileEnv) LookupCommandFunc() func(...string) interface{} {
@@ -70,10 +104,26 @@ func (e *compileEnv) AliasFunc() func(paramPath, alias string) string {
 	return e.aliasFunc
 }
 
-func (e *compileEnv) MissingHolesFunc() func(string, []string, bool) string {
+func (e *compileEnv) ExistsFunc() func(entity, name string) (bool, error) {
+	return e.existsFunc
+}
+
+func (e *compileEnv) MissingHolesFunc() func(string, []string, bool, *env.Param) string {
 	return e.missingHolesFunc
 }
 
+func (e *compileEnv) IncludeFunc() func(string) ([]byte, string, error) {
+	return e.includeFunc
+}
+
+func (e *compileEnv) TemplatePath() string {
+	return e.templatePath
+}
+
+func (e *compileEnv) LocalFunctionsAllowed() bool {
+	return e.localFunctionsAllowed
+}
+
 func (e *compileEnv) ParamsMode() int {
 	return e.paramsSuggested
 }
@@ -133,11 +183,31 @@ func (b *envBuilder) WithAliasFunc(fn func(paramPath, alias string) string) *env
 	return b
 }
 
-func (b *envBuilder) WithMissingHolesFunc(fn func(string, []string, bool) string) *envBuilder {
+func (b *envBuilder) WithExistsFunc(fn func(entity, name string) (bool, error)) *envBuilder {
+	b.E.existsFunc = fn
+	return b
+}
+
+func (b *envBuilder) WithMissingHolesFunc(fn func(string, []string, bool, *env.Param) string) *envBuilder {
 	b.E.missingHolesFunc = fn
 	return b
 }
 
+func (b *envBuilder) WithIncludeFunc(fn func(string) ([]byte, string, error)) *envBuilder {
+	b.E.includeFunc = fn
+	return b
+}
+
+func (b *envBuilder) WithTemplatePath(path string) *envBuilder {
+	b.E.templatePath = path
+	return b
+}
+
+func (b *envBuilder) WithLocalFunctionsAllowed(allowed bool) *envBuilder {
+	b.E.localFunctionsAllowed = allowed
+	return b
+}
+
 func (b *envBuilder) WithLookupCommandFunc(fn func(...string) interface{}) *envBuilder {
 	b.E.lookupCommandFunc = fn
 	return b
@@ -8,6 +8,9 @@ const (
 	FILLERS = iota
 	PROCESSED_FILLERS
 	RESOLVED_VARS
+	// DEFAULTS fill the holes not filled by FILLERS, except those of the
+	// params declared with a default value in the template
+	DEFAULTS
 )
 
 const (
@@ -25,14 +28,34 @@ type Running interface {
 	Context() map[string]interface{}
 	IsDryRun() bool
 	SetDryRun(b bool)
+	Workers() int
+	SetWorkers(n int)
+	ContinueOnError() bool
+	SetContinueOnError(b bool)
+	TemplatePath() string
+	LocalFunctionsAllowed() bool
 }
 
 type Compiling interface {
 	log
 	LookupCommandFunc() func(...string) interface{}
 	AliasFunc() func(paramPath, alias string) string
-	MissingHolesFunc() func(string, []string, bool) string
+	ExistsFunc() func(entity, name string) (bool, error)
+	MissingHolesFunc() func(string, []string, bool, *Param) string
+	IncludeFunc() func(string) ([]byte, string, error)
+	TemplatePath() string
+	// LocalFunctionsAllowed is true if the functions reading the local
+	// environment or files are allowed in remote templates
+	LocalFunctionsAllowed() bool
 	ParamsMode() int
 	Push(int, ...map[string]interface{})
 	Get(int) map[string]interface{}
 }
+
+// Param is the declaration in a template header of the type, default value,
+// allowed values and description of the holes of a given name
+type Param struct {
+	Name, Type, Description string
+	Default                 interface{}
+	Enum                    []string
+}
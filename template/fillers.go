@@ -0,0 +1,93 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ParseFillers reads the values of holes from a YAML or JSON document. Nested
+// maps are flattened with dotted keys, ex: {instance: {type: t2.micro}} fills {instance.type}
+func ParseFillers(content []byte) (map[string]interface{}, error) {
+	var doc map[interface{}]interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	fillers := make(map[string]interface{})
+	if err := flattenFillers("", doc, fillers); err != nil {
+		return nil, err
+	}
+	return fillers, nil
+}
+
+func flattenFillers(prefix string, doc map[interface{}]interface{}, fillers map[string]interface{}) error {
+	for k, v := range doc {
+		key := fmt.Sprint(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch vv := v.(type) {
+		case map[interface{}]interface{}:
+			if err := flattenFillers(key, vv, fillers); err != nil {
+				return err
+			}
+		case []interface{}:
+			var list []interface{}
+			for _, e := range vv {
+				val, err := fillerValue(key, e)
+				if err != nil {
+					return err
+				}
+				list = append(list, val)
+			}
+			fillers[key] = list
+		default:
+			val, err := fillerValue(key, vv)
+			if err != nil {
+				return err
+			}
+			fillers[key] = val
+		}
+	}
+	return nil
+}
+
+func fillerValue(key string, v interface{}) (interface{}, error) {
+	switch vv := v.(type) {
+	case string, int, float64:
+		return vv, nil
+	case bool:
+		return fmt.Sprint(vv), nil
+	case nil:
+		return nil, fmt.Errorf("%s: missing value", key)
+	default:
+		return nil, fmt.Errorf("%s: unexpected value of type %T", key, v)
+	}
+}
+
+// EnvFillers reads the values of holes from the environment variables, given as
+// KEY=value, starting with prefix. The rest of the variable name is lowercased with
+// '_' read as '.', ex: with prefix AWLESS_, AWLESS_INSTANCE_TYPE fills {instance.type}.
+// Values are typed as if given on the command line
+func EnvFillers(prefix string, environ []string) (map[string]interface{}, error) {
+	fillers := make(map[string]interface{})
+	if prefix == "" {
+		return fillers, nil
+	}
+	for _, kv := range environ {
+		splits := strings.SplitN(kv, "=", 2)
+		if len(splits) != 2 || !strings.HasPrefix(splits[0], prefix) || len(splits[0]) == len(prefix) {
+			continue
+		}
+		key := strings.Replace(strings.ToLower(strings.TrimPrefix(splits[0], prefix)), "_", ".", -1)
+		params, err := ParseParams(fmt.Sprintf("%s=%s", key, splits[1]))
+		if err != nil {
+			if params, err = ParseParams(fmt.Sprintf("%s=%s", key, quoteString(splits[1]))); err != nil {
+				return fillers, fmt.Errorf("%s: %s", splits[0], err)
+			}
+		}
+		fillers[key] = params[key]
+	}
+	return fillers, nil
+}
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFillers(t *testing.T) {
+	tcases := []struct {
+		content string
+		expect  map[string]interface{}
+		expErr  string
+	}{
+		{
+			content: "instance:\n  type: t2.micro\n  count: 2\n  lock: true\nvpc.cidr: 10.0.0.0/16\nsubnets: [10.0.1.0/24, 10.0.2.0/24]\n",
+			expect:  map[string]interface{}{"instance.type": "t2.micro", "instance.count": 2, "instance.lock": "true", "vpc.cidr": "10.0.0.0/16", "subnets": []interface{}{"10.0.1.0/24", "10.0.2.0/24"}},
+		},
+		{
+			content: `{"instance": {"type": "t2.micro"}, "keypair.name": "my-key"}`,
+			expect:  map[string]interface{}{"instance.type": "t2.micro", "keypair.name": "my-key"},
+		},
+		{content: "instance.type:\n", expErr: "instance.type: missing value"},
+		{content: "instance: [type", expErr: "yaml"},
+	}
+	for i, tcase := range tcases {
+		fillers, err := ParseFillers([]byte(tcase.content))
+		if tcase.expErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tcase.expErr) {
+				t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := fillers, tcase.expect; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %#v, want %#v", i+1, got, want)
+		}
+	}
+}
+
+func TestEnvFillers(t *testing.T) {
+	environ := []string{"HOME=/home/john", "AWLESS_INSTANCE_TYPE=t2.micro", "AWLESS_INSTANCE_COUNT=3", "AWLESS_KEYPAIR_NAME=my key", "AWLESS_=empty", "AWLESSVPC=none"}
+	fillers, err := EnvFillers("AWLESS_", environ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fillers, map[string]interface{}{"instance.type": "t2.micro", "instance.count": 3, "keypair.name": "my key"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	if fillers, _ := EnvFillers("", environ); len(fillers) != 0 {
+		t.Fatalf("got %#v, want no fillers without prefix", fillers)
+	}
+}
@@ -0,0 +1,179 @@
+package template
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type builtinFunction struct {
+	minArgs, maxArgs int
+	call             func(dir string, args []interface{}) (interface{}, error)
+}
+
+// builtinFunctions are the functions usable in template values, evaluated
+// as soon as their arguments are resolved. The dir given to the call is the
+// directory of the template, used to resolve relative paths
+var builtinFunctions = map[string]builtinFunction{
+	"lower": {1, 1, func(_ string, args []interface{}) (interface{}, error) {
+		return strings.ToLower(fmt.Sprint(args[0])), nil
+	}},
+	"join": {2, 2, func(_ string, args []interface{}) (interface{}, error) {
+		var elems []string
+		switch vv := args[1].(type) {
+		case []interface{}:
+			for _, v := range vv {
+				elems = append(elems, fmt.Sprint(v))
+			}
+		default:
+			elems = append(elems, fmt.Sprint(vv))
+		}
+		return strings.Join(elems, fmt.Sprint(args[0])), nil
+	}},
+	"cidrsubnet": {3, 3, func(_ string, args []interface{}) (interface{}, error) {
+		newbits, err := toInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		netnum, err := toInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return cidrSubnet(fmt.Sprint(args[0]), newbits, netnum)
+	}},
+	"base64": {1, 1, func(_ string, args []interface{}) (interface{}, error) {
+		return base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(args[0]))), nil
+	}},
+	"env": {1, 1, func(_ string, args []interface{}) (interface{}, error) {
+		name := fmt.Sprint(args[0])
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable '%s' is not set", name)
+		}
+		return val, nil
+	}},
+	"file": {1, 1, func(dir string, args []interface{}) (interface{}, error) {
+		path := fmt.Sprint(args[0])
+		if !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return string(content), nil
+	}},
+	"now": {0, 1, func(_ string, args []interface{}) (interface{}, error) {
+		layout := time.RFC3339
+		if len(args) > 0 {
+			layout = fmt.Sprint(args[0])
+		}
+		return time.Now().UTC().Format(layout), nil
+	}},
+}
+
+// functionsEnv gives the path of the template, local, remote (ex: https://..., repo:...)
+// or empty for a command line, and whether local functions are allowed in remote templates
+type functionsEnv interface {
+	TemplatePath() string
+	LocalFunctionsAllowed() bool
+}
+
+// localFunctions read the local environment or files
+var localFunctions = map[string]bool{"env": true, "file": true}
+
+// newFunctionsEvaluator returns the evaluation of builtin functions for the template
+// of the env. Local functions are disabled in remote templates unless allowed by the env
+func newFunctionsEvaluator(fenv functionsEnv) func(string, []interface{}) (interface{}, error) {
+	path := fenv.TemplatePath()
+	remote := isRemoteTemplate(path)
+	var dir string
+	if path != "" && !remote {
+		dir = filepath.Dir(path)
+	}
+	return func(name string, args []interface{}) (interface{}, error) {
+		fn, ok := builtinFunctions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function")
+		}
+		if localFunctions[name] && remote && !fenv.LocalFunctionsAllowed() {
+			return nil, fmt.Errorf("disabled in remote templates (allow it with --allow-local-functions)")
+		}
+		if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+			if fn.minArgs == fn.maxArgs {
+				return nil, fmt.Errorf("expecting %d argument(s), got %d", fn.minArgs, len(args))
+			}
+			return nil, fmt.Errorf("expecting %d to %d argument(s), got %d", fn.minArgs, fn.maxArgs, len(args))
+		}
+		return fn.call(dir, args)
+	}
+}
+
+func isRemoteTemplate(path string) bool {
+	return isHTTP(path) || strings.HasPrefix(path, "repo:")
+}
+
+type noTemplateEnv struct{}
+
+func (noTemplateEnv) TemplatePath() string        { return "" }
+func (noTemplateEnv) LocalFunctionsAllowed() bool { return false }
+
+// evalPureFunction evaluates the builtin functions outside of a template run,
+// failing on the local functions
+func evalPureFunction(name string, args []interface{}) (interface{}, error) {
+	if localFunctions[name] {
+		return nil, fmt.Errorf("local function not evaluated")
+	}
+	return newFunctionsEvaluator(noTemplateEnv{})(name, args)
+}
+
+// cidrSubnet computes the netnum-th subnet of a network prefix extended by newbits,
+// ex: cidrsubnet(10.0.0.0/16, 8, 2) is 10.0.2.0/24
+func cidrSubnet(prefix string, newbits, netnum int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+	ones, bits := network.Mask.Size()
+	if newbits < 0 || ones+newbits > bits {
+		return "", fmt.Errorf("cannot extend prefix of length %d by %d bits", ones, newbits)
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), uint(newbits))
+	if netnum < 0 || big.NewInt(int64(netnum)).Cmp(max) >= 0 {
+		return "", fmt.Errorf("network number %d does not fit in %d bits", netnum, newbits)
+	}
+
+	ip := network.IP
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	num := new(big.Int).SetBytes(ip)
+	num.Or(num, new(big.Int).Lsh(big.NewInt(int64(netnum)), uint(bits-ones-newbits)))
+	raw := num.Bytes()
+	subnet := make(net.IP, len(ip))
+	copy(subnet[len(subnet)-len(raw):], raw)
+
+	return fmt.Sprintf("%s/%d", subnet, ones+newbits), nil
+}
+
+func toInt(v interface{}) (int, error) {
+	switch vv := v.(type) {
+	case int:
+		return vv, nil
+	case string:
+		i, err := strconv.Atoi(vv)
+		if err != nil {
+			return 0, fmt.Errorf("expecting an integer, got '%s'", vv)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("expecting an integer, got '%v'", v)
+	}
+}
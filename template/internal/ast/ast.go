@@ -19,10 +19,12 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/wallix/awless/template/env"
 	"github.com/wallix/awless/template/params"
@@ -31,6 +33,9 @@ import (
 var (
 	_ WithHoles = (*CommandNode)(nil)
 	_ WithHoles = (*ValueNode)(nil)
+
+	_ WithFunctions = (*CommandNode)(nil)
+	_ WithFunctions = (*ValueNode)(nil)
 )
 
 type Node interface {
@@ -43,10 +48,27 @@ type AST struct {
 
 	// state to build the AST
 	stmtBuilder *statementBuilder
+	blocks      []*blockBuilder
+	lineOffsets []int
 }
 
 type Statement struct {
 	Node
+	// Pos is where the statement starts in the template source and End where it ends
+	Pos, End Position
+}
+
+// Position is the line and column, starting at 1, of a statement in the template source
+type Position struct {
+	Line, Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 type DeclarationNode struct {
@@ -54,6 +76,52 @@ type DeclarationNode struct {
 	Expr  ExpressionNode
 }
 
+// ForNode repeats its statements for each value of Items,
+// the current value being referenced in statements as $Ident
+type ForNode struct {
+	Ident      string
+	Items      *ValueNode
+	Statements []*Statement
+}
+
+// IfNode runs its statements only when its condition holds
+// or, for an 'unless' statement, only when it does not hold
+type IfNode struct {
+	Unless     bool
+	Condition  *ConditionNode
+	Statements []*Statement
+}
+
+// IncludeNode is replaced at compilation by the statements
+// of the template found at Path, its holes being filled with Params
+type IncludeNode struct {
+	Path   string
+	Params map[string]CompositeValue
+}
+
+// OutputNode exposes the value of the template run as Ident
+type OutputNode struct {
+	Ident string
+	Value *ValueNode
+}
+
+const (
+	ExistsOperator   = "exists"
+	EqualOperator    = "=="
+	NotEqualOperator = "!="
+)
+
+// ConditionNode either tests the existence of an alias
+// (optionally restricted to an entity) or compares two values
+type ConditionNode struct {
+	Operator    string
+	Entity      string
+	Left, Right *ValueNode
+
+	exists   bool
+	resolved bool
+}
+
 type ExpressionNode interface {
 	Node
 	Result() interface{}
@@ -69,6 +137,8 @@ type Hole struct {
 type WithHoles interface {
 	ProcessHoles(fills map[string]interface{}) (processed map[string]interface{})
 	GetHoles() map[string]*Hole
+	ReplaceHole(string, CompositeValue)
+	IsHole(string) bool
 }
 
 type Command interface {
@@ -78,11 +148,34 @@ type Command interface {
 
 type CommandNode struct {
 	Command
-	CmdResult interface{}
-	CmdErr    error
+	CmdResult  interface{}
+	CmdErr     error
+	CmdSkipped bool
+	// CmdAttempts counts the runs of the command when retried
+	CmdAttempts int
+	// CmdDuration is the time spent running the command, retries included
+	CmdDuration time.Duration
+	// CmdPriorState holds, per param, the values of the resource updated
+	// by the command as they were before it ran, to revert the update
+	CmdPriorState map[string]interface{}
 
 	Action, Entity string
 	Params         map[string]CompositeValue
+	// Meta holds the parameters applied by the runner around the command run
+	Meta map[string]CompositeValue
+
+	// Pos locates the command action and ParamsPos the params keys in the template source
+	Pos       Position
+	ParamsPos map[string]Position
+}
+
+// PosOf returns the position of a param of the command,
+// or of the command if the param position is unknown
+func (c *CommandNode) PosOf(key string) Position {
+	if pos, ok := c.ParamsPos[key]; ok {
+		return pos
+	}
+	return c.Pos
 }
 
 func (c *CommandNode) Result() interface{} { return c.CmdResult }
@@ -101,6 +194,9 @@ func (c *CommandNode) String() string {
 	for k, v := range c.Params {
 		all = append(all, fmt.Sprintf("%s=%s", k, v.String()))
 	}
+	for k, v := range c.Meta {
+		all = append(all, fmt.Sprintf("%s=%s", k, v.String()))
+	}
 
 	sort.Strings(all)
 
@@ -120,11 +216,30 @@ func (c *CommandNode) clone() Node {
 		Command: c.Command,
 		Action:  c.Action, Entity: c.Entity,
 		Params: make(map[string]CompositeValue),
+		Pos:    c.Pos,
+	}
+	if c.CmdPriorState != nil {
+		cmd.CmdPriorState = make(map[string]interface{})
+		for k, v := range c.CmdPriorState {
+			cmd.CmdPriorState[k] = v
+		}
+	}
+	if c.ParamsPos != nil {
+		cmd.ParamsPos = make(map[string]Position)
+		for k, v := range c.ParamsPos {
+			cmd.ParamsPos[k] = v
+		}
 	}
 
 	for k, v := range c.Params {
 		cmd.Params[k] = v.Clone()
 	}
+	if c.Meta != nil {
+		cmd.Meta = make(map[string]CompositeValue)
+		for k, v := range c.Meta {
+			cmd.Meta[k] = v.Clone()
+		}
+	}
 	return cmd
 }
 
@@ -158,6 +273,22 @@ func (c *CommandNode) GetHoles() map[string]*Hole {
 	return holes
 }
 
+func (c *CommandNode) ReplaceHole(key string, value CompositeValue) {
+	for k, param := range c.Params {
+		if withHoles, ok := param.(WithHoles); ok {
+			if withHoles.IsHole(key) {
+				c.Params[k] = value.Clone()
+			} else {
+				withHoles.ReplaceHole(key, value)
+			}
+		}
+	}
+}
+
+func (c *CommandNode) IsHole(key string) bool {
+	return false
+}
+
 func (c *CommandNode) ProcessRefs(refs map[string]interface{}) {
 	for _, param := range c.Params {
 		if withRef, ok := param.(WithRefs); ok {
@@ -191,6 +322,26 @@ func (c *CommandNode) IsRef(key string) bool {
 	return false
 }
 
+func (c *CommandNode) GetFunctions() (funcs []string) {
+	for _, param := range c.Params {
+		if withFuncs, ok := param.(WithFunctions); ok {
+			funcs = append(funcs, withFuncs.GetFunctions()...)
+		}
+	}
+	return
+}
+
+func (c *CommandNode) ResolveFunctions(eval func(string, []interface{}) (interface{}, error)) error {
+	for _, param := range c.Params {
+		if withFuncs, ok := param.(WithFunctions); ok {
+			if err := withFuncs.ResolveFunctions(eval); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *CommandNode) ToDriverParams() map[string]interface{} {
 	params := make(map[string]interface{})
 	for k, v := range c.Params {
@@ -284,6 +435,34 @@ func (n *ValueNode) IsRef(key string) bool {
 	return false
 }
 
+func (n *ValueNode) GetFunctions() (funcs []string) {
+	if withFuncs, ok := n.Value.(WithFunctions); ok {
+		funcs = append(funcs, withFuncs.GetFunctions()...)
+	}
+	return
+}
+
+func (n *ValueNode) ResolveFunctions(eval func(string, []interface{}) (interface{}, error)) error {
+	if withFuncs, ok := n.Value.(WithFunctions); ok {
+		return withFuncs.ResolveFunctions(eval)
+	}
+	return nil
+}
+
+func (n *ValueNode) ReplaceHole(key string, value CompositeValue) {
+	if withHoles, ok := n.Value.(WithHoles); ok {
+		if withHoles.IsHole(key) {
+			n.Value = value.Clone()
+		} else {
+			withHoles.ReplaceHole(key, value)
+		}
+	}
+}
+
+func (n *ValueNode) IsHole(key string) bool {
+	return false
+}
+
 func (n *ValueNode) GetHoles() map[string]*Hole {
 	if withHoles, ok := n.Value.(WithHoles); ok {
 		return withHoles.GetHoles()
@@ -292,7 +471,7 @@ func (n *ValueNode) GetHoles() map[string]*Hole {
 }
 
 func (s *Statement) Clone() *Statement {
-	newStat := &Statement{}
+	newStat := &Statement{Pos: s.Pos, End: s.End}
 	newStat.Node = s.Node.clone()
 
 	return newStat
@@ -320,6 +499,300 @@ func (n *DeclarationNode) String() string {
 	return fmt.Sprintf("%s = %s", n.Ident, n.Expr)
 }
 
+// CommentNode is a comment line, its text including the comment marker
+type CommentNode struct {
+	Text string
+}
+
+func (n *CommentNode) clone() Node {
+	return &CommentNode{Text: n.Text}
+}
+
+func (n *CommentNode) String() string {
+	return n.Text
+}
+
+// ParamTypes are the types of values a template param can be declared with
+var ParamTypes = []string{"string", "int", "bool", "cidr", "ip"}
+
+// ParamNode declares in the template header an input of the template, i.e. the holes
+// of the same name, with its optional type, default value, allowed values and description
+type ParamNode struct {
+	Name        string
+	Type        string
+	Default     CompositeValue
+	Enum        []CompositeValue
+	Description string
+}
+
+func (n *ParamNode) clone() Node {
+	param := &ParamNode{Name: n.Name, Type: n.Type, Description: n.Description}
+	if n.Default != nil {
+		param.Default = n.Default.Clone()
+	}
+	for _, v := range n.Enum {
+		param.Enum = append(param.Enum, v.Clone())
+	}
+	return param
+}
+
+func (n *ParamNode) String() string {
+	var buff bytes.Buffer
+	fmt.Fprintf(&buff, "param %s", n.Name)
+	if n.Type != "" {
+		fmt.Fprintf(&buff, ": %s", n.Type)
+	}
+	if n.Default != nil {
+		fmt.Fprintf(&buff, " = %s", n.Default)
+	}
+	if len(n.Enum) > 0 {
+		var values []string
+		for _, v := range n.Enum {
+			values = append(values, v.String())
+		}
+		fmt.Fprintf(&buff, " enum(%s)", strings.Join(values, ", "))
+	}
+	if n.Description != "" {
+		fmt.Fprintf(&buff, " description %s", quoteString(n.Description))
+	}
+	return buff.String()
+}
+
+// DefaultValue returns the declared default value, nil if none
+func (n *ParamNode) DefaultValue() interface{} {
+	if n.Default == nil {
+		return nil
+	}
+	return n.Default.Value()
+}
+
+// EnumValues returns the declared allowed values as strings
+func (n *ParamNode) EnumValues() (values []string) {
+	for _, v := range n.Enum {
+		values = append(values, fmt.Sprint(v.Value()))
+	}
+	return
+}
+
+// Validate checks a value against the declared type and allowed values
+func (n *ParamNode) Validate(value interface{}) error {
+	str := fmt.Sprint(value)
+	switch n.Type {
+	case "int":
+		if _, err := strconv.Atoi(str); err != nil {
+			return fmt.Errorf("param '%s': expecting an int, got '%s'", n.Name, str)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(str); err != nil {
+			return fmt.Errorf("param '%s': expecting a bool, got '%s'", n.Name, str)
+		}
+	case "cidr":
+		if _, _, err := net.ParseCIDR(str); err != nil {
+			return fmt.Errorf("param '%s': expecting a CIDR, got '%s'", n.Name, str)
+		}
+	case "ip":
+		if net.ParseIP(str) == nil {
+			return fmt.Errorf("param '%s': expecting an IP, got '%s'", n.Name, str)
+		}
+	}
+	if enum := n.EnumValues(); len(enum) > 0 {
+		for _, e := range enum {
+			if e == str {
+				return nil
+			}
+		}
+		return fmt.Errorf("param '%s': expecting one of %s, got '%s'", n.Name, strings.Join(enum, ", "), str)
+	}
+	return nil
+}
+
+func (n *ForNode) clone() Node {
+	loop := &ForNode{
+		Ident: n.Ident,
+		Items: n.Items.clone().(*ValueNode),
+	}
+	for _, stat := range n.Statements {
+		loop.Statements = append(loop.Statements, stat.Clone())
+	}
+	return loop
+}
+
+func (n *ForNode) String() string {
+	var buff bytes.Buffer
+	fmt.Fprintf(&buff, "for %s in %s {\n", n.Ident, n.Items)
+	for _, stat := range n.Statements {
+		for _, line := range strings.Split(stat.String(), "\n") {
+			fmt.Fprintf(&buff, "\t%s\n", line)
+		}
+	}
+	buff.WriteString("}")
+	return buff.String()
+}
+
+// Iterate returns the values to loop over, a single value
+// being iterated once. It fails when values are not yet resolved.
+func (n *ForNode) Iterate() ([]interface{}, error) {
+	switch v := n.Items.Value.Value().(type) {
+	case nil:
+		return nil, fmt.Errorf("for %s: cannot iterate over unresolved value %s", n.Ident, n.Items)
+	case []interface{}:
+		return v, nil
+	case []string:
+		var items []interface{}
+		for _, s := range v {
+			items = append(items, s)
+		}
+		return items, nil
+	default:
+		return []interface{}{v}, nil
+	}
+}
+
+// Skipped returns true when the condition has been resolved
+// at compilation and the statements of the block will not run
+func (n *IfNode) Skipped() bool {
+	return n.Condition.Operator == ExistsOperator && n.Condition.resolved && n.Condition.exists == n.Unless
+}
+
+func (n *IfNode) clone() Node {
+	cond := &IfNode{
+		Unless:    n.Unless,
+		Condition: n.Condition.clone().(*ConditionNode),
+	}
+	for _, stat := range n.Statements {
+		cond.Statements = append(cond.Statements, stat.Clone())
+	}
+	return cond
+}
+
+func (n *IfNode) String() string {
+	var buff bytes.Buffer
+	keyword := "if"
+	if n.Unless {
+		keyword = "unless"
+	}
+	fmt.Fprintf(&buff, "%s %s {\n", keyword, n.Condition)
+	for _, stat := range n.Statements {
+		for _, line := range strings.Split(stat.String(), "\n") {
+			fmt.Fprintf(&buff, "\t%s\n", line)
+		}
+	}
+	buff.WriteString("}")
+	return buff.String()
+}
+
+func (n *IncludeNode) clone() Node {
+	include := &IncludeNode{
+		Path:   n.Path,
+		Params: make(map[string]CompositeValue),
+	}
+	for k, v := range n.Params {
+		include.Params[k] = v.Clone()
+	}
+	return include
+}
+
+func (n *IncludeNode) String() string {
+	var all []string
+	for k, v := range n.Params {
+		all = append(all, fmt.Sprintf("%s=%s", k, v.String()))
+	}
+	sort.Strings(all)
+
+	var buff bytes.Buffer
+	fmt.Fprintf(&buff, "include %s", quoteStringIfNeeded(n.Path))
+	if len(all) > 0 {
+		fmt.Fprintf(&buff, " with %s", strings.Join(all, " "))
+	}
+	return buff.String()
+}
+
+func (n *OutputNode) clone() Node {
+	return &OutputNode{Ident: n.Ident, Value: n.Value.clone().(*ValueNode)}
+}
+
+func (n *OutputNode) String() string {
+	return fmt.Sprintf("output %s = %s", n.Ident, n.Value)
+}
+
+func (n *ConditionNode) clone() Node {
+	cond := &ConditionNode{
+		Operator: n.Operator, Entity: n.Entity,
+		exists: n.exists, resolved: n.resolved,
+	}
+	if n.Left != nil {
+		cond.Left = n.Left.clone().(*ValueNode)
+	}
+	if n.Right != nil {
+		cond.Right = n.Right.clone().(*ValueNode)
+	}
+	return cond
+}
+
+func (n *ConditionNode) String() string {
+	if n.Operator == ExistsOperator {
+		if n.Entity != "" {
+			return fmt.Sprintf("%s %s %s", n.Operator, n.Entity, n.Left)
+		}
+		return fmt.Sprintf("%s %s", n.Operator, n.Left)
+	}
+	return fmt.Sprintf("%s %s %s", n.Left, n.Operator, n.Right)
+}
+
+// Operands returns the compared values, none for an existence test
+func (n *ConditionNode) Operands() []*ValueNode {
+	if n.Operator == ExistsOperator {
+		return nil
+	}
+	return []*ValueNode{n.Left, n.Right}
+}
+
+// ResolveExists resolves an existence test given a lookup of aliases per entity
+func (n *ConditionNode) ResolveExists(lookup func(entity, alias string) (bool, error)) error {
+	if n.Operator != ExistsOperator {
+		return nil
+	}
+	withAlias, ok := n.Left.Value.(WithAlias)
+	if !ok {
+		return nil
+	}
+	exists := true
+	for _, alias := range withAlias.GetAliases() {
+		found, err := lookup(n.Entity, alias)
+		if err != nil {
+			return err
+		}
+		exists = exists && found
+	}
+	n.exists, n.resolved = exists, true
+	return nil
+}
+
+func (n *ConditionNode) ProcessRefs(refs map[string]interface{}) {
+	for _, operand := range n.Operands() {
+		operand.ProcessRefs(refs)
+	}
+}
+
+func (n *ConditionNode) Eval() (bool, error) {
+	switch n.Operator {
+	case ExistsOperator:
+		if !n.resolved {
+			return false, fmt.Errorf("condition '%s': existence has not been resolved", n)
+		}
+		return n.exists, nil
+	case EqualOperator, NotEqualOperator:
+		left, right := n.Left.Value.Value(), n.Right.Value.Value()
+		if left == nil || right == nil {
+			return false, fmt.Errorf("condition '%s': cannot compare unresolved values", n)
+		}
+		equal := fmt.Sprint(left) == fmt.Sprint(right)
+		return equal == (n.Operator == EqualOperator), nil
+	default:
+		return false, fmt.Errorf("condition '%s': unknown operator '%s'", n, n.Operator)
+	}
+}
+
 func printParamValue(i interface{}) string {
 	switch ii := i.(type) {
 	case nil:
@@ -356,7 +829,7 @@ func quoteStringIfNeeded(input string) string {
 	if _, err := strconv.ParseFloat(input, 64); err == nil {
 		return "'" + input + "'"
 	}
-	if SimpleStringValue.MatchString(input) {
+	if SimpleStringValue.MatchString(input) && !strings.HasPrefix(input, "@") { // unquoted, a leading @ would read as an alias
 		return input
 	} else {
 		return quoteString(input)
@@ -20,6 +20,13 @@ const (
 	ruleEntity
 	ruleDeclaration
 	ruleValueExpr
+	ruleForExpr
+	ruleIfExpr
+	ruleIncludeExpr
+	ruleOutputExpr
+	ruleParamExpr
+	ruleParamLiteral
+	ruleCondition
 	ruleCmdExpr
 	ruleParams
 	ruleParam
@@ -29,6 +36,8 @@ const (
 	ruleListWithoutSquareBrackets
 	ruleNoRefValue
 	ruleValue
+	ruleFunctionValue
+	ruleFunctionArg
 	ruleCustomTypedValue
 	ruleUnquotedParamValue
 	ruleUnquotedParam
@@ -55,8 +64,8 @@ const (
 	ruleEndOfLine
 	ruleEndOfFile
 	ruleAction0
-	ruleAction1
 	rulePegText
+	ruleAction1
 	ruleAction2
 	ruleAction3
 	ruleAction4
@@ -80,6 +89,31 @@ const (
 	ruleAction22
 	ruleAction23
 	ruleAction24
+	ruleAction25
+	ruleAction26
+	ruleAction27
+	ruleAction28
+	ruleAction29
+	ruleAction30
+	ruleAction31
+	ruleAction32
+	ruleAction33
+	ruleAction34
+	ruleAction35
+	ruleAction36
+	ruleAction37
+	ruleAction38
+	ruleAction39
+	ruleAction40
+	ruleAction41
+	ruleAction42
+	ruleAction43
+	ruleAction44
+	ruleAction45
+	ruleAction46
+	ruleAction47
+	ruleAction48
+	ruleAction49
 )
 
 var rul3s = [...]string{
@@ -90,6 +124,13 @@ var rul3s = [...]string{
 	"Entity",
 	"Declaration",
 	"ValueExpr",
+	"ForExpr",
+	"IfExpr",
+	"IncludeExpr",
+	"OutputExpr",
+	"ParamExpr",
+	"ParamLiteral",
+	"Condition",
 	"CmdExpr",
 	"Params",
 	"Param",
@@ -99,6 +140,8 @@ var rul3s = [...]string{
 	"ListWithoutSquareBrackets",
 	"NoRefValue",
 	"Value",
+	"FunctionValue",
+	"FunctionArg",
 	"CustomTypedValue",
 	"UnquotedParamValue",
 	"UnquotedParam",
@@ -125,8 +168,8 @@ var rul3s = [...]string{
 	"EndOfLine",
 	"EndOfFile",
 	"Action0",
-	"Action1",
 	"PegText",
+	"Action1",
 	"Action2",
 	"Action3",
 	"Action4",
@@ -150,6 +193,31 @@ var rul3s = [...]string{
 	"Action22",
 	"Action23",
 	"Action24",
+	"Action25",
+	"Action26",
+	"Action27",
+	"Action28",
+	"Action29",
+	"Action30",
+	"Action31",
+	"Action32",
+	"Action33",
+	"Action34",
+	"Action35",
+	"Action36",
+	"Action37",
+	"Action38",
+	"Action39",
+	"Action40",
+	"Action41",
+	"Action42",
+	"Action43",
+	"Action44",
+	"Action45",
+	"Action46",
+	"Action47",
+	"Action48",
+	"Action49",
 }
 
 type token32 struct {
@@ -266,7 +334,7 @@ type Peg struct {
 
 	Buffer string
 	buffer []rune
-	rules  [67]func() bool
+	rules  [101]func() bool
 	parse  func(rule ...int) error
 	reset  func()
 	Pretty bool
@@ -361,53 +429,103 @@ func (p *Peg) Execute() {
 		case ruleAction0:
 			p.NewStatement()
 		case ruleAction1:
-			p.StatementDone()
+			p.markStatementPosition(end)
 		case ruleAction2:
-			p.addDeclarationIdentifier(text)
+			p.markStatementEnd(begin)
 		case ruleAction3:
-			p.addValue()
+			p.StatementDone()
 		case ruleAction4:
-			p.addAction(text)
+			p.addDeclarationIdentifier(text)
 		case ruleAction5:
-			p.addEntity(text)
+			p.addValue()
 		case ruleAction6:
-			p.addParamKey(text)
+			p.addForIdentifier(text)
 		case ruleAction7:
-			p.addFirstValueInList()
+			p.startForBody()
 		case ruleAction8:
-			p.lastValueInList()
+			p.endForBody()
 		case ruleAction9:
-			p.addFirstValueInList()
+			p.addIfKeyword(text)
 		case ruleAction10:
-			p.lastValueInList()
+			p.startIfBody()
 		case ruleAction11:
-			p.addAliasParam(text)
+			p.endIfBody()
 		case ruleAction12:
-			p.addParamRefValue(text)
+			p.addIncludePath(text)
 		case ruleAction13:
-			p.addParamValue(text)
+			p.addOutputIdentifier(text)
 		case ruleAction14:
-			p.addParamValue(text)
+			p.addParamName(text)
 		case ruleAction15:
-			p.addFirstValueInConcatenation()
+			p.addParamType(text)
 		case ruleAction16:
-			p.lastValueInConcatenation()
+			p.addParamDefault()
 		case ruleAction17:
-			p.addFirstValueInConcatenation()
+			p.addParamEnumValue()
 		case ruleAction18:
-			p.lastValueInConcatenation()
+			p.addParamEnumValue()
 		case ruleAction19:
-			p.addStringValue(text)
+			p.addParamDescription(text)
 		case ruleAction20:
-			p.addParamHoleValue(text)
+			p.addConditionOperator(text)
 		case ruleAction21:
-			p.addFirstValueInConcatenation()
+			p.addConditionEntity(text)
 		case ruleAction22:
-			p.lastValueInConcatenation()
+			p.addAliasParam(text)
 		case ruleAction23:
-			p.addFirstValueInConcatenation()
+			p.addConditionOperator(text)
 		case ruleAction24:
+			p.addAction(text)
+		case ruleAction25:
+			p.markCommandPosition(begin)
+		case ruleAction26:
+			p.addEntity(text)
+		case ruleAction27:
+			p.addParamKey(text)
+		case ruleAction28:
+			p.markParamPosition(begin)
+		case ruleAction29:
+			p.addFirstValueInList()
+		case ruleAction30:
+			p.lastValueInList()
+		case ruleAction31:
+			p.addFirstValueInList()
+		case ruleAction32:
+			p.lastValueInList()
+		case ruleAction33:
+			p.addAliasParam(text)
+		case ruleAction34:
+			p.addParamRefValue(text)
+		case ruleAction35:
+			p.startFunction(text)
+		case ruleAction36:
+			p.endFunction()
+		case ruleAction37:
+			p.addParamValue(text)
+		case ruleAction38:
+			p.addParamValue(text)
+		case ruleAction39:
+			p.addFirstValueInConcatenation()
+		case ruleAction40:
+			p.lastValueInConcatenation()
+		case ruleAction41:
+			p.addFirstValueInConcatenation()
+		case ruleAction42:
+			p.lastValueInConcatenation()
+		case ruleAction43:
+			p.addStringValue(text)
+		case ruleAction44:
+			p.addParamHoleValue(text)
+		case ruleAction45:
+			p.addFirstValueInConcatenation()
+		case ruleAction46:
+			p.lastValueInConcatenation()
+		case ruleAction47:
+			p.addFirstValueInConcatenation()
+		case ruleAction48:
 			p.lastValueInConcatenation()
+		case ruleAction49:
+			p.addComment(text)
 
 		}
 	}
@@ -497,312 +615,44 @@ func (p *Peg) Init() {
 				l5:
 					position, tokenIndex = position5, tokenIndex5
 				}
-				{
-					position6 := position
-					{
-						add(ruleAction0, position)
-					}
-					if !_rules[ruleWhiteSpacing]() {
-						goto l0
-					}
-					{
-						position8, tokenIndex8 := position, tokenIndex
-						if !_rules[ruleCmdExpr]() {
-							goto l9
-						}
-						goto l8
-					l9:
-						position, tokenIndex = position8, tokenIndex8
-						{
-							position11 := position
-							{
-								position12 := position
-								if !_rules[ruleIdentifier]() {
-									goto l10
-								}
-								add(rulePegText, position12)
-							}
-							{
-								add(ruleAction2, position)
-							}
-							if !_rules[ruleEqual]() {
-								goto l10
-							}
-							{
-								position14, tokenIndex14 := position, tokenIndex
-								if !_rules[ruleCmdExpr]() {
-									goto l15
-								}
-								goto l14
-							l15:
-								position, tokenIndex = position14, tokenIndex14
-								{
-									position16 := position
-									{
-										add(ruleAction3, position)
-									}
-									if !_rules[ruleCompositeValue]() {
-										goto l10
-									}
-									add(ruleValueExpr, position16)
-								}
-							}
-						l14:
-							add(ruleDeclaration, position11)
-						}
-						goto l8
-					l10:
-						position, tokenIndex = position8, tokenIndex8
-						{
-							position18 := position
-							{
-								position19, tokenIndex19 := position, tokenIndex
-								if buffer[position] != rune('#') {
-									goto l20
-								}
-								position++
-							l21:
-								{
-									position22, tokenIndex22 := position, tokenIndex
-									{
-										position23, tokenIndex23 := position, tokenIndex
-										if !_rules[ruleEndOfLine]() {
-											goto l23
-										}
-										goto l22
-									l23:
-										position, tokenIndex = position23, tokenIndex23
-									}
-									if !matchDot() {
-										goto l22
-									}
-									goto l21
-								l22:
-									position, tokenIndex = position22, tokenIndex22
-								}
-								goto l19
-							l20:
-								position, tokenIndex = position19, tokenIndex19
-								if buffer[position] != rune('/') {
-									goto l0
-								}
-								position++
-								if buffer[position] != rune('/') {
-									goto l0
-								}
-								position++
-							l24:
-								{
-									position25, tokenIndex25 := position, tokenIndex
-									{
-										position26, tokenIndex26 := position, tokenIndex
-										if !_rules[ruleEndOfLine]() {
-											goto l26
-										}
-										goto l25
-									l26:
-										position, tokenIndex = position26, tokenIndex26
-									}
-									if !matchDot() {
-										goto l25
-									}
-									goto l24
-								l25:
-									position, tokenIndex = position25, tokenIndex25
-								}
-							}
-						l19:
-							add(ruleComment, position18)
-						}
-					}
-				l8:
-					if !_rules[ruleWhiteSpacing]() {
-						goto l0
-					}
-				l27:
-					{
-						position28, tokenIndex28 := position, tokenIndex
-						if !_rules[ruleEndOfLine]() {
-							goto l28
-						}
-						goto l27
-					l28:
-						position, tokenIndex = position28, tokenIndex28
-					}
-					{
-						add(ruleAction1, position)
-					}
-					add(ruleStatement, position6)
+				if !_rules[ruleStatement]() {
+					goto l0
 				}
-			l30:
+			l6:
 				{
-					position31, tokenIndex31 := position, tokenIndex
+					position7, tokenIndex7 := position, tokenIndex
 					if !_rules[ruleBlankLine]() {
-						goto l31
+						goto l7
 					}
-					goto l30
-				l31:
-					position, tokenIndex = position31, tokenIndex31
+					goto l6
+				l7:
+					position, tokenIndex = position7, tokenIndex7
 				}
 			l2:
 				{
 					position3, tokenIndex3 := position, tokenIndex
-				l32:
+				l8:
 					{
-						position33, tokenIndex33 := position, tokenIndex
+						position9, tokenIndex9 := position, tokenIndex
 						if !_rules[ruleBlankLine]() {
-							goto l33
+							goto l9
 						}
-						goto l32
-					l33:
-						position, tokenIndex = position33, tokenIndex33
+						goto l8
+					l9:
+						position, tokenIndex = position9, tokenIndex9
 					}
-					{
-						position34 := position
-						{
-							add(ruleAction0, position)
-						}
-						if !_rules[ruleWhiteSpacing]() {
-							goto l3
-						}
-						{
-							position36, tokenIndex36 := position, tokenIndex
-							if !_rules[ruleCmdExpr]() {
-								goto l37
-							}
-							goto l36
-						l37:
-							position, tokenIndex = position36, tokenIndex36
-							{
-								position39 := position
-								{
-									position40 := position
-									if !_rules[ruleIdentifier]() {
-										goto l38
-									}
-									add(rulePegText, position40)
-								}
-								{
-									add(ruleAction2, position)
-								}
-								if !_rules[ruleEqual]() {
-									goto l38
-								}
-								{
-									position42, tokenIndex42 := position, tokenIndex
-									if !_rules[ruleCmdExpr]() {
-										goto l43
-									}
-									goto l42
-								l43:
-									position, tokenIndex = position42, tokenIndex42
-									{
-										position44 := position
-										{
-											add(ruleAction3, position)
-										}
-										if !_rules[ruleCompositeValue]() {
-											goto l38
-										}
-										add(ruleValueExpr, position44)
-									}
-								}
-							l42:
-								add(ruleDeclaration, position39)
-							}
-							goto l36
-						l38:
-							position, tokenIndex = position36, tokenIndex36
-							{
-								position46 := position
-								{
-									position47, tokenIndex47 := position, tokenIndex
-									if buffer[position] != rune('#') {
-										goto l48
-									}
-									position++
-								l49:
-									{
-										position50, tokenIndex50 := position, tokenIndex
-										{
-											position51, tokenIndex51 := position, tokenIndex
-											if !_rules[ruleEndOfLine]() {
-												goto l51
-											}
-											goto l50
-										l51:
-											position, tokenIndex = position51, tokenIndex51
-										}
-										if !matchDot() {
-											goto l50
-										}
-										goto l49
-									l50:
-										position, tokenIndex = position50, tokenIndex50
-									}
-									goto l47
-								l48:
-									position, tokenIndex = position47, tokenIndex47
-									if buffer[position] != rune('/') {
-										goto l3
-									}
-									position++
-									if buffer[position] != rune('/') {
-										goto l3
-									}
-									position++
-								l52:
-									{
-										position53, tokenIndex53 := position, tokenIndex
-										{
-											position54, tokenIndex54 := position, tokenIndex
-											if !_rules[ruleEndOfLine]() {
-												goto l54
-											}
-											goto l53
-										l54:
-											position, tokenIndex = position54, tokenIndex54
-										}
-										if !matchDot() {
-											goto l53
-										}
-										goto l52
-									l53:
-										position, tokenIndex = position53, tokenIndex53
-									}
-								}
-							l47:
-								add(ruleComment, position46)
-							}
-						}
-					l36:
-						if !_rules[ruleWhiteSpacing]() {
-							goto l3
-						}
-					l55:
-						{
-							position56, tokenIndex56 := position, tokenIndex
-							if !_rules[ruleEndOfLine]() {
-								goto l56
-							}
-							goto l55
-						l56:
-							position, tokenIndex = position56, tokenIndex56
-						}
-						{
-							add(ruleAction1, position)
-						}
-						add(ruleStatement, position34)
+					if !_rules[ruleStatement]() {
+						goto l3
 					}
-				l58:
+				l10:
 					{
-						position59, tokenIndex59 := position, tokenIndex
+						position11, tokenIndex11 := position, tokenIndex
 						if !_rules[ruleBlankLine]() {
-							goto l59
+							goto l11
 						}
-						goto l58
-					l59:
-						position, tokenIndex = position59, tokenIndex59
+						goto l10
+					l11:
+						position, tokenIndex = position11, tokenIndex11
 					}
 					goto l2
 				l3:
@@ -812,17 +662,17 @@ func (p *Peg) Init() {
 					goto l0
 				}
 				{
-					position60 := position
+					position12 := position
 					{
-						position61, tokenIndex61 := position, tokenIndex
+						position13, tokenIndex13 := position, tokenIndex
 						if !matchDot() {
-							goto l61
+							goto l13
 						}
 						goto l0
-					l61:
-						position, tokenIndex = position61, tokenIndex61
+					l13:
+						position, tokenIndex = position13, tokenIndex13
 					}
-					add(ruleEndOfFile, position60)
+					add(ruleEndOfFile, position12)
 				}
 				add(ruleScript, position1)
 			}
@@ -831,1514 +681,2667 @@ func (p *Peg) Init() {
 			position, tokenIndex = position0, tokenIndex0
 			return false
 		},
-		/* 1 Statement <- <(Action0 WhiteSpacing (CmdExpr / Declaration / Comment) WhiteSpacing EndOfLine* Action1)> */
-		nil,
-		/* 2 Action <- <[a-z]+> */
-		nil,
-		/* 3 Entity <- <([a-z] / [0-9])+> */
-		nil,
-		/* 4 Declaration <- <(<Identifier> Action2 Equal (CmdExpr / ValueExpr))> */
-		nil,
-		/* 5 ValueExpr <- <(Action3 CompositeValue)> */
-		nil,
-		/* 6 CmdExpr <- <(<Action> Action4 MustWhiteSpacing <Entity> Action5 (MustWhiteSpacing Params)?)> */
+		/* 1 Statement <- <(Action0 <WhiteSpacing> Action1 (ForExpr / IfExpr / IncludeExpr / OutputExpr / ParamExpr / CmdExpr / Declaration / Comment) <WhiteSpacing> Action2 EndOfLine* Action3)> */
 		func() bool {
-			position67, tokenIndex67 := position, tokenIndex
+			position14, tokenIndex14 := position, tokenIndex
 			{
-				position68 := position
+				position15 := position
 				{
-					position69 := position
-					{
-						position70 := position
-						if c := buffer[position]; c < rune('a') || c > rune('z') {
-							goto l67
-						}
-						position++
-					l71:
-						{
-							position72, tokenIndex72 := position, tokenIndex
-							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l72
-							}
-							position++
-							goto l71
-						l72:
-							position, tokenIndex = position72, tokenIndex72
-						}
-						add(ruleAction, position70)
-					}
-					add(rulePegText, position69)
+					add(ruleAction0, position)
 				}
 				{
-					add(ruleAction4, position)
+					position17 := position
+					if !_rules[ruleWhiteSpacing]() {
+						goto l14
+					}
+					add(rulePegText, position17)
 				}
-				if !_rules[ruleMustWhiteSpacing]() {
-					goto l67
+				{
+					add(ruleAction1, position)
 				}
 				{
-					position74 := position
+					position19, tokenIndex19 := position, tokenIndex
 					{
-						position75 := position
+						position21 := position
+						if buffer[position] != rune('f') {
+							goto l20
+						}
+						position++
+						if buffer[position] != rune('o') {
+							goto l20
+						}
+						position++
+						if buffer[position] != rune('r') {
+							goto l20
+						}
+						position++
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l20
+						}
 						{
-							position78, tokenIndex78 := position, tokenIndex
-							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l79
+							position22 := position
+							if !_rules[ruleIdentifier]() {
+								goto l20
 							}
-							position++
-							goto l78
-						l79:
-							position, tokenIndex = position78, tokenIndex78
-							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l67
+							add(rulePegText, position22)
+						}
+						{
+							add(ruleAction6, position)
+						}
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l20
+						}
+						if buffer[position] != rune('i') {
+							goto l20
+						}
+						position++
+						if buffer[position] != rune('n') {
+							goto l20
+						}
+						position++
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l20
+						}
+						if !_rules[ruleCompositeValue]() {
+							goto l20
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l20
+						}
+						if buffer[position] != rune('{') {
+							goto l20
+						}
+						position++
+						{
+							add(ruleAction7, position)
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l20
+						}
+					l25:
+						{
+							position26, tokenIndex26 := position, tokenIndex
+							if !_rules[ruleEndOfLine]() {
+								goto l26
 							}
-							position++
+							goto l25
+						l26:
+							position, tokenIndex = position26, tokenIndex26
 						}
-					l78:
-					l76:
+					l27:
 						{
-							position77, tokenIndex77 := position, tokenIndex
+							position28, tokenIndex28 := position, tokenIndex
+						l29:
 							{
-								position80, tokenIndex80 := position, tokenIndex
-								if c := buffer[position]; c < rune('a') || c > rune('z') {
-									goto l81
+								position30, tokenIndex30 := position, tokenIndex
+								if !_rules[ruleBlankLine]() {
+									goto l30
 								}
-								position++
-								goto l80
-							l81:
-								position, tokenIndex = position80, tokenIndex80
-								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l77
+								goto l29
+							l30:
+								position, tokenIndex = position30, tokenIndex30
+							}
+							if !_rules[ruleStatement]() {
+								goto l28
+							}
+						l31:
+							{
+								position32, tokenIndex32 := position, tokenIndex
+								if !_rules[ruleBlankLine]() {
+									goto l32
 								}
-								position++
+								goto l31
+							l32:
+								position, tokenIndex = position32, tokenIndex32
+							}
+							goto l27
+						l28:
+							position, tokenIndex = position28, tokenIndex28
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l20
+						}
+						if buffer[position] != rune('}') {
+							goto l20
+						}
+						position++
+						{
+							add(ruleAction8, position)
+						}
+						add(ruleForExpr, position21)
+					}
+					goto l19
+				l20:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position35 := position
+						{
+							position36 := position
+							{
+								position37, tokenIndex37 := position, tokenIndex
+								if buffer[position] != rune('i') {
+									goto l38
+								}
+								position++
+								if buffer[position] != rune('f') {
+									goto l38
+								}
+								position++
+								goto l37
+							l38:
+								position, tokenIndex = position37, tokenIndex37
+								if buffer[position] != rune('u') {
+									goto l34
+								}
+								position++
+								if buffer[position] != rune('n') {
+									goto l34
+								}
+								position++
+								if buffer[position] != rune('l') {
+									goto l34
+								}
+								position++
+								if buffer[position] != rune('e') {
+									goto l34
+								}
+								position++
+								if buffer[position] != rune('s') {
+									goto l34
+								}
+								position++
+								if buffer[position] != rune('s') {
+									goto l34
+								}
+								position++
+							}
+						l37:
+							add(rulePegText, position36)
+						}
+						{
+							add(ruleAction9, position)
+						}
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l34
+						}
+						{
+							position40 := position
+							{
+								position41, tokenIndex41 := position, tokenIndex
+								{
+									position43 := position
+									if buffer[position] != rune('e') {
+										goto l42
+									}
+									position++
+									if buffer[position] != rune('x') {
+										goto l42
+									}
+									position++
+									if buffer[position] != rune('i') {
+										goto l42
+									}
+									position++
+									if buffer[position] != rune('s') {
+										goto l42
+									}
+									position++
+									if buffer[position] != rune('t') {
+										goto l42
+									}
+									position++
+									if buffer[position] != rune('s') {
+										goto l42
+									}
+									position++
+									add(rulePegText, position43)
+								}
+								{
+									add(ruleAction20, position)
+								}
+								if !_rules[ruleMustWhiteSpacing]() {
+									goto l42
+								}
+								{
+									position45, tokenIndex45 := position, tokenIndex
+									{
+										position47 := position
+										if !_rules[ruleEntity]() {
+											goto l45
+										}
+										add(rulePegText, position47)
+									}
+									{
+										add(ruleAction21, position)
+									}
+									if !_rules[ruleMustWhiteSpacing]() {
+										goto l45
+									}
+									goto l46
+								l45:
+									position, tokenIndex = position45, tokenIndex45
+								}
+							l46:
+								if !_rules[ruleAliasValue]() {
+									goto l42
+								}
+								{
+									add(ruleAction22, position)
+								}
+								goto l41
+							l42:
+								position, tokenIndex = position41, tokenIndex41
+								if !_rules[ruleValue]() {
+									goto l34
+								}
+								if !_rules[ruleWhiteSpacing]() {
+									goto l34
+								}
+								{
+									position50 := position
+									{
+										position51, tokenIndex51 := position, tokenIndex
+										if buffer[position] != rune('=') {
+											goto l52
+										}
+										position++
+										if buffer[position] != rune('=') {
+											goto l52
+										}
+										position++
+										goto l51
+									l52:
+										position, tokenIndex = position51, tokenIndex51
+										if buffer[position] != rune('!') {
+											goto l34
+										}
+										position++
+										if buffer[position] != rune('=') {
+											goto l34
+										}
+										position++
+									}
+								l51:
+									add(rulePegText, position50)
+								}
+								{
+									add(ruleAction23, position)
+								}
+								if !_rules[ruleWhiteSpacing]() {
+									goto l34
+								}
+								if !_rules[ruleValue]() {
+									goto l34
+								}
+							}
+						l41:
+							add(ruleCondition, position40)
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l34
+						}
+						if buffer[position] != rune('{') {
+							goto l34
+						}
+						position++
+						{
+							add(ruleAction10, position)
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l34
+						}
+					l55:
+						{
+							position56, tokenIndex56 := position, tokenIndex
+							if !_rules[ruleEndOfLine]() {
+								goto l56
+							}
+							goto l55
+						l56:
+							position, tokenIndex = position56, tokenIndex56
+						}
+					l57:
+						{
+							position58, tokenIndex58 := position, tokenIndex
+						l59:
+							{
+								position60, tokenIndex60 := position, tokenIndex
+								if !_rules[ruleBlankLine]() {
+									goto l60
+								}
+								goto l59
+							l60:
+								position, tokenIndex = position60, tokenIndex60
+							}
+							if !_rules[ruleStatement]() {
+								goto l58
+							}
+						l61:
+							{
+								position62, tokenIndex62 := position, tokenIndex
+								if !_rules[ruleBlankLine]() {
+									goto l62
+								}
+								goto l61
+							l62:
+								position, tokenIndex = position62, tokenIndex62
+							}
+							goto l57
+						l58:
+							position, tokenIndex = position58, tokenIndex58
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l34
+						}
+						if buffer[position] != rune('}') {
+							goto l34
+						}
+						position++
+						{
+							add(ruleAction11, position)
+						}
+						add(ruleIfExpr, position35)
+					}
+					goto l19
+				l34:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position65 := position
+						if buffer[position] != rune('i') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('n') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('c') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('l') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('u') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('d') {
+							goto l64
+						}
+						position++
+						if buffer[position] != rune('e') {
+							goto l64
+						}
+						position++
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l64
+						}
+						{
+							switch buffer[position] {
+							case '\'':
+								if !_rules[ruleSingleQuotedValue]() {
+									goto l64
+								}
+								break
+							case '"':
+								if !_rules[ruleDoubleQuotedValue]() {
+									goto l64
+								}
+								break
+							default:
+								{
+									position67 := position
+									if !_rules[ruleUnquotedParam]() {
+										goto l64
+									}
+									add(rulePegText, position67)
+								}
+								break
+							}
+						}
+
+						{
+							add(ruleAction12, position)
+						}
+						{
+							position69, tokenIndex69 := position, tokenIndex
+							if !_rules[ruleMustWhiteSpacing]() {
+								goto l69
+							}
+							if buffer[position] != rune('w') {
+								goto l69
+							}
+							position++
+							if buffer[position] != rune('i') {
+								goto l69
+							}
+							position++
+							if buffer[position] != rune('t') {
+								goto l69
+							}
+							position++
+							if buffer[position] != rune('h') {
+								goto l69
+							}
+							position++
+							if !_rules[ruleMustWhiteSpacing]() {
+								goto l69
+							}
+							if !_rules[ruleParams]() {
+								goto l69
+							}
+							goto l70
+						l69:
+							position, tokenIndex = position69, tokenIndex69
+						}
+					l70:
+						add(ruleIncludeExpr, position65)
+					}
+					goto l19
+				l64:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position72 := position
+						if buffer[position] != rune('o') {
+							goto l71
+						}
+						position++
+						if buffer[position] != rune('u') {
+							goto l71
+						}
+						position++
+						if buffer[position] != rune('t') {
+							goto l71
+						}
+						position++
+						if buffer[position] != rune('p') {
+							goto l71
+						}
+						position++
+						if buffer[position] != rune('u') {
+							goto l71
+						}
+						position++
+						if buffer[position] != rune('t') {
+							goto l71
+						}
+						position++
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l71
+						}
+						{
+							position73 := position
+							if !_rules[ruleIdentifier]() {
+								goto l71
+							}
+							add(rulePegText, position73)
+						}
+						{
+							add(ruleAction13, position)
+						}
+						if !_rules[ruleEqual]() {
+							goto l71
+						}
+						if !_rules[ruleValueExpr]() {
+							goto l71
+						}
+						add(ruleOutputExpr, position72)
+					}
+					goto l19
+				l71:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position76 := position
+						if buffer[position] != rune('p') {
+							goto l75
+						}
+						position++
+						if buffer[position] != rune('a') {
+							goto l75
+						}
+						position++
+						if buffer[position] != rune('r') {
+							goto l75
+						}
+						position++
+						if buffer[position] != rune('a') {
+							goto l75
+						}
+						position++
+						if buffer[position] != rune('m') {
+							goto l75
+						}
+						position++
+						if !_rules[ruleMustWhiteSpacing]() {
+							goto l75
+						}
+						{
+							position77 := position
+							if !_rules[ruleIdentifier]() {
+								goto l75
+							}
+							add(rulePegText, position77)
+						}
+						{
+							add(ruleAction14, position)
+						}
+						{
+							position79, tokenIndex79 := position, tokenIndex
+							if !_rules[ruleWhiteSpacing]() {
+								goto l79
+							}
+							if buffer[position] != rune(':') {
+								goto l79
+							}
+							position++
+							if !_rules[ruleWhiteSpacing]() {
+								goto l79
+							}
+							{
+								position81 := position
+								if c := buffer[position]; c < rune('a') || c > rune('z') {
+									goto l79
+								}
+								position++
+							l82:
+								{
+									position83, tokenIndex83 := position, tokenIndex
+									if c := buffer[position]; c < rune('a') || c > rune('z') {
+										goto l83
+									}
+									position++
+									goto l82
+								l83:
+									position, tokenIndex = position83, tokenIndex83
+								}
+								add(rulePegText, position81)
+							}
+							{
+								add(ruleAction15, position)
+							}
+							goto l80
+						l79:
+							position, tokenIndex = position79, tokenIndex79
+						}
+					l80:
+						{
+							position85, tokenIndex85 := position, tokenIndex
+							if !_rules[ruleEqual]() {
+								goto l85
+							}
+							if !_rules[ruleParamLiteral]() {
+								goto l85
+							}
+							{
+								add(ruleAction16, position)
+							}
+							goto l86
+						l85:
+							position, tokenIndex = position85, tokenIndex85
+						}
+					l86:
+						{
+							position88, tokenIndex88 := position, tokenIndex
+							if !_rules[ruleMustWhiteSpacing]() {
+								goto l88
+							}
+							if buffer[position] != rune('e') {
+								goto l88
+							}
+							position++
+							if buffer[position] != rune('n') {
+								goto l88
+							}
+							position++
+							if buffer[position] != rune('u') {
+								goto l88
+							}
+							position++
+							if buffer[position] != rune('m') {
+								goto l88
+							}
+							position++
+							if buffer[position] != rune('(') {
+								goto l88
+							}
+							position++
+							if !_rules[ruleWhiteSpacing]() {
+								goto l88
+							}
+							if !_rules[ruleParamLiteral]() {
+								goto l88
+							}
+							{
+								add(ruleAction17, position)
+							}
+						l91:
+							{
+								position92, tokenIndex92 := position, tokenIndex
+								if !_rules[ruleWhiteSpacing]() {
+									goto l92
+								}
+								if buffer[position] != rune(',') {
+									goto l92
+								}
+								position++
+								if !_rules[ruleWhiteSpacing]() {
+									goto l92
+								}
+								if !_rules[ruleParamLiteral]() {
+									goto l92
+								}
+								{
+									add(ruleAction18, position)
+								}
+								goto l91
+							l92:
+								position, tokenIndex = position92, tokenIndex92
+							}
+							if !_rules[ruleWhiteSpacing]() {
+								goto l88
+							}
+							if buffer[position] != rune(')') {
+								goto l88
+							}
+							position++
+							goto l89
+						l88:
+							position, tokenIndex = position88, tokenIndex88
+						}
+					l89:
+						{
+							position94, tokenIndex94 := position, tokenIndex
+							if !_rules[ruleMustWhiteSpacing]() {
+								goto l94
+							}
+							if buffer[position] != rune('d') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('e') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('s') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('c') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('r') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('i') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('p') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('t') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('i') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('o') {
+								goto l94
+							}
+							position++
+							if buffer[position] != rune('n') {
+								goto l94
+							}
+							position++
+							if !_rules[ruleMustWhiteSpacing]() {
+								goto l94
+							}
+							if !_rules[ruleQuotedString]() {
+								goto l94
+							}
+							{
+								add(ruleAction19, position)
+							}
+							goto l95
+						l94:
+							position, tokenIndex = position94, tokenIndex94
+						}
+					l95:
+						add(ruleParamExpr, position76)
+					}
+					goto l19
+				l75:
+					position, tokenIndex = position19, tokenIndex19
+					if !_rules[ruleCmdExpr]() {
+						goto l97
+					}
+					goto l19
+				l97:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position99 := position
+						{
+							position100 := position
+							if !_rules[ruleIdentifier]() {
+								goto l98
+							}
+							add(rulePegText, position100)
+						}
+						{
+							add(ruleAction4, position)
+						}
+						if !_rules[ruleEqual]() {
+							goto l98
+						}
+						{
+							position102, tokenIndex102 := position, tokenIndex
+							if !_rules[ruleCmdExpr]() {
+								goto l103
+							}
+							goto l102
+						l103:
+							position, tokenIndex = position102, tokenIndex102
+							if !_rules[ruleValueExpr]() {
+								goto l98
+							}
+						}
+					l102:
+						add(ruleDeclaration, position99)
+					}
+					goto l19
+				l98:
+					position, tokenIndex = position19, tokenIndex19
+					{
+						position104 := position
+						{
+							position105 := position
+							{
+								position106, tokenIndex106 := position, tokenIndex
+								if buffer[position] != rune('#') {
+									goto l107
+								}
+								position++
+							l108:
+								{
+									position109, tokenIndex109 := position, tokenIndex
+									{
+										position110, tokenIndex110 := position, tokenIndex
+										if !_rules[ruleEndOfLine]() {
+											goto l110
+										}
+										goto l109
+									l110:
+										position, tokenIndex = position110, tokenIndex110
+									}
+									if !matchDot() {
+										goto l109
+									}
+									goto l108
+								l109:
+									position, tokenIndex = position109, tokenIndex109
+								}
+								goto l106
+							l107:
+								position, tokenIndex = position106, tokenIndex106
+								if buffer[position] != rune('/') {
+									goto l14
+								}
+								position++
+								if buffer[position] != rune('/') {
+									goto l14
+								}
+								position++
+							l111:
+								{
+									position112, tokenIndex112 := position, tokenIndex
+									{
+										position113, tokenIndex113 := position, tokenIndex
+										if !_rules[ruleEndOfLine]() {
+											goto l113
+										}
+										goto l112
+									l113:
+										position, tokenIndex = position113, tokenIndex113
+									}
+									if !matchDot() {
+										goto l112
+									}
+									goto l111
+								l112:
+									position, tokenIndex = position112, tokenIndex112
+								}
+							}
+						l106:
+							add(rulePegText, position105)
+						}
+						{
+							add(ruleAction49, position)
+						}
+						add(ruleComment, position104)
+					}
+				}
+			l19:
+				{
+					position115 := position
+					if !_rules[ruleWhiteSpacing]() {
+						goto l14
+					}
+					add(rulePegText, position115)
+				}
+				{
+					add(ruleAction2, position)
+				}
+			l117:
+				{
+					position118, tokenIndex118 := position, tokenIndex
+					if !_rules[ruleEndOfLine]() {
+						goto l118
+					}
+					goto l117
+				l118:
+					position, tokenIndex = position118, tokenIndex118
+				}
+				{
+					add(ruleAction3, position)
+				}
+				add(ruleStatement, position15)
+			}
+			return true
+		l14:
+			position, tokenIndex = position14, tokenIndex14
+			return false
+		},
+		/* 2 Action <- <[a-z]+> */
+		nil,
+		/* 3 Entity <- <([a-z] / [0-9])+> */
+		func() bool {
+			position121, tokenIndex121 := position, tokenIndex
+			{
+				position122 := position
+				{
+					position125, tokenIndex125 := position, tokenIndex
+					if c := buffer[position]; c < rune('a') || c > rune('z') {
+						goto l126
+					}
+					position++
+					goto l125
+				l126:
+					position, tokenIndex = position125, tokenIndex125
+					if c := buffer[position]; c < rune('0') || c > rune('9') {
+						goto l121
+					}
+					position++
+				}
+			l125:
+			l123:
+				{
+					position124, tokenIndex124 := position, tokenIndex
+					{
+						position127, tokenIndex127 := position, tokenIndex
+						if c := buffer[position]; c < rune('a') || c > rune('z') {
+							goto l128
+						}
+						position++
+						goto l127
+					l128:
+						position, tokenIndex = position127, tokenIndex127
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l124
+						}
+						position++
+					}
+				l127:
+					goto l123
+				l124:
+					position, tokenIndex = position124, tokenIndex124
+				}
+				add(ruleEntity, position122)
+			}
+			return true
+		l121:
+			position, tokenIndex = position121, tokenIndex121
+			return false
+		},
+		/* 4 Declaration <- <(<Identifier> Action4 Equal (CmdExpr / ValueExpr))> */
+		nil,
+		/* 5 ValueExpr <- <(Action5 CompositeValue)> */
+		func() bool {
+			position130, tokenIndex130 := position, tokenIndex
+			{
+				position131 := position
+				{
+					add(ruleAction5, position)
+				}
+				if !_rules[ruleCompositeValue]() {
+					goto l130
+				}
+				add(ruleValueExpr, position131)
+			}
+			return true
+		l130:
+			position, tokenIndex = position130, tokenIndex130
+			return false
+		},
+		/* 6 ForExpr <- <('f' 'o' 'r' MustWhiteSpacing <Identifier> Action6 MustWhiteSpacing ('i' 'n') MustWhiteSpacing CompositeValue WhiteSpacing '{' Action7 WhiteSpacing EndOfLine* (BlankLine* Statement BlankLine*)* WhiteSpacing '}' Action8)> */
+		nil,
+		/* 7 IfExpr <- <(<(('i' 'f') / ('u' 'n' 'l' 'e' 's' 's'))> Action9 MustWhiteSpacing Condition WhiteSpacing '{' Action10 WhiteSpacing EndOfLine* (BlankLine* Statement BlankLine*)* WhiteSpacing '}' Action11)> */
+		nil,
+		/* 8 IncludeExpr <- <('i' 'n' 'c' 'l' 'u' 'd' 'e' MustWhiteSpacing ((&('\'') SingleQuotedValue) | (&('"') DoubleQuotedValue) | (&('*' | '+' | '-' | '.' | '/' | '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9' | ':' | ';' | '<' | '>' | '@' | 'A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z' | '~') <UnquotedParam>)) Action12 (MustWhiteSpacing ('w' 'i' 't' 'h') MustWhiteSpacing Params)?)> */
+		nil,
+		/* 9 OutputExpr <- <('o' 'u' 't' 'p' 'u' 't' MustWhiteSpacing <Identifier> Action13 Equal ValueExpr)> */
+		nil,
+		/* 10 ParamExpr <- <('p' 'a' 'r' 'a' 'm' MustWhiteSpacing <Identifier> Action14 (WhiteSpacing ':' WhiteSpacing <[a-z]+> Action15)? (Equal ParamLiteral Action16)? (MustWhiteSpacing ('e' 'n' 'u' 'm' '(') WhiteSpacing ParamLiteral Action17 (WhiteSpacing ',' WhiteSpacing ParamLiteral Action18)* WhiteSpacing ')')? (MustWhiteSpacing ('d' 'e' 's' 'c' 'r' 'i' 'p' 't' 'i' 'o' 'n') MustWhiteSpacing QuotedString Action19)?)> */
+		nil,
+		/* 11 ParamLiteral <- <((&('[') ListValue) | (&('"' | '\'') QuotedStringValue) | (&('*' | '+' | '-' | '.' | '/' | '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9' | ':' | ';' | '<' | '>' | '@' | 'A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z' | '~') UnquotedParamValue))> */
+		func() bool {
+			position138, tokenIndex138 := position, tokenIndex
+			{
+				position139 := position
+				{
+					switch buffer[position] {
+					case '[':
+						if !_rules[ruleListValue]() {
+							goto l138
+						}
+						break
+					case '"', '\'':
+						if !_rules[ruleQuotedStringValue]() {
+							goto l138
+						}
+						break
+					default:
+						if !_rules[ruleUnquotedParamValue]() {
+							goto l138
+						}
+						break
+					}
+				}
+
+				add(ruleParamLiteral, position139)
+			}
+			return true
+		l138:
+			position, tokenIndex = position138, tokenIndex138
+			return false
+		},
+		/* 12 Condition <- <((<('e' 'x' 'i' 's' 't' 's')> Action20 MustWhiteSpacing (<Entity> Action21 MustWhiteSpacing)? AliasValue Action22) / (Value WhiteSpacing <(('=' '=') / ('!' '='))> Action23 WhiteSpacing Value))> */
+		nil,
+		/* 13 CmdExpr <- <(<Action> Action24 Action25 MustWhiteSpacing <Entity> Action26 (MustWhiteSpacing Params)?)> */
+		func() bool {
+			position142, tokenIndex142 := position, tokenIndex
+			{
+				position143 := position
+				{
+					position144 := position
+					{
+						position145 := position
+						if c := buffer[position]; c < rune('a') || c > rune('z') {
+							goto l142
+						}
+						position++
+					l146:
+						{
+							position147, tokenIndex147 := position, tokenIndex
+							if c := buffer[position]; c < rune('a') || c > rune('z') {
+								goto l147
 							}
-						l80:
-							goto l76
-						l77:
-							position, tokenIndex = position77, tokenIndex77
+							position++
+							goto l146
+						l147:
+							position, tokenIndex = position147, tokenIndex147
 						}
-						add(ruleEntity, position75)
+						add(ruleAction, position145)
 					}
-					add(rulePegText, position74)
+					add(rulePegText, position144)
 				}
 				{
-					add(ruleAction5, position)
+					add(ruleAction24, position)
+				}
+				{
+					add(ruleAction25, position)
+				}
+				if !_rules[ruleMustWhiteSpacing]() {
+					goto l142
+				}
+				{
+					position150 := position
+					if !_rules[ruleEntity]() {
+						goto l142
+					}
+					add(rulePegText, position150)
+				}
+				{
+					add(ruleAction26, position)
 				}
 				{
-					position83, tokenIndex83 := position, tokenIndex
+					position152, tokenIndex152 := position, tokenIndex
 					if !_rules[ruleMustWhiteSpacing]() {
-						goto l83
+						goto l152
+					}
+					if !_rules[ruleParams]() {
+						goto l152
+					}
+					goto l153
+				l152:
+					position, tokenIndex = position152, tokenIndex152
+				}
+			l153:
+				add(ruleCmdExpr, position143)
+			}
+			return true
+		l142:
+			position, tokenIndex = position142, tokenIndex142
+			return false
+		},
+		/* 14 Params <- <Param+> */
+		func() bool {
+			position154, tokenIndex154 := position, tokenIndex
+			{
+				position155 := position
+				{
+					position158 := position
+					{
+						position159 := position
+						if !_rules[ruleIdentifier]() {
+							goto l154
+						}
+						add(rulePegText, position159)
+					}
+					{
+						add(ruleAction27, position)
+					}
+					{
+						add(ruleAction28, position)
+					}
+					if !_rules[ruleEqual]() {
+						goto l154
+					}
+					if !_rules[ruleCompositeValue]() {
+						goto l154
+					}
+					if !_rules[ruleWhiteSpacing]() {
+						goto l154
 					}
+					add(ruleParam, position158)
+				}
+			l156:
+				{
+					position157, tokenIndex157 := position, tokenIndex
 					{
-						position85 := position
+						position162 := position
 						{
-							position88 := position
-							{
-								position89 := position
-								if !_rules[ruleIdentifier]() {
-									goto l83
-								}
-								add(rulePegText, position89)
-							}
-							{
-								add(ruleAction6, position)
-							}
-							if !_rules[ruleEqual]() {
-								goto l83
-							}
-							if !_rules[ruleCompositeValue]() {
-								goto l83
-							}
-							if !_rules[ruleWhiteSpacing]() {
-								goto l83
+							position163 := position
+							if !_rules[ruleIdentifier]() {
+								goto l157
 							}
-							add(ruleParam, position88)
+							add(rulePegText, position163)
 						}
-					l86:
 						{
-							position87, tokenIndex87 := position, tokenIndex
-							{
-								position91 := position
-								{
-									position92 := position
-									if !_rules[ruleIdentifier]() {
-										goto l87
-									}
-									add(rulePegText, position92)
-								}
-								{
-									add(ruleAction6, position)
-								}
-								if !_rules[ruleEqual]() {
-									goto l87
-								}
-								if !_rules[ruleCompositeValue]() {
-									goto l87
-								}
-								if !_rules[ruleWhiteSpacing]() {
-									goto l87
-								}
-								add(ruleParam, position91)
-							}
-							goto l86
-						l87:
-							position, tokenIndex = position87, tokenIndex87
+							add(ruleAction27, position)
+						}
+						{
+							add(ruleAction28, position)
+						}
+						if !_rules[ruleEqual]() {
+							goto l157
 						}
-						add(ruleParams, position85)
+						if !_rules[ruleCompositeValue]() {
+							goto l157
+						}
+						if !_rules[ruleWhiteSpacing]() {
+							goto l157
+						}
+						add(ruleParam, position162)
 					}
-					goto l84
-				l83:
-					position, tokenIndex = position83, tokenIndex83
+					goto l156
+				l157:
+					position, tokenIndex = position157, tokenIndex157
 				}
-			l84:
-				add(ruleCmdExpr, position68)
+				add(ruleParams, position155)
 			}
 			return true
-		l67:
-			position, tokenIndex = position67, tokenIndex67
+		l154:
+			position, tokenIndex = position154, tokenIndex154
 			return false
 		},
-		/* 7 Params <- <Param+> */
-		nil,
-		/* 8 Param <- <(<Identifier> Action6 Equal CompositeValue WhiteSpacing)> */
+		/* 15 Param <- <(<Identifier> Action27 Action28 Equal CompositeValue WhiteSpacing)> */
 		nil,
-		/* 9 Identifier <- <((&('.') '.') | (&('_') '_') | (&('-') '-') | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+> */
+		/* 16 Identifier <- <((&('.') '.') | (&('_') '_') | (&('-') '-') | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+> */
 		func() bool {
-			position96, tokenIndex96 := position, tokenIndex
+			position167, tokenIndex167 := position, tokenIndex
 			{
-				position97 := position
+				position168 := position
 				{
 					switch buffer[position] {
 					case '.':
 						if buffer[position] != rune('.') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					case '_':
 						if buffer[position] != rune('_') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					case '-':
 						if buffer[position] != rune('-') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 						if c := buffer[position]; c < rune('A') || c > rune('Z') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					default:
 						if c := buffer[position]; c < rune('a') || c > rune('z') {
-							goto l96
+							goto l167
 						}
 						position++
 						break
 					}
 				}
 
-			l98:
+			l169:
 				{
-					position99, tokenIndex99 := position, tokenIndex
+					position170, tokenIndex170 := position, tokenIndex
 					{
 						switch buffer[position] {
 						case '.':
 							if buffer[position] != rune('.') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						case '_':
 							if buffer[position] != rune('_') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						case '-':
 							if buffer[position] != rune('-') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 							if c := buffer[position]; c < rune('A') || c > rune('Z') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						default:
 							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l99
+								goto l170
 							}
 							position++
 							break
 						}
 					}
 
-					goto l98
-				l99:
-					position, tokenIndex = position99, tokenIndex99
+					goto l169
+				l170:
+					position, tokenIndex = position170, tokenIndex170
 				}
-				add(ruleIdentifier, position97)
+				add(ruleIdentifier, position168)
 			}
 			return true
-		l96:
-			position, tokenIndex = position96, tokenIndex96
+		l167:
+			position, tokenIndex = position167, tokenIndex167
 			return false
 		},
-		/* 10 CompositeValue <- <(ListValue / ListWithoutSquareBrackets / Value)> */
+		/* 17 CompositeValue <- <(ListValue / ListWithoutSquareBrackets / Value)> */
 		func() bool {
-			position102, tokenIndex102 := position, tokenIndex
+			position173, tokenIndex173 := position, tokenIndex
 			{
-				position103 := position
+				position174 := position
 				{
-					position104, tokenIndex104 := position, tokenIndex
-					{
-						position106 := position
-						{
-							add(ruleAction7, position)
-						}
-						if buffer[position] != rune('[') {
-							goto l105
-						}
-						position++
-						{
-							position108, tokenIndex108 := position, tokenIndex
-							if !_rules[ruleWhiteSpacing]() {
-								goto l108
-							}
-							if !_rules[ruleValue]() {
-								goto l108
-							}
-							if !_rules[ruleWhiteSpacing]() {
-								goto l108
-							}
-							goto l109
-						l108:
-							position, tokenIndex = position108, tokenIndex108
-						}
-					l109:
-					l110:
-						{
-							position111, tokenIndex111 := position, tokenIndex
-							if buffer[position] != rune(',') {
-								goto l111
-							}
-							position++
-							if !_rules[ruleWhiteSpacing]() {
-								goto l111
-							}
-							if !_rules[ruleValue]() {
-								goto l111
-							}
-							if !_rules[ruleWhiteSpacing]() {
-								goto l111
-							}
-							goto l110
-						l111:
-							position, tokenIndex = position111, tokenIndex111
-						}
-						if buffer[position] != rune(']') {
-							goto l105
-						}
-						position++
-						{
-							add(ruleAction8, position)
-						}
-						add(ruleListValue, position106)
+					position175, tokenIndex175 := position, tokenIndex
+					if !_rules[ruleListValue]() {
+						goto l176
 					}
-					goto l104
-				l105:
-					position, tokenIndex = position104, tokenIndex104
+					goto l175
+				l176:
+					position, tokenIndex = position175, tokenIndex175
 					{
-						position114 := position
+						position178 := position
 						{
-							add(ruleAction9, position)
+							add(ruleAction31, position)
 						}
 						if !_rules[ruleWhiteSpacing]() {
-							goto l113
+							goto l177
 						}
 						if !_rules[ruleValue]() {
-							goto l113
+							goto l177
 						}
 						if !_rules[ruleWhiteSpacing]() {
-							goto l113
+							goto l177
 						}
 						if buffer[position] != rune(',') {
-							goto l113
+							goto l177
 						}
 						position++
 						if !_rules[ruleWhiteSpacing]() {
-							goto l113
+							goto l177
 						}
 						if !_rules[ruleValue]() {
-							goto l113
+							goto l177
 						}
 						if !_rules[ruleWhiteSpacing]() {
-							goto l113
+							goto l177
 						}
-					l116:
+					l180:
 						{
-							position117, tokenIndex117 := position, tokenIndex
+							position181, tokenIndex181 := position, tokenIndex
 							if buffer[position] != rune(',') {
-								goto l117
+								goto l181
 							}
 							position++
 							if !_rules[ruleWhiteSpacing]() {
-								goto l117
+								goto l181
 							}
 							if !_rules[ruleValue]() {
-								goto l117
+								goto l181
 							}
 							if !_rules[ruleWhiteSpacing]() {
-								goto l117
+								goto l181
 							}
-							goto l116
-						l117:
-							position, tokenIndex = position117, tokenIndex117
+							goto l180
+						l181:
+							position, tokenIndex = position181, tokenIndex181
 						}
 						{
-							add(ruleAction10, position)
+							add(ruleAction32, position)
 						}
-						add(ruleListWithoutSquareBrackets, position114)
+						add(ruleListWithoutSquareBrackets, position178)
 					}
-					goto l104
-				l113:
-					position, tokenIndex = position104, tokenIndex104
+					goto l175
+				l177:
+					position, tokenIndex = position175, tokenIndex175
 					if !_rules[ruleValue]() {
-						goto l102
+						goto l173
 					}
 				}
-			l104:
-				add(ruleCompositeValue, position103)
+			l175:
+				add(ruleCompositeValue, position174)
 			}
 			return true
-		l102:
-			position, tokenIndex = position102, tokenIndex102
+		l173:
+			position, tokenIndex = position173, tokenIndex173
 			return false
 		},
-		/* 11 ListValue <- <(Action7 '[' (WhiteSpacing Value WhiteSpacing)? (',' WhiteSpacing Value WhiteSpacing)* ']' Action8)> */
-		nil,
-		/* 12 ListWithoutSquareBrackets <- <(Action9 (WhiteSpacing Value WhiteSpacing) (',' WhiteSpacing Value WhiteSpacing)+ Action10)> */
+		/* 18 ListValue <- <(Action29 '[' (WhiteSpacing Value WhiteSpacing)? (',' WhiteSpacing Value WhiteSpacing)* ']' Action30)> */
+		func() bool {
+			position183, tokenIndex183 := position, tokenIndex
+			{
+				position184 := position
+				{
+					add(ruleAction29, position)
+				}
+				if buffer[position] != rune('[') {
+					goto l183
+				}
+				position++
+				{
+					position186, tokenIndex186 := position, tokenIndex
+					if !_rules[ruleWhiteSpacing]() {
+						goto l186
+					}
+					if !_rules[ruleValue]() {
+						goto l186
+					}
+					if !_rules[ruleWhiteSpacing]() {
+						goto l186
+					}
+					goto l187
+				l186:
+					position, tokenIndex = position186, tokenIndex186
+				}
+			l187:
+			l188:
+				{
+					position189, tokenIndex189 := position, tokenIndex
+					if buffer[position] != rune(',') {
+						goto l189
+					}
+					position++
+					if !_rules[ruleWhiteSpacing]() {
+						goto l189
+					}
+					if !_rules[ruleValue]() {
+						goto l189
+					}
+					if !_rules[ruleWhiteSpacing]() {
+						goto l189
+					}
+					goto l188
+				l189:
+					position, tokenIndex = position189, tokenIndex189
+				}
+				if buffer[position] != rune(']') {
+					goto l183
+				}
+				position++
+				{
+					add(ruleAction30, position)
+				}
+				add(ruleListValue, position184)
+			}
+			return true
+		l183:
+			position, tokenIndex = position183, tokenIndex183
+			return false
+		},
+		/* 19 ListWithoutSquareBrackets <- <(Action31 (WhiteSpacing Value WhiteSpacing) (',' WhiteSpacing Value WhiteSpacing)+ Action32)> */
 		nil,
-		/* 13 NoRefValue <- <(ConcatenationValue / HoleWithSuffixValue / HoleValue / HolesStringValue / (AliasValue Action11) / (DoubleQuote CustomTypedValue DoubleQuote) / (SingleQuote CustomTypedValue SingleQuote) / CustomTypedValue / QuotedStringValue / UnquotedParamValue)> */
+		/* 20 NoRefValue <- <(FunctionValue / ConcatenationValue / HoleWithSuffixValue / HoleValue / HolesStringValue / (AliasValue Action33) / (DoubleQuote CustomTypedValue DoubleQuote) / (SingleQuote CustomTypedValue SingleQuote) / CustomTypedValue / QuotedStringValue / UnquotedParamValue)> */
 		nil,
-		/* 14 Value <- <((RefValue Action12) / NoRefValue)> */
+		/* 21 Value <- <((RefValue Action34) / NoRefValue)> */
 		func() bool {
-			position122, tokenIndex122 := position, tokenIndex
+			position193, tokenIndex193 := position, tokenIndex
 			{
-				position123 := position
+				position194 := position
 				{
-					position124, tokenIndex124 := position, tokenIndex
+					position195, tokenIndex195 := position, tokenIndex
 					{
-						position126 := position
+						position197 := position
 						if buffer[position] != rune('$') {
-							goto l125
+							goto l196
 						}
 						position++
 						{
-							position127 := position
+							position198 := position
 							if !_rules[ruleIdentifier]() {
-								goto l125
+								goto l196
 							}
-							add(rulePegText, position127)
+							add(rulePegText, position198)
 						}
-						add(ruleRefValue, position126)
+						add(ruleRefValue, position197)
 					}
 					{
-						add(ruleAction12, position)
+						add(ruleAction34, position)
 					}
-					goto l124
-				l125:
-					position, tokenIndex = position124, tokenIndex124
+					goto l195
+				l196:
+					position, tokenIndex = position195, tokenIndex195
 					{
-						position129 := position
+						position200 := position
 						{
-							position130, tokenIndex130 := position, tokenIndex
+							position201, tokenIndex201 := position, tokenIndex
+							{
+								position203 := position
+								{
+									position204 := position
+									if c := buffer[position]; c < rune('a') || c > rune('z') {
+										goto l202
+									}
+									position++
+								l205:
+									{
+										position206, tokenIndex206 := position, tokenIndex
+										{
+											position207, tokenIndex207 := position, tokenIndex
+											if c := buffer[position]; c < rune('a') || c > rune('z') {
+												goto l208
+											}
+											position++
+											goto l207
+										l208:
+											position, tokenIndex = position207, tokenIndex207
+											if c := buffer[position]; c < rune('0') || c > rune('9') {
+												goto l206
+											}
+											position++
+										}
+									l207:
+										goto l205
+									l206:
+										position, tokenIndex = position206, tokenIndex206
+									}
+									add(rulePegText, position204)
+								}
+								{
+									add(ruleAction35, position)
+								}
+								if buffer[position] != rune('(') {
+									goto l202
+								}
+								position++
+								if !_rules[ruleWhiteSpacing]() {
+									goto l202
+								}
+								{
+									position210, tokenIndex210 := position, tokenIndex
+									if !_rules[ruleFunctionArg]() {
+										goto l210
+									}
+									if !_rules[ruleWhiteSpacing]() {
+										goto l210
+									}
+								l212:
+									{
+										position213, tokenIndex213 := position, tokenIndex
+										if buffer[position] != rune(',') {
+											goto l213
+										}
+										position++
+										if !_rules[ruleWhiteSpacing]() {
+											goto l213
+										}
+										if !_rules[ruleFunctionArg]() {
+											goto l213
+										}
+										if !_rules[ruleWhiteSpacing]() {
+											goto l213
+										}
+										goto l212
+									l213:
+										position, tokenIndex = position213, tokenIndex213
+									}
+									goto l211
+								l210:
+									position, tokenIndex = position210, tokenIndex210
+								}
+							l211:
+								if buffer[position] != rune(')') {
+									goto l202
+								}
+								position++
+								{
+									add(ruleAction36, position)
+								}
+								add(ruleFunctionValue, position203)
+							}
+							goto l201
+						l202:
+							position, tokenIndex = position201, tokenIndex201
 							{
-								position132 := position
+								position216 := position
 								{
-									position133, tokenIndex133 := position, tokenIndex
+									position217, tokenIndex217 := position, tokenIndex
 									{
-										add(ruleAction15, position)
+										add(ruleAction39, position)
 									}
 									if !_rules[ruleHoleValue]() {
-										goto l134
+										goto l218
 									}
 									if !_rules[ruleWhiteSpacing]() {
-										goto l134
+										goto l218
 									}
 									if buffer[position] != rune('+') {
-										goto l134
+										goto l218
 									}
 									position++
 									if !_rules[ruleWhiteSpacing]() {
-										goto l134
+										goto l218
 									}
 									{
-										position138, tokenIndex138 := position, tokenIndex
+										position222, tokenIndex222 := position, tokenIndex
 										if !_rules[ruleQuotedStringValue]() {
-											goto l139
+											goto l223
 										}
-										goto l138
-									l139:
-										position, tokenIndex = position138, tokenIndex138
+										goto l222
+									l223:
+										position, tokenIndex = position222, tokenIndex222
 										if !_rules[ruleHoleValue]() {
-											goto l134
+											goto l218
 										}
 									}
-								l138:
-								l136:
+								l222:
+								l220:
 									{
-										position137, tokenIndex137 := position, tokenIndex
+										position221, tokenIndex221 := position, tokenIndex
 										if !_rules[ruleWhiteSpacing]() {
-											goto l137
+											goto l221
 										}
 										if buffer[position] != rune('+') {
-											goto l137
+											goto l221
 										}
 										position++
 										if !_rules[ruleWhiteSpacing]() {
-											goto l137
+											goto l221
 										}
 										{
-											position140, tokenIndex140 := position, tokenIndex
+											position224, tokenIndex224 := position, tokenIndex
 											if !_rules[ruleQuotedStringValue]() {
-												goto l141
+												goto l225
 											}
-											goto l140
-										l141:
-											position, tokenIndex = position140, tokenIndex140
+											goto l224
+										l225:
+											position, tokenIndex = position224, tokenIndex224
 											if !_rules[ruleHoleValue]() {
-												goto l137
+												goto l221
 											}
 										}
-									l140:
-										goto l136
-									l137:
-										position, tokenIndex = position137, tokenIndex137
+									l224:
+										goto l220
+									l221:
+										position, tokenIndex = position221, tokenIndex221
 									}
 									{
-										add(ruleAction16, position)
+										add(ruleAction40, position)
 									}
-									goto l133
-								l134:
-									position, tokenIndex = position133, tokenIndex133
+									goto l217
+								l218:
+									position, tokenIndex = position217, tokenIndex217
 									{
-										add(ruleAction17, position)
+										add(ruleAction41, position)
 									}
 									if !_rules[ruleQuotedStringValue]() {
-										goto l131
+										goto l215
 									}
 									if !_rules[ruleWhiteSpacing]() {
-										goto l131
+										goto l215
 									}
 									if buffer[position] != rune('+') {
-										goto l131
+										goto l215
 									}
 									position++
 									if !_rules[ruleWhiteSpacing]() {
-										goto l131
+										goto l215
 									}
 									{
-										position146, tokenIndex146 := position, tokenIndex
+										position230, tokenIndex230 := position, tokenIndex
 										if !_rules[ruleQuotedStringValue]() {
-											goto l147
+											goto l231
 										}
-										goto l146
-									l147:
-										position, tokenIndex = position146, tokenIndex146
+										goto l230
+									l231:
+										position, tokenIndex = position230, tokenIndex230
 										if !_rules[ruleHoleValue]() {
-											goto l131
+											goto l215
 										}
 									}
-								l146:
-								l144:
+								l230:
+								l228:
 									{
-										position145, tokenIndex145 := position, tokenIndex
+										position229, tokenIndex229 := position, tokenIndex
 										if !_rules[ruleWhiteSpacing]() {
-											goto l145
+											goto l229
 										}
 										if buffer[position] != rune('+') {
-											goto l145
+											goto l229
 										}
 										position++
 										if !_rules[ruleWhiteSpacing]() {
-											goto l145
+											goto l229
 										}
 										{
-											position148, tokenIndex148 := position, tokenIndex
+											position232, tokenIndex232 := position, tokenIndex
 											if !_rules[ruleQuotedStringValue]() {
-												goto l149
+												goto l233
 											}
-											goto l148
-										l149:
-											position, tokenIndex = position148, tokenIndex148
+											goto l232
+										l233:
+											position, tokenIndex = position232, tokenIndex232
 											if !_rules[ruleHoleValue]() {
-												goto l145
+												goto l229
 											}
 										}
-									l148:
-										goto l144
-									l145:
-										position, tokenIndex = position145, tokenIndex145
+									l232:
+										goto l228
+									l229:
+										position, tokenIndex = position229, tokenIndex229
 									}
 									{
-										add(ruleAction18, position)
+										add(ruleAction42, position)
 									}
 								}
-							l133:
-								add(ruleConcatenationValue, position132)
+							l217:
+								add(ruleConcatenationValue, position216)
 							}
-							goto l130
-						l131:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l215:
+							position, tokenIndex = position201, tokenIndex201
 							{
-								position152 := position
+								position236 := position
 								{
-									add(ruleAction23, position)
+									add(ruleAction47, position)
 								}
 								{
-									position154 := position
+									position238 := position
 									if !_rules[ruleHoleValue]() {
-										goto l151
+										goto l235
 									}
 									if !_rules[ruleUnquotedParamValue]() {
-										goto l151
+										goto l235
 									}
-								l155:
+								l239:
 									{
-										position156, tokenIndex156 := position, tokenIndex
+										position240, tokenIndex240 := position, tokenIndex
 										if !_rules[ruleUnquotedParamValue]() {
-											goto l156
+											goto l240
 										}
-										goto l155
-									l156:
-										position, tokenIndex = position156, tokenIndex156
+										goto l239
+									l240:
+										position, tokenIndex = position240, tokenIndex240
 									}
-								l157:
+								l241:
 									{
-										position158, tokenIndex158 := position, tokenIndex
+										position242, tokenIndex242 := position, tokenIndex
 										{
-											position159, tokenIndex159 := position, tokenIndex
+											position243, tokenIndex243 := position, tokenIndex
 											if !_rules[ruleUnquotedParamValue]() {
-												goto l159
+												goto l243
 											}
-											goto l160
-										l159:
-											position, tokenIndex = position159, tokenIndex159
+											goto l244
+										l243:
+											position, tokenIndex = position243, tokenIndex243
 										}
-									l160:
+									l244:
 										if !_rules[ruleHoleValue]() {
-											goto l158
+											goto l242
 										}
 										{
-											position161, tokenIndex161 := position, tokenIndex
+											position245, tokenIndex245 := position, tokenIndex
 											if !_rules[ruleUnquotedParamValue]() {
-												goto l161
+												goto l245
 											}
-											goto l162
-										l161:
-											position, tokenIndex = position161, tokenIndex161
+											goto l246
+										l245:
+											position, tokenIndex = position245, tokenIndex245
 										}
-									l162:
-										goto l157
-									l158:
-										position, tokenIndex = position158, tokenIndex158
+									l246:
+										goto l241
+									l242:
+										position, tokenIndex = position242, tokenIndex242
 									}
-									add(rulePegText, position154)
+									add(rulePegText, position238)
 								}
 								{
-									add(ruleAction24, position)
+									add(ruleAction48, position)
 								}
-								add(ruleHoleWithSuffixValue, position152)
+								add(ruleHoleWithSuffixValue, position236)
 							}
-							goto l130
-						l151:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l235:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleHoleValue]() {
-								goto l164
+								goto l248
 							}
-							goto l130
-						l164:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l248:
+							position, tokenIndex = position201, tokenIndex201
 							{
-								position166 := position
+								position250 := position
 								{
-									add(ruleAction21, position)
+									add(ruleAction45, position)
 								}
 								{
-									position168 := position
+									position252 := position
 									{
-										position171, tokenIndex171 := position, tokenIndex
+										position255, tokenIndex255 := position, tokenIndex
 										if !_rules[ruleUnquotedParamValue]() {
-											goto l171
+											goto l255
 										}
-										goto l172
-									l171:
-										position, tokenIndex = position171, tokenIndex171
+										goto l256
+									l255:
+										position, tokenIndex = position255, tokenIndex255
 									}
-								l172:
+								l256:
 									if !_rules[ruleHoleValue]() {
-										goto l165
+										goto l249
 									}
 									{
-										position173, tokenIndex173 := position, tokenIndex
+										position257, tokenIndex257 := position, tokenIndex
 										if !_rules[ruleUnquotedParamValue]() {
-											goto l173
+											goto l257
 										}
-										goto l174
-									l173:
-										position, tokenIndex = position173, tokenIndex173
+										goto l258
+									l257:
+										position, tokenIndex = position257, tokenIndex257
 									}
-								l174:
-								l169:
+								l258:
+								l253:
 									{
-										position170, tokenIndex170 := position, tokenIndex
+										position254, tokenIndex254 := position, tokenIndex
 										{
-											position175, tokenIndex175 := position, tokenIndex
+											position259, tokenIndex259 := position, tokenIndex
 											if !_rules[ruleUnquotedParamValue]() {
-												goto l175
+												goto l259
 											}
-											goto l176
-										l175:
-											position, tokenIndex = position175, tokenIndex175
+											goto l260
+										l259:
+											position, tokenIndex = position259, tokenIndex259
 										}
-									l176:
+									l260:
 										if !_rules[ruleHoleValue]() {
-											goto l170
+											goto l254
 										}
 										{
-											position177, tokenIndex177 := position, tokenIndex
+											position261, tokenIndex261 := position, tokenIndex
 											if !_rules[ruleUnquotedParamValue]() {
-												goto l177
+												goto l261
 											}
-											goto l178
-										l177:
-											position, tokenIndex = position177, tokenIndex177
+											goto l262
+										l261:
+											position, tokenIndex = position261, tokenIndex261
 										}
-									l178:
-										goto l169
-									l170:
-										position, tokenIndex = position170, tokenIndex170
+									l262:
+										goto l253
+									l254:
+										position, tokenIndex = position254, tokenIndex254
 									}
-									add(rulePegText, position168)
+									add(rulePegText, position252)
 								}
 								{
-									add(ruleAction22, position)
+									add(ruleAction46, position)
 								}
-								add(ruleHolesStringValue, position166)
+								add(ruleHolesStringValue, position250)
 							}
-							goto l130
-						l165:
-							position, tokenIndex = position130, tokenIndex130
-							{
-								position181 := position
-								{
-									position182, tokenIndex182 := position, tokenIndex
-									if buffer[position] != rune('@') {
-										goto l183
-									}
-									position++
-									{
-										position184 := position
-										if !_rules[ruleUnquotedParam]() {
-											goto l183
-										}
-										add(rulePegText, position184)
-									}
-									goto l182
-								l183:
-									position, tokenIndex = position182, tokenIndex182
-									if buffer[position] != rune('@') {
-										goto l185
-									}
-									position++
-									if !_rules[ruleDoubleQuotedValue]() {
-										goto l185
-									}
-									goto l182
-								l185:
-									position, tokenIndex = position182, tokenIndex182
-									if buffer[position] != rune('@') {
-										goto l180
-									}
-									position++
-									if !_rules[ruleSingleQuotedValue]() {
-										goto l180
-									}
-								}
-							l182:
-								add(ruleAliasValue, position181)
+							goto l201
+						l249:
+							position, tokenIndex = position201, tokenIndex201
+							if !_rules[ruleAliasValue]() {
+								goto l264
 							}
 							{
-								add(ruleAction11, position)
+								add(ruleAction33, position)
 							}
-							goto l130
-						l180:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l264:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleDoubleQuote]() {
-								goto l187
+								goto l266
 							}
 							if !_rules[ruleCustomTypedValue]() {
-								goto l187
+								goto l266
 							}
 							if !_rules[ruleDoubleQuote]() {
-								goto l187
+								goto l266
 							}
-							goto l130
-						l187:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l266:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleSingleQuote]() {
-								goto l188
+								goto l267
 							}
 							if !_rules[ruleCustomTypedValue]() {
-								goto l188
+								goto l267
 							}
 							if !_rules[ruleSingleQuote]() {
-								goto l188
+								goto l267
 							}
-							goto l130
-						l188:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l267:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleCustomTypedValue]() {
-								goto l189
+								goto l268
 							}
-							goto l130
-						l189:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l268:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleQuotedStringValue]() {
-								goto l190
+								goto l269
 							}
-							goto l130
-						l190:
-							position, tokenIndex = position130, tokenIndex130
+							goto l201
+						l269:
+							position, tokenIndex = position201, tokenIndex201
 							if !_rules[ruleUnquotedParamValue]() {
-								goto l122
+								goto l193
 							}
 						}
-					l130:
-						add(ruleNoRefValue, position129)
+					l201:
+						add(ruleNoRefValue, position200)
+					}
+				}
+			l195:
+				add(ruleValue, position194)
+			}
+			return true
+		l193:
+			position, tokenIndex = position193, tokenIndex193
+			return false
+		},
+		/* 22 FunctionValue <- <(<([a-z] ([a-z] / [0-9])*)> Action35 '(' WhiteSpacing (FunctionArg WhiteSpacing (',' WhiteSpacing FunctionArg WhiteSpacing)*)? ')' Action36)> */
+		nil,
+		/* 23 FunctionArg <- <(ListValue / Value)> */
+		func() bool {
+			position271, tokenIndex271 := position, tokenIndex
+			{
+				position272 := position
+				{
+					position273, tokenIndex273 := position, tokenIndex
+					if !_rules[ruleListValue]() {
+						goto l274
+					}
+					goto l273
+				l274:
+					position, tokenIndex = position273, tokenIndex273
+					if !_rules[ruleValue]() {
+						goto l271
 					}
 				}
-			l124:
-				add(ruleValue, position123)
+			l273:
+				add(ruleFunctionArg, position272)
 			}
 			return true
-		l122:
-			position, tokenIndex = position122, tokenIndex122
+		l271:
+			position, tokenIndex = position271, tokenIndex271
 			return false
 		},
-		/* 15 CustomTypedValue <- <(<IntRangeValue> Action13)> */
+		/* 24 CustomTypedValue <- <(<IntRangeValue> Action37)> */
 		func() bool {
-			position191, tokenIndex191 := position, tokenIndex
+			position275, tokenIndex275 := position, tokenIndex
 			{
-				position192 := position
+				position276 := position
 				{
-					position193 := position
+					position277 := position
 					{
-						position194 := position
+						position278 := position
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l191
+							goto l275
 						}
 						position++
-					l195:
+					l279:
 						{
-							position196, tokenIndex196 := position, tokenIndex
+							position280, tokenIndex280 := position, tokenIndex
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l196
+								goto l280
 							}
 							position++
-							goto l195
-						l196:
-							position, tokenIndex = position196, tokenIndex196
+							goto l279
+						l280:
+							position, tokenIndex = position280, tokenIndex280
 						}
 						if buffer[position] != rune('-') {
-							goto l191
+							goto l275
 						}
 						position++
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l191
+							goto l275
 						}
 						position++
-					l197:
+					l281:
 						{
-							position198, tokenIndex198 := position, tokenIndex
+							position282, tokenIndex282 := position, tokenIndex
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l198
+								goto l282
 							}
 							position++
-							goto l197
-						l198:
-							position, tokenIndex = position198, tokenIndex198
+							goto l281
+						l282:
+							position, tokenIndex = position282, tokenIndex282
 						}
-						add(ruleIntRangeValue, position194)
+						add(ruleIntRangeValue, position278)
 					}
-					add(rulePegText, position193)
+					add(rulePegText, position277)
 				}
 				{
-					add(ruleAction13, position)
+					add(ruleAction37, position)
 				}
-				add(ruleCustomTypedValue, position192)
+				add(ruleCustomTypedValue, position276)
 			}
 			return true
-		l191:
-			position, tokenIndex = position191, tokenIndex191
+		l275:
+			position, tokenIndex = position275, tokenIndex275
 			return false
 		},
-		/* 16 UnquotedParamValue <- <(<UnquotedParam> Action14)> */
+		/* 25 UnquotedParamValue <- <(<UnquotedParam> Action38)> */
 		func() bool {
-			position200, tokenIndex200 := position, tokenIndex
+			position284, tokenIndex284 := position, tokenIndex
 			{
-				position201 := position
+				position285 := position
 				{
-					position202 := position
+					position286 := position
 					if !_rules[ruleUnquotedParam]() {
-						goto l200
+						goto l284
 					}
-					add(rulePegText, position202)
+					add(rulePegText, position286)
 				}
 				{
-					add(ruleAction14, position)
+					add(ruleAction38, position)
 				}
-				add(ruleUnquotedParamValue, position201)
+				add(ruleUnquotedParamValue, position285)
 			}
 			return true
-		l200:
-			position, tokenIndex = position200, tokenIndex200
+		l284:
+			position, tokenIndex = position284, tokenIndex284
 			return false
 		},
-		/* 17 UnquotedParam <- <((&('*') '*') | (&('>') '>') | (&('<') '<') | (&('@') '@') | (&('~') '~') | (&(';') ';') | (&('+') '+') | (&('/') '/') | (&(':') ':') | (&('_') '_') | (&('.') '.') | (&('-') '-') | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+> */
+		/* 26 UnquotedParam <- <((&('*') '*') | (&('>') '>') | (&('<') '<') | (&('@') '@') | (&('~') '~') | (&(';') ';') | (&('+') '+') | (&('/') '/') | (&(':') ':') | (&('_') '_') | (&('.') '.') | (&('-') '-') | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+> */
 		func() bool {
-			position204, tokenIndex204 := position, tokenIndex
+			position288, tokenIndex288 := position, tokenIndex
 			{
-				position205 := position
+				position289 := position
 				{
 					switch buffer[position] {
 					case '*':
 						if buffer[position] != rune('*') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '>':
 						if buffer[position] != rune('>') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '<':
 						if buffer[position] != rune('<') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '@':
 						if buffer[position] != rune('@') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '~':
 						if buffer[position] != rune('~') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case ';':
 						if buffer[position] != rune(';') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '+':
 						if buffer[position] != rune('+') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '/':
 						if buffer[position] != rune('/') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case ':':
 						if buffer[position] != rune(':') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '_':
 						if buffer[position] != rune('_') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '.':
 						if buffer[position] != rune('.') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '-':
 						if buffer[position] != rune('-') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 						if c := buffer[position]; c < rune('A') || c > rune('Z') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					default:
 						if c := buffer[position]; c < rune('a') || c > rune('z') {
-							goto l204
+							goto l288
 						}
 						position++
 						break
 					}
 				}
 
-			l206:
+			l290:
 				{
-					position207, tokenIndex207 := position, tokenIndex
+					position291, tokenIndex291 := position, tokenIndex
 					{
 						switch buffer[position] {
 						case '*':
 							if buffer[position] != rune('*') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '>':
 							if buffer[position] != rune('>') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '<':
 							if buffer[position] != rune('<') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '@':
 							if buffer[position] != rune('@') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '~':
 							if buffer[position] != rune('~') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case ';':
 							if buffer[position] != rune(';') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '+':
 							if buffer[position] != rune('+') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '/':
 							if buffer[position] != rune('/') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case ':':
 							if buffer[position] != rune(':') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '_':
 							if buffer[position] != rune('_') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '.':
 							if buffer[position] != rune('.') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '-':
 							if buffer[position] != rune('-') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 							if c := buffer[position]; c < rune('A') || c > rune('Z') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						default:
 							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l207
+								goto l291
 							}
 							position++
 							break
 						}
 					}
 
-					goto l206
-				l207:
-					position, tokenIndex = position207, tokenIndex207
+					goto l290
+				l291:
+					position, tokenIndex = position291, tokenIndex291
 				}
-				add(ruleUnquotedParam, position205)
+				add(ruleUnquotedParam, position289)
 			}
 			return true
-		l204:
-			position, tokenIndex = position204, tokenIndex204
+		l288:
+			position, tokenIndex = position288, tokenIndex288
 			return false
 		},
-		/* 18 ConcatenationValue <- <((Action15 HoleValue (WhiteSpacing '+' WhiteSpacing (QuotedStringValue / HoleValue))+ Action16) / (Action17 QuotedStringValue (WhiteSpacing '+' WhiteSpacing (QuotedStringValue / HoleValue))+ Action18))> */
+		/* 27 ConcatenationValue <- <((Action39 HoleValue (WhiteSpacing '+' WhiteSpacing (QuotedStringValue / HoleValue))+ Action40) / (Action41 QuotedStringValue (WhiteSpacing '+' WhiteSpacing (QuotedStringValue / HoleValue))+ Action42))> */
 		nil,
-		/* 19 QuotedStringValue <- <(QuotedString Action19)> */
+		/* 28 QuotedStringValue <- <(QuotedString Action43)> */
 		func() bool {
-			position211, tokenIndex211 := position, tokenIndex
+			position295, tokenIndex295 := position, tokenIndex
 			{
-				position212 := position
+				position296 := position
+				if !_rules[ruleQuotedString]() {
+					goto l295
+				}
 				{
-					position213 := position
-					{
-						position214, tokenIndex214 := position, tokenIndex
-						if !_rules[ruleDoubleQuotedValue]() {
-							goto l215
-						}
-						goto l214
-					l215:
-						position, tokenIndex = position214, tokenIndex214
-						if !_rules[ruleSingleQuotedValue]() {
-							goto l211
-						}
-					}
-				l214:
-					add(ruleQuotedString, position213)
+					add(ruleAction43, position)
 				}
+				add(ruleQuotedStringValue, position296)
+			}
+			return true
+		l295:
+			position, tokenIndex = position295, tokenIndex295
+			return false
+		},
+		/* 29 QuotedString <- <(DoubleQuotedValue / SingleQuotedValue)> */
+		func() bool {
+			position298, tokenIndex298 := position, tokenIndex
+			{
+				position299 := position
 				{
-					add(ruleAction19, position)
+					position300, tokenIndex300 := position, tokenIndex
+					if !_rules[ruleDoubleQuotedValue]() {
+						goto l301
+					}
+					goto l300
+				l301:
+					position, tokenIndex = position300, tokenIndex300
+					if !_rules[ruleSingleQuotedValue]() {
+						goto l298
+					}
 				}
-				add(ruleQuotedStringValue, position212)
+			l300:
+				add(ruleQuotedString, position299)
 			}
 			return true
-		l211:
-			position, tokenIndex = position211, tokenIndex211
+		l298:
+			position, tokenIndex = position298, tokenIndex298
 			return false
 		},
-		/* 20 QuotedString <- <(DoubleQuotedValue / SingleQuotedValue)> */
-		nil,
-		/* 21 DoubleQuotedValue <- <(DoubleQuote <(!'"' .)*> DoubleQuote)> */
+		/* 30 DoubleQuotedValue <- <(DoubleQuote <(!'"' .)*> DoubleQuote)> */
 		func() bool {
-			position218, tokenIndex218 := position, tokenIndex
+			position302, tokenIndex302 := position, tokenIndex
 			{
-				position219 := position
+				position303 := position
 				if !_rules[ruleDoubleQuote]() {
-					goto l218
+					goto l302
 				}
 				{
-					position220 := position
-				l221:
+					position304 := position
+				l305:
 					{
-						position222, tokenIndex222 := position, tokenIndex
+						position306, tokenIndex306 := position, tokenIndex
 						{
-							position223, tokenIndex223 := position, tokenIndex
+							position307, tokenIndex307 := position, tokenIndex
 							if buffer[position] != rune('"') {
-								goto l223
+								goto l307
 							}
 							position++
-							goto l222
-						l223:
-							position, tokenIndex = position223, tokenIndex223
+							goto l306
+						l307:
+							position, tokenIndex = position307, tokenIndex307
 						}
 						if !matchDot() {
-							goto l222
+							goto l306
 						}
-						goto l221
-					l222:
-						position, tokenIndex = position222, tokenIndex222
+						goto l305
+					l306:
+						position, tokenIndex = position306, tokenIndex306
 					}
-					add(rulePegText, position220)
+					add(rulePegText, position304)
 				}
 				if !_rules[ruleDoubleQuote]() {
-					goto l218
+					goto l302
 				}
-				add(ruleDoubleQuotedValue, position219)
+				add(ruleDoubleQuotedValue, position303)
 			}
 			return true
-		l218:
-			position, tokenIndex = position218, tokenIndex218
+		l302:
+			position, tokenIndex = position302, tokenIndex302
 			return false
 		},
-		/* 22 SingleQuotedValue <- <(SingleQuote <(!'\'' .)*> SingleQuote)> */
+		/* 31 SingleQuotedValue <- <(SingleQuote <(!'\'' .)*> SingleQuote)> */
 		func() bool {
-			position224, tokenIndex224 := position, tokenIndex
+			position308, tokenIndex308 := position, tokenIndex
 			{
-				position225 := position
+				position309 := position
 				if !_rules[ruleSingleQuote]() {
-					goto l224
+					goto l308
 				}
 				{
-					position226 := position
-				l227:
+					position310 := position
+				l311:
 					{
-						position228, tokenIndex228 := position, tokenIndex
+						position312, tokenIndex312 := position, tokenIndex
 						{
-							position229, tokenIndex229 := position, tokenIndex
+							position313, tokenIndex313 := position, tokenIndex
 							if buffer[position] != rune('\'') {
-								goto l229
+								goto l313
 							}
 							position++
-							goto l228
-						l229:
-							position, tokenIndex = position229, tokenIndex229
+							goto l312
+						l313:
+							position, tokenIndex = position313, tokenIndex313
 						}
 						if !matchDot() {
-							goto l228
+							goto l312
 						}
-						goto l227
-					l228:
-						position, tokenIndex = position228, tokenIndex228
+						goto l311
+					l312:
+						position, tokenIndex = position312, tokenIndex312
 					}
-					add(rulePegText, position226)
+					add(rulePegText, position310)
 				}
 				if !_rules[ruleSingleQuote]() {
-					goto l224
+					goto l308
 				}
-				add(ruleSingleQuotedValue, position225)
+				add(ruleSingleQuotedValue, position309)
 			}
 			return true
-		l224:
-			position, tokenIndex = position224, tokenIndex224
+		l308:
+			position, tokenIndex = position308, tokenIndex308
 			return false
 		},
-		/* 23 IntRangeValue <- <([0-9]+ '-' [0-9]+)> */
-		nil,
-		/* 24 RefValue <- <('$' <Identifier>)> */
+		/* 32 IntRangeValue <- <([0-9]+ '-' [0-9]+)> */
 		nil,
-		/* 25 AliasValue <- <(('@' <UnquotedParam>) / ('@' DoubleQuotedValue) / ('@' SingleQuotedValue))> */
+		/* 33 RefValue <- <('$' <Identifier>)> */
 		nil,
-		/* 26 HoleValue <- <(Hole Action20)> */
+		/* 34 AliasValue <- <(('@' <UnquotedParam>) / ('@' DoubleQuotedValue) / ('@' SingleQuotedValue))> */
+		func() bool {
+			position316, tokenIndex316 := position, tokenIndex
+			{
+				position317 := position
+				{
+					position318, tokenIndex318 := position, tokenIndex
+					if buffer[position] != rune('@') {
+						goto l319
+					}
+					position++
+					{
+						position320 := position
+						if !_rules[ruleUnquotedParam]() {
+							goto l319
+						}
+						add(rulePegText, position320)
+					}
+					goto l318
+				l319:
+					position, tokenIndex = position318, tokenIndex318
+					if buffer[position] != rune('@') {
+						goto l321
+					}
+					position++
+					if !_rules[ruleDoubleQuotedValue]() {
+						goto l321
+					}
+					goto l318
+				l321:
+					position, tokenIndex = position318, tokenIndex318
+					if buffer[position] != rune('@') {
+						goto l316
+					}
+					position++
+					if !_rules[ruleSingleQuotedValue]() {
+						goto l316
+					}
+				}
+			l318:
+				add(ruleAliasValue, position317)
+			}
+			return true
+		l316:
+			position, tokenIndex = position316, tokenIndex316
+			return false
+		},
+		/* 35 HoleValue <- <(Hole Action44)> */
 		func() bool {
-			position233, tokenIndex233 := position, tokenIndex
+			position322, tokenIndex322 := position, tokenIndex
 			{
-				position234 := position
+				position323 := position
 				{
-					position235 := position
+					position324 := position
 					if buffer[position] != rune('{') {
-						goto l233
+						goto l322
 					}
 					position++
 					if !_rules[ruleWhiteSpacing]() {
-						goto l233
+						goto l322
 					}
 					{
-						position236 := position
+						position325 := position
 						if !_rules[ruleIdentifier]() {
-							goto l233
+							goto l322
 						}
-						add(rulePegText, position236)
+						add(rulePegText, position325)
 					}
 					if !_rules[ruleWhiteSpacing]() {
-						goto l233
+						goto l322
 					}
 					if buffer[position] != rune('}') {
-						goto l233
+						goto l322
 					}
 					position++
-					add(ruleHole, position235)
+					add(ruleHole, position324)
 				}
 				{
-					add(ruleAction20, position)
+					add(ruleAction44, position)
 				}
-				add(ruleHoleValue, position234)
+				add(ruleHoleValue, position323)
 			}
 			return true
-		l233:
-			position, tokenIndex = position233, tokenIndex233
+		l322:
+			position, tokenIndex = position322, tokenIndex322
 			return false
 		},
-		/* 27 Hole <- <('{' WhiteSpacing <Identifier> WhiteSpacing '}')> */
+		/* 36 Hole <- <('{' WhiteSpacing <Identifier> WhiteSpacing '}')> */
 		nil,
-		/* 28 HolesStringValue <- <(Action21 <(UnquotedParamValue? HoleValue UnquotedParamValue?)+> Action22)> */
+		/* 37 HolesStringValue <- <(Action45 <(UnquotedParamValue? HoleValue UnquotedParamValue?)+> Action46)> */
 		nil,
-		/* 29 HoleWithSuffixValue <- <(Action23 <(HoleValue UnquotedParamValue+ (UnquotedParamValue? HoleValue UnquotedParamValue?)*)> Action24)> */
+		/* 38 HoleWithSuffixValue <- <(Action47 <(HoleValue UnquotedParamValue+ (UnquotedParamValue? HoleValue UnquotedParamValue?)*)> Action48)> */
 		nil,
-		/* 30 Comment <- <(('#' (!EndOfLine .)*) / ('/' '/' (!EndOfLine .)*))> */
+		/* 39 Comment <- <(<(('#' (!EndOfLine .)*) / ('/' '/' (!EndOfLine .)*))> Action49)> */
 		nil,
-		/* 31 SingleQuote <- <'\''> */
+		/* 40 SingleQuote <- <'\''> */
 		func() bool {
-			position242, tokenIndex242 := position, tokenIndex
+			position331, tokenIndex331 := position, tokenIndex
 			{
-				position243 := position
+				position332 := position
 				if buffer[position] != rune('\'') {
-					goto l242
+					goto l331
 				}
 				position++
-				add(ruleSingleQuote, position243)
+				add(ruleSingleQuote, position332)
 			}
 			return true
-		l242:
-			position, tokenIndex = position242, tokenIndex242
+		l331:
+			position, tokenIndex = position331, tokenIndex331
 			return false
 		},
-		/* 32 DoubleQuote <- <'"'> */
+		/* 41 DoubleQuote <- <'"'> */
 		func() bool {
-			position244, tokenIndex244 := position, tokenIndex
+			position333, tokenIndex333 := position, tokenIndex
 			{
-				position245 := position
+				position334 := position
 				if buffer[position] != rune('"') {
-					goto l244
+					goto l333
 				}
 				position++
-				add(ruleDoubleQuote, position245)
+				add(ruleDoubleQuote, position334)
 			}
 			return true
-		l244:
-			position, tokenIndex = position244, tokenIndex244
+		l333:
+			position, tokenIndex = position333, tokenIndex333
 			return false
 		},
-		/* 33 WhiteSpacing <- <Whitespace*> */
+		/* 42 WhiteSpacing <- <Whitespace*> */
 		func() bool {
 			{
-				position247 := position
-			l248:
+				position336 := position
+			l337:
 				{
-					position249, tokenIndex249 := position, tokenIndex
+					position338, tokenIndex338 := position, tokenIndex
 					if !_rules[ruleWhitespace]() {
-						goto l249
+						goto l338
 					}
-					goto l248
-				l249:
-					position, tokenIndex = position249, tokenIndex249
+					goto l337
+				l338:
+					position, tokenIndex = position338, tokenIndex338
 				}
-				add(ruleWhiteSpacing, position247)
+				add(ruleWhiteSpacing, position336)
 			}
 			return true
 		},
-		/* 34 MustWhiteSpacing <- <Whitespace+> */
+		/* 43 MustWhiteSpacing <- <Whitespace+> */
 		func() bool {
-			position250, tokenIndex250 := position, tokenIndex
+			position339, tokenIndex339 := position, tokenIndex
 			{
-				position251 := position
+				position340 := position
 				if !_rules[ruleWhitespace]() {
-					goto l250
+					goto l339
 				}
-			l252:
+			l341:
 				{
-					position253, tokenIndex253 := position, tokenIndex
+					position342, tokenIndex342 := position, tokenIndex
 					if !_rules[ruleWhitespace]() {
-						goto l253
+						goto l342
 					}
-					goto l252
-				l253:
-					position, tokenIndex = position253, tokenIndex253
+					goto l341
+				l342:
+					position, tokenIndex = position342, tokenIndex342
 				}
-				add(ruleMustWhiteSpacing, position251)
+				add(ruleMustWhiteSpacing, position340)
 			}
 			return true
-		l250:
-			position, tokenIndex = position250, tokenIndex250
+		l339:
+			position, tokenIndex = position339, tokenIndex339
 			return false
 		},
-		/* 35 Equal <- <(WhiteSpacing '=' WhiteSpacing)> */
+		/* 44 Equal <- <(WhiteSpacing '=' WhiteSpacing)> */
 		func() bool {
-			position254, tokenIndex254 := position, tokenIndex
+			position343, tokenIndex343 := position, tokenIndex
 			{
-				position255 := position
+				position344 := position
 				if !_rules[ruleWhiteSpacing]() {
-					goto l254
+					goto l343
 				}
 				if buffer[position] != rune('=') {
-					goto l254
+					goto l343
 				}
 				position++
 				if !_rules[ruleWhiteSpacing]() {
-					goto l254
+					goto l343
 				}
-				add(ruleEqual, position255)
+				add(ruleEqual, position344)
 			}
 			return true
-		l254:
-			position, tokenIndex = position254, tokenIndex254
+		l343:
+			position, tokenIndex = position343, tokenIndex343
 			return false
 		},
-		/* 36 BlankLine <- <(WhiteSpacing EndOfLine)> */
+		/* 45 BlankLine <- <(WhiteSpacing EndOfLine)> */
 		func() bool {
-			position256, tokenIndex256 := position, tokenIndex
+			position345, tokenIndex345 := position, tokenIndex
 			{
-				position257 := position
+				position346 := position
 				if !_rules[ruleWhiteSpacing]() {
-					goto l256
+					goto l345
 				}
 				if !_rules[ruleEndOfLine]() {
-					goto l256
+					goto l345
 				}
-				add(ruleBlankLine, position257)
+				add(ruleBlankLine, position346)
 			}
 			return true
-		l256:
-			position, tokenIndex = position256, tokenIndex256
+		l345:
+			position, tokenIndex = position345, tokenIndex345
 			return false
 		},
-		/* 37 Whitespace <- <(' ' / '\t')> */
+		/* 46 Whitespace <- <(' ' / '\t')> */
 		func() bool {
-			position258, tokenIndex258 := position, tokenIndex
+			position347, tokenIndex347 := position, tokenIndex
 			{
-				position259 := position
+				position348 := position
 				{
-					position260, tokenIndex260 := position, tokenIndex
+					position349, tokenIndex349 := position, tokenIndex
 					if buffer[position] != rune(' ') {
-						goto l261
+						goto l350
 					}
 					position++
-					goto l260
-				l261:
-					position, tokenIndex = position260, tokenIndex260
+					goto l349
+				l350:
+					position, tokenIndex = position349, tokenIndex349
 					if buffer[position] != rune('\t') {
-						goto l258
+						goto l347
 					}
 					position++
 				}
-			l260:
-				add(ruleWhitespace, position259)
+			l349:
+				add(ruleWhitespace, position348)
 			}
 			return true
-		l258:
-			position, tokenIndex = position258, tokenIndex258
+		l347:
+			position, tokenIndex = position347, tokenIndex347
 			return false
 		},
-		/* 38 EndOfLine <- <(('\r' '\n') / '\n' / '\r')> */
+		/* 47 EndOfLine <- <(('\r' '\n') / '\n' / '\r')> */
 		func() bool {
-			position262, tokenIndex262 := position, tokenIndex
+			position351, tokenIndex351 := position, tokenIndex
 			{
-				position263 := position
+				position352 := position
 				{
-					position264, tokenIndex264 := position, tokenIndex
+					position353, tokenIndex353 := position, tokenIndex
 					if buffer[position] != rune('\r') {
-						goto l265
+						goto l354
 					}
 					position++
 					if buffer[position] != rune('\n') {
-						goto l265
+						goto l354
 					}
 					position++
-					goto l264
-				l265:
-					position, tokenIndex = position264, tokenIndex264
+					goto l353
+				l354:
+					position, tokenIndex = position353, tokenIndex353
 					if buffer[position] != rune('\n') {
-						goto l266
+						goto l355
 					}
 					position++
-					goto l264
-				l266:
-					position, tokenIndex = position264, tokenIndex264
+					goto l353
+				l355:
+					position, tokenIndex = position353, tokenIndex353
 					if buffer[position] != rune('\r') {
-						goto l262
+						goto l351
 					}
 					position++
 				}
-			l264:
-				add(ruleEndOfLine, position263)
+			l353:
+				add(ruleEndOfLine, position352)
 			}
 			return true
-		l262:
-			position, tokenIndex = position262, tokenIndex262
+		l351:
+			position, tokenIndex = position351, tokenIndex351
 			return false
 		},
-		/* 39 EndOfFile <- <!.> */
+		/* 48 EndOfFile <- <!.> */
+		nil,
+		/* 50 Action0 <- <{ p.NewStatement() }> */
+		nil,
+		nil,
+		/* 52 Action1 <- <{ p.markStatementPosition(end) }> */
+		nil,
+		/* 53 Action2 <- <{ p.markStatementEnd(begin) }> */
+		nil,
+		/* 54 Action3 <- <{ p.StatementDone() }> */
+		nil,
+		/* 55 Action4 <- <{ p.addDeclarationIdentifier(text) }> */
+		nil,
+		/* 56 Action5 <- <{ p.addValue() }> */
+		nil,
+		/* 57 Action6 <- <{ p.addForIdentifier(text) }> */
+		nil,
+		/* 58 Action7 <- <{ p.startForBody() }> */
+		nil,
+		/* 59 Action8 <- <{ p.endForBody() }> */
+		nil,
+		/* 60 Action9 <- <{ p.addIfKeyword(text) }> */
+		nil,
+		/* 61 Action10 <- <{ p.startIfBody() }> */
+		nil,
+		/* 62 Action11 <- <{ p.endIfBody() }> */
+		nil,
+		/* 63 Action12 <- <{ p.addIncludePath(text) }> */
+		nil,
+		/* 64 Action13 <- <{ p.addOutputIdentifier(text) }> */
+		nil,
+		/* 65 Action14 <- <{ p.addParamName(text) }> */
+		nil,
+		/* 66 Action15 <- <{ p.addParamType(text) }> */
+		nil,
+		/* 67 Action16 <- <{ p.addParamDefault() }> */
+		nil,
+		/* 68 Action17 <- <{ p.addParamEnumValue() }> */
+		nil,
+		/* 69 Action18 <- <{ p.addParamEnumValue() }> */
+		nil,
+		/* 70 Action19 <- <{ p.addParamDescription(text) }> */
+		nil,
+		/* 71 Action20 <- <{ p.addConditionOperator(text) }> */
+		nil,
+		/* 72 Action21 <- <{ p.addConditionEntity(text) }> */
+		nil,
+		/* 73 Action22 <- <{ p.addAliasParam(text) }> */
+		nil,
+		/* 74 Action23 <- <{ p.addConditionOperator(text) }> */
 		nil,
-		/* 41 Action0 <- <{ p.NewStatement() }> */
+		/* 75 Action24 <- <{ p.addAction(text) }> */
 		nil,
-		/* 42 Action1 <- <{ p.StatementDone() }> */
+		/* 76 Action25 <- <{ p.markCommandPosition(begin) }> */
 		nil,
+		/* 77 Action26 <- <{ p.addEntity(text) }> */
 		nil,
-		/* 44 Action2 <- <{ p.addDeclarationIdentifier(text) }> */
+		/* 78 Action27 <- <{ p.addParamKey(text) }> */
 		nil,
-		/* 45 Action3 <- <{ p.addValue() }> */
+		/* 79 Action28 <- <{ p.markParamPosition(begin) }> */
 		nil,
-		/* 46 Action4 <- <{ p.addAction(text) }> */
+		/* 80 Action29 <- <{  p.addFirstValueInList() }> */
 		nil,
-		/* 47 Action5 <- <{ p.addEntity(text) }> */
+		/* 81 Action30 <- <{  p.lastValueInList() }> */
 		nil,
-		/* 48 Action6 <- <{ p.addParamKey(text) }> */
+		/* 82 Action31 <- <{  p.addFirstValueInList() }> */
 		nil,
-		/* 49 Action7 <- <{  p.addFirstValueInList() }> */
+		/* 83 Action32 <- <{  p.lastValueInList() }> */
 		nil,
-		/* 50 Action8 <- <{  p.lastValueInList() }> */
+		/* 84 Action33 <- <{  p.addAliasParam(text) }> */
 		nil,
-		/* 51 Action9 <- <{  p.addFirstValueInList() }> */
+		/* 85 Action34 <- <{  p.addParamRefValue(text) }> */
 		nil,
-		/* 52 Action10 <- <{  p.lastValueInList() }> */
+		/* 86 Action35 <- <{ p.startFunction(text) }> */
 		nil,
-		/* 53 Action11 <- <{  p.addAliasParam(text) }> */
+		/* 87 Action36 <- <{ p.endFunction() }> */
 		nil,
-		/* 54 Action12 <- <{  p.addParamRefValue(text) }> */
+		/* 88 Action37 <- <{ p.addParamValue(text) }> */
 		nil,
-		/* 55 Action13 <- <{ p.addParamValue(text) }> */
+		/* 89 Action38 <- <{ p.addParamValue(text) }> */
 		nil,
-		/* 56 Action14 <- <{ p.addParamValue(text) }> */
+		/* 90 Action39 <- <{ p.addFirstValueInConcatenation() }> */
 		nil,
-		/* 57 Action15 <- <{ p.addFirstValueInConcatenation() }> */
+		/* 91 Action40 <- <{  p.lastValueInConcatenation() }> */
 		nil,
-		/* 58 Action16 <- <{  p.lastValueInConcatenation() }> */
+		/* 92 Action41 <- <{ p.addFirstValueInConcatenation() }> */
 		nil,
-		/* 59 Action17 <- <{ p.addFirstValueInConcatenation() }> */
+		/* 93 Action42 <- <{  p.lastValueInConcatenation() }> */
 		nil,
-		/* 60 Action18 <- <{  p.lastValueInConcatenation() }> */
+		/* 94 Action43 <- <{ p.addStringValue(text) }> */
 		nil,
-		/* 61 Action19 <- <{ p.addStringValue(text) }> */
+		/* 95 Action44 <- <{  p.addParamHoleValue(text) }> */
 		nil,
-		/* 62 Action20 <- <{  p.addParamHoleValue(text) }> */
+		/* 96 Action45 <- <{ p.addFirstValueInConcatenation() }> */
 		nil,
-		/* 63 Action21 <- <{ p.addFirstValueInConcatenation() }> */
+		/* 97 Action46 <- <{  p.lastValueInConcatenation() }> */
 		nil,
-		/* 64 Action22 <- <{  p.lastValueInConcatenation() }> */
+		/* 98 Action47 <- <{ p.addFirstValueInConcatenation() }> */
 		nil,
-		/* 65 Action23 <- <{ p.addFirstValueInConcatenation() }> */
+		/* 99 Action48 <- <{  p.lastValueInConcatenation() }> */
 		nil,
-		/* 66 Action24 <- <{  p.lastValueInConcatenation() }> */
+		/* 100 Action49 <- <{ p.addComment(text) }> */
 		nil,
 	}
 	p.rules = _rules
@@ -2,7 +2,9 @@ package ast
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 type parameter struct {
@@ -20,9 +22,57 @@ type statementBuilder struct {
 	currentValue          CompositeValue
 	listBuilder           *listValueBuilder
 	concatenationBuilder  *concatenationValueBuilder
+	functionBuilders      []*functionValueBuilder
+	forIdentifier         string
+	ifKeyword             string
+	conditionOperator     string
+	conditionEntity       string
+	conditionLeft         CompositeValue
+	includePath           string
+	comment               string
+	outputIdentifier      string
+	paramNode             *ParamNode
+	blockNode             Node
+	pos, end              Position
+	cmdPos                Position
+	currentKeyPos         Position
+	paramsPos             map[string]Position
+}
+
+type blockBuilder struct {
+	node  Node
+	outer *statementBuilder
+}
+
+func (b *blockBuilder) add(stmt *Statement) {
+	switch n := b.node.(type) {
+	case *ForNode:
+		n.Statements = append(n.Statements, stmt)
+	case *IfNode:
+		n.Statements = append(n.Statements, stmt)
+	}
 }
 
 func (b *statementBuilder) build() *Statement {
+	if b.blockNode != nil {
+		return &Statement{Node: b.blockNode}
+	}
+	if b.comment != "" {
+		return &Statement{Node: &CommentNode{Text: b.comment}}
+	}
+	if b.paramNode != nil {
+		return &Statement{Node: b.paramNode}
+	}
+	if b.includePath != "" {
+		includeParams := make(map[string]CompositeValue)
+		for _, param := range b.params {
+			includeParams[param.key] = param.value
+		}
+		return &Statement{Node: &IncludeNode{Path: b.includePath, Params: includeParams}}
+	}
+	if b.outputIdentifier != "" {
+		return &Statement{Node: &OutputNode{Ident: b.outputIdentifier, Value: &ValueNode{Value: b.currentValue}}}
+	}
 	if b.action == "" && b.entity == "" && b.declarationIdentifier == "" && !b.isValue {
 		return nil
 	}
@@ -34,7 +84,7 @@ func (b *statementBuilder) build() *Statement {
 		for _, param := range b.params {
 			cmdParams[param.key] = param.value
 		}
-		expr = &CommandNode{Action: b.action, Entity: b.entity, Params: cmdParams}
+		expr = &CommandNode{Action: b.action, Entity: b.entity, Params: cmdParams, Pos: b.cmdPos, ParamsPos: b.paramsPos}
 	}
 	if b.declarationIdentifier != "" {
 		decl := &DeclarationNode{Ident: b.declarationIdentifier, Expr: expr}
@@ -56,9 +106,18 @@ func (b *statementBuilder) addParamValue(val CompositeValue) *statementBuilder {
 	} else if b.listBuilder != nil {
 		b.listBuilder.add(b.currentValue)
 		b.currentValue = nil
+	} else if len(b.functionBuilders) > 0 {
+		b.functionBuilders[len(b.functionBuilders)-1].add(b.currentValue)
+		b.currentValue = nil
 	} else {
 		if b.currentKey != "" {
 			b.params = append(b.params, &parameter{key: b.currentKey, value: b.currentValue})
+			if b.currentKeyPos.IsValid() {
+				if b.paramsPos == nil {
+					b.paramsPos = make(map[string]Position)
+				}
+				b.paramsPos[b.currentKey] = b.currentKeyPos
+			}
 			b.currentKey = ""
 			b.currentValue = nil
 		}
@@ -103,6 +162,107 @@ func (a *AST) addDeclarationIdentifier(text string) {
 	a.stmtBuilder.declarationIdentifier = text
 }
 
+func (a *AST) addOutputIdentifier(text string) {
+	a.stmtBuilder.outputIdentifier = text
+}
+
+func (a *AST) addParamName(text string) {
+	a.stmtBuilder.paramNode = &ParamNode{Name: text}
+}
+
+func (a *AST) addParamType(text string) {
+	for _, t := range ParamTypes {
+		if t == text {
+			a.stmtBuilder.paramNode.Type = text
+			return
+		}
+	}
+	panic(fmt.Errorf("unknown type '%s' for param '%s', expecting %s", text, a.stmtBuilder.paramNode.Name, strings.Join(ParamTypes, ", ")))
+}
+
+func (a *AST) addParamDefault() {
+	a.stmtBuilder.paramNode.Default = a.stmtBuilder.currentValue
+	a.stmtBuilder.currentValue = nil
+}
+
+func (a *AST) addParamEnumValue() {
+	a.stmtBuilder.paramNode.Enum = append(a.stmtBuilder.paramNode.Enum, a.stmtBuilder.currentValue)
+	a.stmtBuilder.currentValue = nil
+}
+
+func (a *AST) addParamDescription(text string) {
+	a.stmtBuilder.paramNode.Description = text
+}
+
+func (a *AST) addForIdentifier(text string) {
+	a.stmtBuilder.forIdentifier = text
+}
+
+func (a *AST) startForBody() {
+	loop := &ForNode{
+		Ident: a.stmtBuilder.forIdentifier,
+		Items: &ValueNode{Value: a.stmtBuilder.currentValue},
+	}
+	a.startBlock(loop)
+}
+
+func (a *AST) endForBody() {
+	a.endBlock()
+}
+
+func (a *AST) addComment(text string) {
+	a.stmtBuilder.comment = strings.TrimRight(text, " \t")
+}
+
+func (a *AST) addIncludePath(text string) {
+	a.stmtBuilder.includePath = text
+}
+
+func (a *AST) addIfKeyword(text string) {
+	a.stmtBuilder.ifKeyword = text
+}
+
+func (a *AST) addConditionOperator(text string) {
+	a.stmtBuilder.conditionOperator = text
+	a.stmtBuilder.conditionLeft = a.stmtBuilder.currentValue
+	a.stmtBuilder.currentValue = nil
+}
+
+func (a *AST) addConditionEntity(text string) {
+	if IsInvalidEntity(text) {
+		panic(fmt.Errorf("unknown entity '%s'", text))
+	}
+	a.stmtBuilder.conditionEntity = text
+}
+
+func (a *AST) startIfBody() {
+	b := a.stmtBuilder
+	cond := &ConditionNode{Operator: b.conditionOperator, Entity: b.conditionEntity}
+	if b.conditionOperator == ExistsOperator {
+		cond.Left = &ValueNode{Value: b.currentValue}
+	} else {
+		cond.Left = &ValueNode{Value: b.conditionLeft}
+		cond.Right = &ValueNode{Value: b.currentValue}
+	}
+	a.startBlock(&IfNode{Unless: b.ifKeyword == "unless", Condition: cond})
+}
+
+func (a *AST) endIfBody() {
+	a.endBlock()
+}
+
+func (a *AST) startBlock(n Node) {
+	a.blocks = append(a.blocks, &blockBuilder{node: n, outer: a.stmtBuilder})
+	a.stmtBuilder = nil
+}
+
+func (a *AST) endBlock() {
+	last := a.blocks[len(a.blocks)-1]
+	a.blocks = a.blocks[:len(a.blocks)-1]
+	a.stmtBuilder = last.outer
+	a.stmtBuilder.blockNode = last.node
+}
+
 func (a *AST) NewStatement() {
 	a.stmtBuilder = &statementBuilder{}
 }
@@ -110,11 +270,46 @@ func (a *AST) NewStatement() {
 func (a *AST) StatementDone() {
 
 	if stmt := a.stmtBuilder.build(); stmt != nil {
-		a.Statements = append(a.Statements, stmt)
+		stmt.Pos, stmt.End = a.stmtBuilder.pos, a.stmtBuilder.end
+		if len(a.blocks) > 0 {
+			a.blocks[len(a.blocks)-1].add(stmt)
+		} else {
+			a.Statements = append(a.Statements, stmt)
+		}
 	}
 	a.stmtBuilder = nil
 }
 
+func (p *Peg) markStatementPosition(offset int) {
+	p.stmtBuilder.pos = p.position(offset)
+}
+
+// position converts an offset in the parsed buffer to a line and column
+func (p *Peg) position(offset int) Position {
+	if p.lineOffsets == nil {
+		p.lineOffsets = []int{0}
+		for i, c := range p.buffer {
+			if c == '\n' {
+				p.lineOffsets = append(p.lineOffsets, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(p.lineOffsets), func(i int) bool { return p.lineOffsets[i] > offset })
+	return Position{Line: line, Column: offset - p.lineOffsets[line-1] + 1}
+}
+
+func (p *Peg) markStatementEnd(offset int) {
+	p.stmtBuilder.end = p.position(offset)
+}
+
+func (p *Peg) markCommandPosition(offset int) {
+	p.stmtBuilder.cmdPos = p.position(offset)
+}
+
+func (p *Peg) markParamPosition(offset int) {
+	p.stmtBuilder.currentKeyPos = p.position(offset)
+}
+
 func (a *AST) addParamKey(text string) {
 	a.stmtBuilder.addParamKey(text)
 }
@@ -154,6 +349,22 @@ func (a *AST) lastValueInConcatenation() {
 	}
 }
 
+// startFunction stacks a function builder receiving the following values as arguments,
+// the list being built, if any, is restored when the function ends
+func (a *AST) startFunction(name string) {
+	b := a.stmtBuilder
+	b.functionBuilders = append(b.functionBuilders, &functionValueBuilder{name: name, outerList: b.listBuilder})
+	b.listBuilder = nil
+}
+
+func (a *AST) endFunction() {
+	b := a.stmtBuilder
+	last := b.functionBuilders[len(b.functionBuilders)-1]
+	b.functionBuilders = b.functionBuilders[:len(b.functionBuilders)-1]
+	b.listBuilder = last.outerList
+	b.addParamValue(last.build())
+}
+
 func (a *AST) addStringValue(text string) {
 	a.stmtBuilder.addParamValue(&interfaceValue{val: text})
 }
@@ -195,3 +406,18 @@ func (c *concatenationValueBuilder) add(v CompositeValue) *concatenationValueBui
 func (c *concatenationValueBuilder) build() CompositeValue {
 	return &concatenationValue{c.vals}
 }
+
+type functionValueBuilder struct {
+	name      string
+	args      []CompositeValue
+	outerList *listValueBuilder
+}
+
+func (c *functionValueBuilder) add(v CompositeValue) *functionValueBuilder {
+	c.args = append(c.args, v)
+	return c
+}
+
+func (c *functionValueBuilder) build() CompositeValue {
+	return &functionValue{name: c.name, args: c.args}
+}
@@ -0,0 +1,101 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Format prints the statements in canonical form: one statement per line with
+// sorted params, blocks indented with tabs, comments kept in place, at most one
+// blank line where the source had blank lines between statements and the
+// assignments of consecutive declarations aligned
+func (a *AST) Format() string {
+	var buff bytes.Buffer
+	formatStatements(&buff, a.Statements, "")
+	buff.WriteByte('\n')
+	return buff.String()
+}
+
+func formatStatements(w *bytes.Buffer, statements []*Statement, indent string) {
+	widths := declarationsWidths(statements)
+	for i, st := range statements {
+		if i > 0 {
+			if isTrailingComment(statements[i-1], st) {
+				fmt.Fprintf(w, " %s", st)
+				continue
+			}
+			w.WriteByte('\n')
+			if hasBlankLineBetween(statements[i-1], st) {
+				w.WriteByte('\n')
+			}
+		}
+		w.WriteString(indent)
+		switch n := st.Node.(type) {
+		case *ForNode:
+			fmt.Fprintf(w, "for %s in %s {", n.Ident, n.Items)
+			formatBlock(w, n.Statements, indent)
+		case *IfNode:
+			keyword := "if"
+			if n.Unless {
+				keyword = "unless"
+			}
+			fmt.Fprintf(w, "%s %s {", keyword, n.Condition)
+			formatBlock(w, n.Statements, indent)
+		case *DeclarationNode:
+			fmt.Fprintf(w, "%-*s = %s", widths[i], n.Ident, n.Expr)
+		default:
+			w.WriteString(st.String())
+		}
+	}
+}
+
+func formatBlock(w *bytes.Buffer, statements []*Statement, indent string) {
+	if len(statements) > 0 {
+		w.WriteByte('\n')
+		formatStatements(w, statements, indent+"\t")
+	}
+	fmt.Fprintf(w, "\n%s}", indent)
+}
+
+// declarationsWidths returns per statement index the width to which the identifier
+// of a declaration is padded: the widest among the declarations on consecutive lines
+func declarationsWidths(statements []*Statement) map[int]int {
+	widths := make(map[int]int)
+	var group []int
+	flush := func() {
+		var max int
+		for _, i := range group {
+			if l := len(statements[i].Node.(*DeclarationNode).Ident); l > max {
+				max = l
+			}
+		}
+		for _, i := range group {
+			widths[i] = max
+		}
+		group = nil
+	}
+	for i, st := range statements {
+		if i > 0 && isTrailingComment(statements[i-1], st) {
+			continue
+		}
+		if _, isDecl := st.Node.(*DeclarationNode); !isDecl {
+			flush()
+			continue
+		}
+		if i > 0 && hasBlankLineBetween(statements[i-1], st) {
+			flush()
+		}
+		group = append(group, i)
+	}
+	flush()
+	return widths
+}
+
+func isTrailingComment(prev, st *Statement) bool {
+	_, isComment := st.Node.(*CommentNode)
+	return isComment && st.Pos.IsValid() && st.Pos.Line == prev.End.Line
+}
+
+func hasBlankLineBetween(prev, st *Statement) bool {
+	return prev.End.IsValid() && st.Pos.Line > prev.End.Line+1
+}
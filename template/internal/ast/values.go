@@ -10,6 +10,10 @@ var (
 	_ WithHoles = (*listValue)(nil)
 	_ WithHoles = (*holeValue)(nil)
 	_ WithHoles = (*concatenationValue)(nil)
+	_ WithHoles = (*functionValue)(nil)
+
+	_ WithFunctions = (*listValue)(nil)
+	_ WithFunctions = (*functionValue)(nil)
 )
 
 type CompositeValue interface {
@@ -30,6 +34,11 @@ type WithAlias interface {
 	ResolveAlias(func(string) (string, bool))
 }
 
+type WithFunctions interface {
+	GetFunctions() []string
+	ResolveFunctions(func(name string, args []interface{}) (interface{}, error)) error
+}
+
 type listValue struct {
 	vals []CompositeValue
 }
@@ -105,6 +114,22 @@ func (l *listValue) IsRef(key string) bool {
 	return false
 }
 
+func (l *listValue) ReplaceHole(key string, value CompositeValue) {
+	for k, val := range l.vals {
+		if withHoles, ok := val.(WithHoles); ok {
+			if withHoles.IsHole(key) {
+				l.vals[k] = value.Clone()
+			} else {
+				withHoles.ReplaceHole(key, value)
+			}
+		}
+	}
+}
+
+func (l *listValue) IsHole(key string) bool {
+	return false
+}
+
 func (l *listValue) String() string {
 	var buff bytes.Buffer
 	buff.WriteRune('[')
@@ -135,6 +160,26 @@ func (l *listValue) ResolveAlias(resolvFunc func(string) (string, bool)) {
 	}
 }
 
+func (l *listValue) GetFunctions() (res []string) {
+	for _, val := range l.vals {
+		if withFuncs, ok := val.(WithFunctions); ok {
+			res = append(res, withFuncs.GetFunctions()...)
+		}
+	}
+	return
+}
+
+func (l *listValue) ResolveFunctions(eval func(string, []interface{}) (interface{}, error)) error {
+	for _, val := range l.vals {
+		if withFuncs, ok := val.(WithFunctions); ok {
+			if err := withFuncs.ResolveFunctions(eval); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (l *listValue) Clone() CompositeValue {
 	clone := &listValue{}
 	for _, val := range l.vals {
@@ -184,6 +229,14 @@ func (h *holeValue) GetHoles() map[string]*Hole {
 	return make(map[string]*Hole)
 }
 
+func (h *holeValue) ReplaceHole(key string, value CompositeValue) {
+
+}
+
+func (h *holeValue) IsHole(key string) bool {
+	return h.hole.Name == key && h.val == nil && h.alias == nil
+}
+
 func (h *holeValue) Value() interface{} {
 	if h.alias != nil {
 		return h.alias.(CompositeValue).Value()
@@ -315,6 +368,22 @@ func (c *concatenationValue) IsRef(key string) bool {
 	return false
 }
 
+func (c *concatenationValue) ReplaceHole(key string, value CompositeValue) {
+	for k, val := range c.vals {
+		if withHoles, ok := val.(WithHoles); ok {
+			if withHoles.IsHole(key) {
+				c.vals[k] = value.Clone()
+			} else {
+				withHoles.ReplaceHole(key, value)
+			}
+		}
+	}
+}
+
+func (c *concatenationValue) IsHole(key string) bool {
+	return false
+}
+
 func (c *concatenationValue) String() string {
 	if len(c.GetHoles())+len(c.GetRefs())+len(c.GetAliases()) == 0 {
 		return quoteStringIfNeeded(c.Value().(string))
@@ -460,3 +529,167 @@ func (r *referenceValue) ResolveAlias(resolvFunc func(string) (string, bool)) {
 		r.val = val
 	}
 }
+
+// functionValue is evaluated once all its arguments are resolved
+type functionValue struct {
+	name string
+	args []CompositeValue
+	val  interface{}
+}
+
+func (f *functionValue) Value() interface{} {
+	return f.val
+}
+
+func (f *functionValue) String() string {
+	if f.val != nil {
+		return printParamValue(f.val)
+	}
+	var args []string
+	for _, arg := range f.args {
+		args = append(args, arg.String())
+	}
+	return fmt.Sprintf("%s(%s)", f.name, strings.Join(args, ", "))
+}
+
+func (f *functionValue) Clone() CompositeValue {
+	clone := &functionValue{name: f.name, val: f.val}
+	for _, arg := range f.args {
+		clone.args = append(clone.args, arg.Clone())
+	}
+	return clone
+}
+
+func (f *functionValue) GetFunctions() (res []string) {
+	if f.val != nil {
+		return
+	}
+	res = append(res, f.name)
+	for _, arg := range f.args {
+		if withFuncs, ok := arg.(WithFunctions); ok {
+			res = append(res, withFuncs.GetFunctions()...)
+		}
+	}
+	return
+}
+
+// ResolveFunctions evaluates the function, nested functions first,
+// leaving it unresolved while one of its arguments is unresolved
+func (f *functionValue) ResolveFunctions(eval func(string, []interface{}) (interface{}, error)) error {
+	if f.val != nil {
+		return nil
+	}
+	for _, arg := range f.args {
+		if withFuncs, ok := arg.(WithFunctions); ok {
+			if err := withFuncs.ResolveFunctions(eval); err != nil {
+				return err
+			}
+		}
+	}
+	var args []interface{}
+	for _, arg := range f.args {
+		if arg.Value() == nil {
+			return nil
+		}
+		args = append(args, arg.Value())
+	}
+	val, err := eval(f.name, args)
+	if err != nil {
+		return fmt.Errorf("%s: %s", f.name, err)
+	}
+	f.val = val
+	return nil
+}
+
+func (f *functionValue) GetHoles() map[string]*Hole {
+	res := make(map[string]*Hole)
+	for _, arg := range f.args {
+		if withHoles, ok := arg.(WithHoles); ok {
+			for k, v := range withHoles.GetHoles() {
+				res[k] = v
+			}
+		}
+	}
+	return res
+}
+
+func (f *functionValue) ProcessHoles(fills map[string]interface{}) map[string]interface{} {
+	processed := make(map[string]interface{})
+	for _, arg := range f.args {
+		if withHoles, ok := arg.(WithHoles); ok {
+			for k, v := range withHoles.ProcessHoles(fills) {
+				processed[k] = v
+			}
+		}
+	}
+	return processed
+}
+
+func (f *functionValue) ReplaceHole(key string, value CompositeValue) {
+	for k, arg := range f.args {
+		if withHoles, ok := arg.(WithHoles); ok {
+			if withHoles.IsHole(key) {
+				f.args[k] = value.Clone()
+			} else {
+				withHoles.ReplaceHole(key, value)
+			}
+		}
+	}
+}
+
+func (f *functionValue) IsHole(key string) bool {
+	return false
+}
+
+func (f *functionValue) GetRefs() (res []string) {
+	if f.val != nil {
+		return
+	}
+	for _, arg := range f.args {
+		if withRefs, ok := arg.(WithRefs); ok {
+			res = append(res, withRefs.GetRefs()...)
+		}
+	}
+	return
+}
+
+func (f *functionValue) ProcessRefs(fills map[string]interface{}) {
+	for _, arg := range f.args {
+		if withRefs, ok := arg.(WithRefs); ok {
+			withRefs.ProcessRefs(fills)
+		}
+	}
+}
+
+func (f *functionValue) ReplaceRef(key string, value CompositeValue) {
+	for k, arg := range f.args {
+		if withRef, ok := arg.(WithRefs); ok {
+			if withRef.IsRef(key) {
+				f.args[k] = value
+			} else {
+				withRef.ReplaceRef(key, value)
+			}
+		}
+	}
+}
+
+func (f *functionValue) IsRef(key string) bool {
+	return false
+}
+
+func (f *functionValue) GetAliases() (res []string) {
+	for _, arg := range f.args {
+		if alias, ok := arg.(WithAlias); ok {
+			res = append(res, alias.GetAliases()...)
+		}
+	}
+	return
+}
+
+func (f *functionValue) ResolveAlias(resolvFunc func(string) (string, bool)) {
+	for _, arg := range f.args {
+		if alias, ok := arg.(WithAlias); ok {
+			alias.ResolveAlias(resolvFunc)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package ast
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -76,6 +78,11 @@ func TestCompositeValues(t *testing.T) {
 			holesFillers: map[string]interface{}{"instance.name": "toto", "version": 2},
 			expValue:     "ins$\\ta{nce}-toto2",
 		},
+		{
+			val:      &functionValue{name: "cidrsubnet", args: []CompositeValue{&holeValue{hole: &Hole{Name: "vpc.cidr"}}, &referenceValue{ref: "bits"}, &interfaceValue{val: 2}}},
+			expHoles: map[string]*Hole{"vpc.cidr": &Hole{Name: "vpc.cidr"}},
+			expRefs:  []string{"bits"},
+		},
 	}
 
 	for i, tcase := range tcases {
@@ -181,6 +188,12 @@ func TestCompositeValuesStringer(t *testing.T) {
 			},
 			expect: "instance-toto2",
 		},
+		{
+			val:    &functionValue{name: "join", args: []CompositeValue{&interfaceValue{val: "-"}, newCompositeValue(&referenceValue{ref: "myref"}, &holeValue{hole: &Hole{Name: "myhole"}})}},
+			expect: "join(-, [$myref,{myhole}])",
+		},
+		{val: &functionValue{name: "now"}, expect: "now()"},
+		{val: &functionValue{name: "lower", args: []CompositeValue{&interfaceValue{val: "TEST"}}, val: "test"}, expect: "test"},
 	}
 
 	for i, tcase := range tcases {
@@ -238,6 +251,72 @@ func TestCloneValues(t *testing.T) {
 	}
 }
 
+func TestResolveFunctions(t *testing.T) {
+	eval := func(name string, args []interface{}) (interface{}, error) {
+		switch name {
+		case "upper":
+			return strings.ToUpper(fmt.Sprint(args[0])), nil
+		case "concat":
+			return fmt.Sprint(args...), nil
+		}
+		return nil, fmt.Errorf("unknown function")
+	}
+	tcases := []struct {
+		val         CompositeValue
+		refsFillers map[string]interface{}
+		expFuncs    []string
+		expValue    interface{}
+		expErr      string
+	}{
+		{val: &functionValue{name: "upper", args: []CompositeValue{&interfaceValue{val: "test"}}}, expValue: "TEST"},
+		{
+			val: &functionValue{name: "upper", args: []CompositeValue{
+				&functionValue{name: "concat", args: []CompositeValue{&interfaceValue{val: "a"}, &interfaceValue{val: "b"}}},
+			}},
+			expValue: "AB",
+		},
+		{
+			val: &functionValue{name: "upper", args: []CompositeValue{
+				&functionValue{name: "concat", args: []CompositeValue{&interfaceValue{val: "a"}, &referenceValue{ref: "myref"}}},
+			}},
+			expFuncs: []string{"upper", "concat"},
+		},
+		{
+			val: &functionValue{name: "upper", args: []CompositeValue{
+				&functionValue{name: "concat", args: []CompositeValue{&interfaceValue{val: "a"}, &referenceValue{ref: "myref"}}},
+			}},
+			refsFillers: map[string]interface{}{"myref": "b"},
+			expValue:    "AB",
+		},
+		{
+			val:      newCompositeValue(&interfaceValue{val: "a"}, &functionValue{name: "upper", args: []CompositeValue{&interfaceValue{val: "b"}}}),
+			expValue: []interface{}{"a", "B"},
+		},
+		{val: &functionValue{name: "unknown"}, expFuncs: []string{"unknown"}, expErr: "unknown: unknown function"},
+	}
+
+	for i, tcase := range tcases {
+		if withRefs, ok := tcase.val.(WithRefs); ok {
+			withRefs.ProcessRefs(tcase.refsFillers)
+		}
+		withFuncs := tcase.val.(WithFunctions)
+		err := withFuncs.ResolveFunctions(eval)
+		if tcase.expErr != "" {
+			if err == nil || err.Error() != tcase.expErr {
+				t.Fatalf("%d: got error %v, want %s", i+1, err, tcase.expErr)
+			}
+		} else if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := withFuncs.GetFunctions(), tcase.expFuncs; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: functions: got %#v, want %#v", i+1, got, want)
+		}
+		if got, want := tcase.val.Value(), tcase.expValue; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: value: got %#v, want %#v", i+1, got, want)
+		}
+	}
+}
+
 func newCompositeValue(values ...CompositeValue) CompositeValue {
 	return &listValue{vals: values}
 }
@@ -0,0 +1,222 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wallix/awless/template/env"
+	"github.com/wallix/awless/template/internal/ast"
+	"github.com/wallix/awless/template/params"
+)
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// Diagnostic is an issue reported on a template statement
+type Diagnostic struct {
+	Line, Column int
+	Severity     string
+	Message      string
+}
+
+func (d *Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Lint reports, without running the template nor accessing the cloud, the syntax errors,
+// the unknown commands or params, the references to undeclared variables, the unused
+// declarations, the holes not filled by the compile env fillers and the non revertible
+// commands. Without any error found, it reports the first error of the compile passes.
+// Diagnostics are ordered by position in template
+func Lint(text string, cenv env.Compiling) ([]*Diagnostic, error) {
+	tpl, err := Parse(text)
+	if perr, ok := err.(*parseError); ok && !perr.invalidIndexes() {
+		return []*Diagnostic{{Line: perr.line, Column: perr.start + 1, Severity: LintError, Message: "invalid syntax"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cenv.IncludeFunc() != nil {
+		if tpl, cenv, err = resolveIncludesPass(tpl, cenv); err != nil {
+			return nil, err
+		}
+	}
+
+	fillers := make(map[string]interface{})
+	for _, data := range []int{env.DEFAULTS, env.FILLERS} {
+		for k, v := range cenv.Get(data) {
+			fillers[k] = v
+		}
+	}
+	l := &linter{cenv: cenv, fillers: fillers, declared: make(map[string]ast.Position), used: make(map[string]bool), params: tpl.paramDeclarations()}
+	l.lint(tpl.Statements, make(map[string]bool))
+
+	holes := make(map[string]bool)
+	for _, name := range tpl.HoleNames() {
+		holes[name] = true
+	}
+	for _, st := range tpl.Statements {
+		if n, ok := st.Node.(*ast.ParamNode); ok && !holes[n.Name] {
+			l.add(st.Pos, LintWarning, "param '%s' is declared but there is no hole {%s}", n.Name, n.Name)
+		}
+	}
+
+	var idents []string
+	for ident := range l.declared {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+	for _, ident := range idents {
+		if !l.used[ident] {
+			l.add(l.declared[ident], LintWarning, "unused declaration '%s'", ident)
+		}
+	}
+
+	if !l.hasErrors() {
+		if _, _, err := Compile(tpl, cenv, LintCompileMode); err != nil {
+			d := &Diagnostic{Severity: LintError, Message: err.Error()}
+			if located, ok := err.(*Error); ok {
+				d.Line, d.Column, d.Message = located.Line, located.Column, located.Msg
+			}
+			l.diags = append(l.diags, d)
+		}
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		if l.diags[i].Line != l.diags[j].Line {
+			return l.diags[i].Line < l.diags[j].Line
+		}
+		return l.diags[i].Column < l.diags[j].Column
+	})
+	return l.diags, nil
+}
+
+type linter struct {
+	cenv     env.Compiling
+	fillers  map[string]interface{}
+	declared map[string]ast.Position
+	used     map[string]bool
+	params   map[string]*ast.Statement
+	diags    []*Diagnostic
+}
+
+func (l *linter) add(pos ast.Position, severity, format string, a ...interface{}) {
+	l.diags = append(l.diags, &Diagnostic{Line: pos.Line, Column: pos.Column, Severity: severity, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *linter) hasErrors() bool {
+	for _, d := range l.diags {
+		if d.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) lint(statements []*ast.Statement, knownRefs map[string]bool) {
+	for _, st := range statements {
+		switch n := st.Node.(type) {
+		case *ast.ForNode:
+			l.lintExpression(st.Pos, n.Items, knownRefs)
+			scoped := map[string]bool{n.Ident: true}
+			for k, v := range knownRefs {
+				scoped[k] = v
+			}
+			l.lint(n.Statements, scoped)
+		case *ast.IfNode:
+			for _, operand := range n.Condition.Operands() {
+				l.lintExpression(st.Pos, operand, knownRefs)
+			}
+			scoped := make(map[string]bool)
+			for k, v := range knownRefs {
+				scoped[k] = v
+			}
+			l.lint(n.Statements, scoped)
+		case *ast.ParamNode:
+			if n.Default != nil {
+				if err := n.Validate(n.DefaultValue()); err != nil {
+					l.add(st.Pos, LintError, "default value: %s", err)
+				}
+			}
+		case *ast.OutputNode:
+			l.lintExpression(st.Pos, n.Value, knownRefs)
+		case *ast.DeclarationNode:
+			l.lintExpression(st.Pos, n.Expr, knownRefs)
+			if knownRefs[n.Ident] {
+				l.add(st.Pos, LintError, "'%s' has already been assigned in template", n.Ident)
+			}
+			knownRefs[n.Ident] = true
+			l.declared[n.Ident] = st.Pos
+		case ast.ExpressionNode:
+			l.lintExpression(st.Pos, n, knownRefs)
+		}
+	}
+}
+
+func (l *linter) lintExpression(pos ast.Position, expr ast.ExpressionNode, knownRefs map[string]bool) {
+	if withRefs, ok := expr.(ast.WithRefs); ok {
+		for _, ref := range withRefs.GetRefs() {
+			l.used[ref] = true
+			if !knownRefs[ref] {
+				l.add(pos, LintError, "reference '$%s' is undeclared", ref)
+			}
+		}
+	}
+
+	if cmd, ok := expr.(*ast.CommandNode); ok {
+		l.lintCommand(cmd)
+	}
+
+	if withHoles, ok := expr.(ast.WithHoles); ok {
+		var holes []string
+		for name, hole := range withHoles.GetHoles() {
+			if _, filled := l.fillers[name]; !filled && !hole.IsOptional && !l.hasDefault(name) {
+				holes = append(holes, name)
+			}
+		}
+		sort.Strings(holes)
+		for _, hole := range holes {
+			l.add(pos, LintWarning, "hole {%s} has no default value and will be prompted", hole)
+		}
+	}
+}
+
+func (l *linter) hasDefault(hole string) bool {
+	st, ok := l.params[hole]
+	return ok && st.Node.(*ast.ParamNode).Default != nil
+}
+
+func (l *linter) lintCommand(node *ast.CommandNode) {
+	pos := node.Pos
+	cmd, ok := l.cenv.LookupCommandFunc()(fmt.Sprintf("%s%s", node.Action, node.Entity)).(ast.Command)
+	if !ok || cmd == nil {
+		l.add(pos, LintError, "unknown command '%s %s'", node.Action, node.Entity)
+		return
+	}
+
+	rule := cmd.ParamsSpec().Rule()
+	required, optionals, _ := params.List(rule)
+	keys := node.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !contains(required, key) && !contains(optionals, key) && !contains(metaParams, key) {
+			l.add(node.PosOf(key), LintError, "%s %s: unknown param '%s'", node.Action, node.Entity, key)
+		}
+	}
+	for _, missing := range rule.Missing(node.Keys()) {
+		if hole := fmt.Sprintf("%s.%s", node.Entity, missing); l.fillers[hole] == nil {
+			l.add(pos, LintWarning, "%s %s: missing required param '%s' will be prompted as {%s}", node.Action, node.Entity, missing, hole)
+		}
+	}
+
+	ran := *node // revertibility depends on the command result once run
+	ran.CmdResult = "result"
+	if !isRevertible(&ran) {
+		l.add(pos, LintWarning, "%s %s: command is not revertible", node.Action, node.Entity)
+	}
+}
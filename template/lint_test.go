@@ -0,0 +1,99 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/template/env"
+)
+
+func TestLint(t *testing.T) {
+	files := map[string]string{
+		"/tpl/subnet.aws": "sub = create subnet vpc={vpc} cidr=10.0.1.0/24",
+	}
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		newCommandFunc := awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))
+		if newCommandFunc == nil {
+			return nil
+		}
+		return newCommandFunc()
+	}).WithIncludeFunc(func(path string) ([]byte, string, error) {
+		return []byte(files[path]), path, nil
+	}).WithTemplatePath("/tpl/main.aws").Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"instance.type": "t2.micro"})
+	defaults := map[string]interface{}{"instance.image": "ami-1234"}
+	cenv.Push(env.DEFAULTS, defaults)
+
+	tcases := []struct {
+		tpl    string
+		expect []string
+	}{
+		{tpl: "vpc = create vpc cidr=10.0.0.0/16\ncreate tag resource=$vpc key=Name value=prod"},
+		{
+			tpl: "vpc = create vpc cidr=10.0.0.0/16 unknown=param\n\nsub = create subnet vpc=$vpc cidr={subnet.cidr}\n  attach vpc id=$vpc\nstart instance id=$inst",
+			expect: []string{
+				"1:35: error: create vpc: unknown param 'unknown'",
+				"3:1: warning: hole {subnet.cidr} has no default value and will be prompted",
+				"3:1: warning: unused declaration 'sub'",
+				"4:3: error: unknown command 'attach vpc'",
+				"5:1: error: reference '$inst' is undeclared",
+			},
+		},
+		{
+			tpl: "for name in [a, b] {\n  create instance name=$name subnet=sub-1 image=ami-1 count=1 retry=3\n}\ncheck instance id=@inst state=running timeout=10",
+			expect: []string{
+				"4:1: warning: check instance: command is not revertible",
+			},
+		},
+		{
+			tpl: "create keypair",
+			expect: []string{
+				"1:1: warning: create keypair: missing required param 'name' will be prompted as {keypair.name}",
+			},
+		},
+		{
+			tpl: "vpc = create vpc cidr=10.0.0.0/16\ninclude subnet.aws with vpc=$vpc",
+			expect: []string{
+				"2:1: warning: unused declaration 'sub'",
+			},
+		},
+		{
+			tpl:    "vpc = create vpc cidr=10.0.0.0/16\ncreate subnet vpc=$vpc cidr=10.0.300.0/24",
+			expect: []string{"2:24: error: create subnet: param validation:\n\t\t- param 'cidr': invalid CIDR address: 10.0.300.0/24"},
+		},
+		{
+			tpl:    "create vpc cidr=10.0.0.0/16 name=upper(prod)",
+			expect: []string{"1:1: error: create vpc: unknown function 'upper'"},
+		},
+		{
+			tpl: "param vpc.cidr: cidr = 10.0.0.0/16\nparam vpc.name = prod enum(dev, test)\nparam subnet.name\ncreate vpc cidr={vpc.cidr} name={vpc.name}",
+			expect: []string{
+				"2:1: error: default value: param 'vpc.name': expecting one of dev, test, got 'prod'",
+				"3:1: warning: param 'subnet.name' is declared but there is no hole {subnet.name}",
+			},
+		},
+		{
+			tpl:    "create vpc cidr=10.0.0.0/16\ncreate subnet cidr=[a,",
+			expect: []string{"2:22: error: invalid syntax"},
+		},
+	}
+
+	for i, tcase := range tcases {
+		diags, err := Lint(tcase.tpl, cenv)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if want := tcase.expect; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+	if got, want := defaults, map[string]interface{}{"instance.image": "ami-1234"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
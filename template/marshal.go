@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,11 @@ type TemplateExecution struct {
 	Author, Source, Locale string
 	Profile, Path, Message string
 	Fillers                map[string]interface{}
+	// RollbackOf links a rollback execution to the failed execution it reverts,
+	// RolledBackBy links a failed execution to its rollback execution
+	RollbackOf, RolledBackBy string
+	// Started and Ended are the wall clock times of the run, not persisted
+	Started, Ended time.Time
 }
 
 // Date extract the date from the ulid template identifier
@@ -58,6 +64,11 @@ func (t *TemplateExecution) MarshalJSON() ([]byte, error) {
 	out.Message = t.Message
 	out.Path = t.Path
 	out.Fillers = t.Fillers
+	out.RollbackOf = t.RollbackOf
+	out.RolledBackBy = t.RolledBackBy
+	if outputs := t.Outputs(); len(outputs) > 0 {
+		out.Outputs = outputs
+	}
 	if out.Fillers == nil {
 		out.Fillers = make(map[string]interface{}, 0) // friendlier for json, avoiding "fillers": null,
 	}
@@ -66,6 +77,9 @@ func (t *TemplateExecution) MarshalJSON() ([]byte, error) {
 	for _, cmd := range t.CommandNodesIterator() {
 		newCmd := command{}
 		newCmd.Line = cmd.String()
+		newCmd.Skipped = cmd.CmdSkipped
+		newCmd.Attempts = cmd.CmdAttempts
+		newCmd.PriorState = cmd.CmdPriorState
 		if cmd.CmdErr != nil {
 			newCmd.Errors = append(newCmd.Errors, cmd.CmdErr.Error())
 		}
@@ -102,6 +116,8 @@ func (t *TemplateExecution) UnmarshalJSON(b []byte) error {
 	t.Path = v.Path
 	t.Author = v.Author
 	t.Fillers = v.Fillers
+	t.RollbackOf = v.RollbackOf
+	t.RolledBackBy = v.RolledBackBy
 
 	tpl := &Template{ID: v.ID, AST: &ast.AST{
 		Statements: make([]*ast.Statement, 0),
@@ -122,10 +138,23 @@ func (t *TemplateExecution) UnmarshalJSON(b []byte) error {
 			if len(c.Errors) > 0 {
 				n.CmdErr = errors.New(c.Errors[0])
 			}
+			n.CmdSkipped = c.Skipped
+			n.CmdAttempts = c.Attempts
+			n.CmdPriorState = c.PriorState
 			tpl.Statements = append(tpl.Statements, &ast.Statement{Node: n})
 		}
 	}
 
+	var outputs []string
+	for k := range v.Outputs {
+		outputs = append(outputs, k)
+	}
+	sort.Strings(outputs)
+	for _, k := range outputs {
+		out := &ast.OutputNode{Ident: k, Value: &ast.ValueNode{Value: ast.NewInterfaceValue(v.Outputs[k])}}
+		tpl.Statements = append(tpl.Statements, &ast.Statement{Node: out})
+	}
+
 	*(t.Template) = *tpl
 
 	return nil
@@ -133,6 +162,7 @@ func (t *TemplateExecution) UnmarshalJSON(b []byte) error {
 
 type TemplateExecutionStats struct {
 	KOCount, OKCount, CmdCount int
+	SkippedCount               int
 	ActionEntityCount          map[string]int
 	Oneliner                   string
 }
@@ -146,6 +176,10 @@ func (t *TemplateExecution) Stats() *TemplateExecutionStats {
 
 	var actionentity string
 	for _, cmd := range t.CommandNodesIterator() {
+		if cmd.CmdSkipped {
+			stats.SkippedCount++
+			continue
+		}
 		actionentity = fmt.Sprintf("%s %s", cmd.Action, cmd.Entity)
 		if cmd.Err() != nil {
 			stats.KOCount++
@@ -173,10 +207,17 @@ type toJSON struct {
 	Path     string                 `json:"path,omitempty"`
 	Fillers  map[string]interface{} `json:"fillers"`
 	Commands []command              `json:"commands"`
+	Outputs  map[string]interface{} `json:"outputs,omitempty"`
+
+	RollbackOf   string `json:"rollbackOf,omitempty"`
+	RolledBackBy string `json:"rolledBackBy,omitempty"`
 }
 
 type command struct {
-	Line    string   `json:"line"`
-	Errors  []string `json:"errors,omitempty"`
-	Results []string `json:"results,omitempty"`
+	Line       string                 `json:"line"`
+	Errors     []string               `json:"errors,omitempty"`
+	Results    []string               `json:"results,omitempty"`
+	Skipped    bool                   `json:"skipped,omitempty"`
+	Attempts   int                    `json:"attempts,omitempty"`
+	PriorState map[string]interface{} `json:"priorState,omitempty"`
 }
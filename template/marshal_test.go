@@ -52,7 +52,8 @@ func TestGetStatsFromTemplateExecution(t *testing.T) {
 			{"line": "create subnet"},
 			{"line": "attach policy"},
 			{"line": "stop instance"},
-			{"line": "detach policy", "errors": ["any"]}
+			{"line": "detach policy", "errors": ["any"]},
+			{"line": "create securitygroup", "skipped": true}
 		]}`)); err != nil {
 		t.Fatal(err)
 	}
@@ -72,6 +73,9 @@ func TestGetStatsFromTemplateExecution(t *testing.T) {
 	if got, want := stats.CmdCount, 9; got != want {
 		t.Fatalf("got %d, want %d", got, want)
 	}
+	if got, want := stats.SkippedCount, 1; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
 	if got, want := stats.Oneliner, ""; got != want {
 		t.Fatalf("got %s, want %s", got, want)
 	}
@@ -92,6 +96,7 @@ func TestTemplateExecutionUnmarshalFromJSON(t *testing.T) {
 		"message": "Make the CLI great again",
 		"profile": "admin",
 		"path": "http://gist.com/mytemplate.aws",
+		"rollbackOf": "654321",
 		"fillers": {
 			"mykey": "myvalue",
 			"mysecondkey": "mysecondvalue"
@@ -136,6 +141,9 @@ func TestTemplateExecutionUnmarshalFromJSON(t *testing.T) {
 	if got, want := tplExec.Path, "http://gist.com/mytemplate.aws"; got != want {
 		t.Fatalf("got %s, want %s", got, want)
 	}
+	if got, want := tplExec.RollbackOf, "654321"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
 
 	if got, want := cmds[0].CmdResult, "vpc-12345"; got != want {
 		t.Fatalf("got %v, want %v", got, want)
@@ -67,6 +67,15 @@ func MustParse(text string) *Template {
 	return t
 }
 
+// Format parses a template and prints it back in canonical form keeping its comments
+func Format(text string) (string, error) {
+	tpl, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	return tpl.Format(), nil
+}
+
 func ParseParams(text string) (map[string]interface{}, error) {
 	node, err := parseParamsAsCommandNode(text)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -224,6 +225,244 @@ func TestParsingInvalidActionAndEntities(t *testing.T) {
 	}
 }
 
+func TestParseForLoops(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+		items         interface{}
+		bodyLen       int
+	}{
+		{
+			input:   "for sub in [sub-1,sub-2] {\n  create instance subnet=$sub\n}",
+			expect:  "for sub in [sub-1,sub-2] {\n\tcreate instance subnet=$sub\n}",
+			items:   []interface{}{"sub-1", "sub-2"},
+			bodyLen: 1,
+		},
+		{
+			input:   "for name in {names} { create user name=$name }",
+			expect:  "for name in {names} {\n\tcreate user name=$name\n}",
+			bodyLen: 1,
+		},
+		{
+			input:   "for cidr in [10.0.0.0/24, 10.0.1.0/24]{\n\n  # create subnets\n  sub = create subnet cidr=$cidr vpc=vpc-1\n\n  for t in [k1,k2] {\n    create tag resource=$sub key=$t value=$cidr\n  }\n}",
+			expect:  "for cidr in [10.0.0.0/24,10.0.1.0/24] {\n\t# create subnets\n\tsub = create subnet cidr=$cidr vpc=vpc-1\n\tfor t in [k1,k2] {\n\t\tcreate tag key=$t resource=$sub value=$cidr\n\t}\n}",
+			items:   []interface{}{"10.0.0.0/24", "10.0.1.0/24"},
+			bodyLen: 3,
+		},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := Parse(tcase.input)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := len(tpl.Statements), 1; got != want {
+			t.Fatalf("%d: got %d, want %d", i+1, got, want)
+		}
+		loop, ok := tpl.Statements[0].Node.(*ast.ForNode)
+		if !ok {
+			t.Fatalf("%d: expected for node, got %T", i+1, tpl.Statements[0].Node)
+		}
+		if got, want := loop.Items.Value.Value(), tcase.items; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %#v, want %#v", i+1, got, want)
+		}
+		if got, want := len(loop.Statements), tcase.bodyLen; got != want {
+			t.Fatalf("%d: got %d, want %d", i+1, got, want)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+		}
+		if _, err := Parse(tpl.String()); err != nil {
+			t.Fatalf("%d: cannot parse back: %s", i+1, err)
+		}
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+		unless        bool
+		operator      string
+		entity        string
+	}{
+		{
+			input:    "if exists @my-sg {\n  create securitygroup name=my-sg\n}",
+			expect:   "if exists @my-sg {\n\tcreate securitygroup name=my-sg\n}",
+			operator: "exists",
+		},
+		{
+			input:    "unless exists securitygroup @my-sg { create securitygroup name=my-sg }",
+			expect:   "unless exists securitygroup @my-sg {\n\tcreate securitygroup name=my-sg\n}",
+			unless:   true,
+			operator: "exists",
+			entity:   "securitygroup",
+		},
+		{
+			input:    "if {env}==prod {\n  create instance\n\n  for n in [1,2] { create tag value=$n }\n}",
+			expect:   "if {env} == prod {\n\tcreate instance\n\tfor n in [1,2] {\n\t\tcreate tag value=$n\n\t}\n}",
+			operator: "==",
+		},
+		{
+			input:    "vpc = create vpc\nif $vpc != 'vpc-1234' {\n  create subnet vpc=$vpc\n}",
+			expect:   "vpc = create vpc\nif $vpc != vpc-1234 {\n\tcreate subnet vpc=$vpc\n}",
+			operator: "!=",
+		},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := Parse(tcase.input)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		cond, ok := tpl.Statements[len(tpl.Statements)-1].Node.(*ast.IfNode)
+		if !ok {
+			t.Fatalf("%d: expected if node, got %T", i+1, tpl.Statements[0].Node)
+		}
+		if got, want := cond.Unless, tcase.unless; got != want {
+			t.Fatalf("%d: got %t, want %t", i+1, got, want)
+		}
+		if got, want := cond.Condition.Operator, tcase.operator; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+		if got, want := cond.Condition.Entity, tcase.entity; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+		}
+		if _, err := Parse(tpl.String()); err != nil {
+			t.Fatalf("%d: cannot parse back: %s", i+1, err)
+		}
+	}
+
+	if _, err := Parse("if exists intance @my-sg {\n create vpc\n}"); err == nil || !strings.Contains(err.Error(), "entity 'intance'") {
+		t.Fatalf("expected error with specific message, got: %v", err)
+	}
+}
+
+func TestParseOutputs(t *testing.T) {
+	tpl, err := Parse("vpc = create vpc\noutput vpc-id = $vpc\noutputs = [$vpc, vpc-2]\noutput  all=$outputs\noutput name = 'my vpc'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idents []string
+	for _, st := range tpl.Statements {
+		if out, ok := st.Node.(*ast.OutputNode); ok {
+			idents = append(idents, out.Ident)
+		}
+	}
+	if got, want := idents, []string{"vpc-id", "all", "name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	exp := "vpc = create vpc\noutput vpc-id = $vpc\noutputs = [$vpc,vpc-2]\noutput all = $outputs\noutput name = 'my vpc'"
+	if got, want := tpl.String(), exp; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+		funcs         []string
+	}{
+		{input: "create vpc name=lower(MyVpc)", expect: "create vpc name=lower(MyVpc)", funcs: []string{"lower"}},
+		{input: "create subnet cidr=cidrsubnet({vpc.cidr}, 8,2)", expect: "create subnet cidr=cidrsubnet({vpc.cidr}, 8, 2)", funcs: []string{"cidrsubnet"}},
+		{input: "create tag key=Name value=join('-', [$env, base64( env(\"HOME\") )])", expect: "create tag key=Name value=join(-, [$env,base64(env(HOME))])", funcs: []string{"join", "base64", "env"}},
+		{input: "name = now()", expect: "name = now()", funcs: []string{"now"}},
+		{input: "create instance userdata=file(x.sh) subnets=sub-1,lower(SUB-2)", expect: "create instance subnets=[sub-1,lower(SUB-2)] userdata=file(x.sh)", funcs: []string{"lower", "file"}},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := Parse(tcase.input)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+		}
+		var funcs []string
+		for _, expr := range tpl.expressionNodesIterator() {
+			if withFuncs, ok := expr.(ast.WithFunctions); ok {
+				funcs = append(funcs, withFuncs.GetFunctions()...)
+			}
+		}
+		sort.Strings(funcs)
+		sort.Strings(tcase.funcs)
+		if got, want := funcs, tcase.funcs; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestParseStatementsPosition(t *testing.T) {
+	tpl := MustParse("# comment\nvpc = create vpc\n\n  for name in [a, b] {\n\tcreate subnet vpc=$vpc name=$name\n  }\noutput id = $vpc")
+	var positions []string
+	visitStatements(tpl.Statements, func(st *ast.Statement) {
+		positions = append(positions, st.Pos.String())
+	})
+	if got, want := positions, []string{"1:1", "2:1", "4:3", "5:2", "7:1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := tpl.Clone().Statements[1].Pos, tpl.Statements[1].Pos; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	cmds := (&Template{AST: tpl.Clone()}).CommandNodesIterator()
+	if got, want := cmds[0].Pos.String(), "2:7"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got, want := cmds[1].Pos.String(), "5:2"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got, want := cmds[1].PosOf("name").String(), "5:25"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got, want := cmds[1].PosOf("unknown").String(), "5:2"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseIncludes(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+		path          string
+		params        []string
+	}{
+		{input: "include network/vpc.aws", expect: "include network/vpc.aws", path: "network/vpc.aws"},
+		{input: "include 'repo:vpc.aws'", expect: "include repo:vpc.aws", path: "repo:vpc.aws"},
+		{
+			input:  "include \"my vpc.aws\" with name=$name cidr={vpc.cidr}",
+			expect: "include 'my vpc.aws' with cidr={vpc.cidr} name=$name",
+			path:   "my vpc.aws",
+			params: []string{"cidr", "name"},
+		},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := Parse(tcase.input)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		incl, ok := tpl.Statements[0].Node.(*ast.IncludeNode)
+		if !ok {
+			t.Fatalf("%d: expected include node, got %T", i+1, tpl.Statements[0].Node)
+		}
+		if got, want := incl.Path, tcase.path; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+		var keys []string
+		for k := range incl.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if got, want := keys, tcase.params; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %v, want %v", i+1, got, want)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+		}
+	}
+}
+
 func TestParsingEmptyTemplate(t *testing.T) {
 	_, err := Parse(``)
 	if err == nil || err.Error() != "empty template" {
@@ -383,7 +622,7 @@ func TestTemplateParsing(t *testing.T) {
 		}
 	})
 
-	t.Run("Allow and ignore comments", func(t *testing.T) {
+	t.Run("Allow and retain comments", func(t *testing.T) {
 		tcases := []struct {
 			input    string
 			verifyFn func(tpl *Template) error
@@ -391,13 +630,16 @@ func TestTemplateParsing(t *testing.T) {
 			{
 				input: "create vpc\n#my comment\ncreate subnet",
 				verifyFn: func(tpl *Template) error {
-					if got, want := len(tpl.Statements), 2; got != want {
+					if got, want := len(tpl.Statements), 3; got != want {
 						t.Fatalf("got %d, want %d", got, want)
 					}
 					if err := isCommandNode(tpl.Statements[0].Node); err != nil {
 						t.Fatal(err)
 					}
-					if err := isCommandNode(tpl.Statements[1].Node); err != nil {
+					if comment, ok := tpl.Statements[1].Node.(*ast.CommentNode); !ok || comment.Text != "#my comment" {
+						t.Fatalf("got %#v, want comment node", tpl.Statements[1].Node)
+					}
+					if err := isCommandNode(tpl.Statements[2].Node); err != nil {
 						t.Fatal(err)
 					}
 					return nil
@@ -406,13 +648,16 @@ func TestTemplateParsing(t *testing.T) {
 			{
 				input: "create vpc \n//my comment\ncreate subnet",
 				verifyFn: func(tpl *Template) error {
-					if got, want := len(tpl.Statements), 2; got != want {
+					if got, want := len(tpl.Statements), 3; got != want {
 						t.Fatalf("got %d, want %d", got, want)
 					}
 					if err := isCommandNode(tpl.Statements[0].Node); err != nil {
 						t.Fatal(err)
 					}
-					if err := isCommandNode(tpl.Statements[1].Node); err != nil {
+					if comment, ok := tpl.Statements[1].Node.(*ast.CommentNode); !ok || comment.Text != "//my comment" {
+						t.Fatalf("got %#v, want comment node", tpl.Statements[1].Node)
+					}
+					if err := isCommandNode(tpl.Statements[2].Node); err != nil {
 						t.Fatal(err)
 					}
 					return nil
@@ -636,12 +881,15 @@ create subnet
 
 `,
 				verifyFn: func(s *Template) error {
-					if err := assertCommandNode(s.Statements[0].Node, "create", "vpc",
+					if got, want := len(s.Statements), 6; got != want {
+						return fmt.Errorf("got %d statements, want %d", got, want)
+					}
+					if err := assertCommandNode(s.Statements[2].Node, "create", "vpc",
 						make(map[string][]string), make(map[string]interface{}), make(map[string][]string), make(map[string][]string),
 					); err != nil {
 						return err
 					}
-					if err := assertCommandNode(s.Statements[1].Node, "create", "subnet",
+					if err := assertCommandNode(s.Statements[4].Node, "create", "subnet",
 						make(map[string][]string), make(map[string]interface{}), make(map[string][]string), make(map[string][]string),
 					); err != nil {
 						return err
@@ -1003,3 +1251,104 @@ func isValueNode(n ast.Node) error {
 	}
 	return nil
 }
+
+func TestParseParamDeclarations(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+		param         *ast.ParamNode
+		expErr        string
+	}{
+		{
+			input:  "param instance.type",
+			expect: "param instance.type",
+			param:  &ast.ParamNode{Name: "instance.type"},
+		},
+		{
+			input:  `param instance.type: string = "t3.micro" enum(t2.micro,"t3.micro") description "Type of the instance"`,
+			expect: "param instance.type: string = t3.micro enum(t2.micro, t3.micro) description 'Type of the instance'",
+			param: &ast.ParamNode{Name: "instance.type", Type: "string", Description: "Type of the instance",
+				Default: ast.NewInterfaceValue("t3.micro"), Enum: []ast.CompositeValue{ast.NewInterfaceValue("t2.micro"), ast.NewInterfaceValue("t3.micro")}},
+		},
+		{
+			input:  "param subnets.cidrs = [10.0.0.0/24, 10.0.1.0/24]",
+			expect: "param subnets.cidrs = [10.0.0.0/24,10.0.1.0/24]",
+		},
+		{
+			input:  "param instance.count : int=3",
+			expect: "param instance.count: int = 3",
+			param:  &ast.ParamNode{Name: "instance.count", Type: "int", Default: ast.NewInterfaceValue(3)},
+		},
+		{
+			input:  "param instance.count: float",
+			expErr: "unknown type 'float' for param 'instance.count', expecting string, int, bool, cidr, ip",
+		},
+	}
+
+	for i, tcase := range tcases {
+		tpl, err := Parse(tcase.input)
+		if tcase.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.expErr) {
+				t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+		if tcase.param != nil {
+			if got, want := tpl.Statements[0].Node, tcase.param; !reflect.DeepEqual(got, want) {
+				t.Fatalf("%d: got %#v, want %#v", i+1, got, want)
+			}
+		}
+		if _, err := Parse(tpl.String()); err != nil {
+			t.Fatalf("%d: cannot parse back: %s", i+1, err)
+		}
+	}
+
+	if _, err := Parse("params = create vpc cidr=10.0.0.0/16"); err != nil {
+		t.Fatalf("declaration named param: %s", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tcases := []struct {
+		input, expect string
+	}{
+		{
+			input:  "create vpc   cidr=10.0.0.0/16 name='my vpc'",
+			expect: "create vpc cidr=10.0.0.0/16 name='my vpc'\n",
+		},
+		{
+			input:  "# my infra\n\n\n\nvpc = create vpc cidr=10.0.0.0/16 # main vpc\nsubnetname= \"subnet\"\n\nsub = create subnet vpc=$vpc cidr=10.0.0.0/24 name=$subnetname\n",
+			expect: "# my infra\n\nvpc        = create vpc cidr=10.0.0.0/16 # main vpc\nsubnetname = subnet\n\nsub = create subnet cidr=10.0.0.0/24 name=$subnetname vpc=$vpc\n",
+		},
+		{
+			input:  "for name in [a,   b]{\n// create users\n\n   create user name=$name\n} # end\nunless {skip}==true{ create group name=admins }",
+			expect: "for name in [a,b] {\n\t// create users\n\n\tcreate user name=$name\n} # end\nunless {skip} == true {\n\tcreate group name=admins\n}\n",
+		},
+		{
+			input:  "create tag key=note value=\"@notalias\" resource=@myinstance\ncreate tag key='$notref' value='{nothole}' resource=['@a',b]",
+			expect: "create tag key=note resource=@myinstance value='@notalias'\ncreate tag key='$notref' resource=['@a',b] value='{nothole}'\n",
+		},
+	}
+
+	for i, tcase := range tcases {
+		got, err := Format(tcase.input)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if want := tcase.expect; got != want {
+			t.Fatalf("%d: got\n%q\nwant\n%q", i+1, got, want)
+		}
+		again, err := Format(got)
+		if err != nil {
+			t.Fatalf("%d: cannot format back: %s", i+1, err)
+		}
+		if again != got {
+			t.Fatalf("%d: not idempotent, got\n%q\nwant\n%q", i+1, again, got)
+		}
+	}
+}
@@ -2,6 +2,10 @@ package template
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -79,6 +83,24 @@ func TestBailOnUnresolvedAliasOrHoles(t *testing.T) {
 	}
 }
 
+func TestUnresolvedHolesErrorListsParamPaths(t *testing.T) {
+	tpl := MustParse("create instance name={instance.name} subnet={subnet}\ncreate subnet name={subnet.name} vpc={vpc}\ncreate vpc name={instance.name}")
+	_, _, err := failOnUnresolvedHolesPass(tpl, NewEnv().Build())
+	unresolved, ok := err.(*UnresolvedHolesError)
+	if !ok {
+		t.Fatalf("got %T error, want *UnresolvedHolesError", err)
+	}
+	if got, want := unresolved.Holes, []string{"instance.name", "subnet", "subnet.name", "vpc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := unresolved.ParamPaths["instance.name"], []string{"create.instance.name", "create.vpc.name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := unresolved.Error(), "template contains unresolved holes: [instance.name subnet subnet.name vpc]"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
 func TestCheckInvalidReferencesDeclarationPass(t *testing.T) {
 	env := NewEnv().Build()
 	tcases := []struct {
@@ -94,6 +116,18 @@ func TestCheckInvalidReferencesDeclarationPass(t *testing.T) {
 		{"new_inst = create instance autoref=$new_inst\n", "'new_inst' is undefined in template"},
 		{"a = $test", "'test' is undefined in template"},
 		{"b = [test1,$test2,{test4}]", "'test2' is undefined in template"},
+		{"for sub in [sub-1,sub-2] {\n create instance subnet=$sub\n}", ""},
+		{"subs = [sub-1,sub-2]\nfor sub in $subs {\n inst = create instance subnet=$sub\n create tag resource=$inst key=k value=v\n}", ""},
+		{"for sub in $subs {\n create instance subnet=$sub\n}", "'subs' is undefined in template"},
+		{"for sub in [sub-1,sub-2] {\n create instance subnet=$sub\n}\ncreate instance subnet=$sub", "'sub' is undefined in template"},
+		{"sub = create subnet\nfor sub in [sub-1,sub-2] {\n create instance subnet=$sub\n}", "'sub' has already been assigned in template"},
+		{"sub = create subnet\nif $sub == sub-1 {\n create instance subnet=$sub\n}", ""},
+		{"if $sub == sub-1 {\n create instance\n}", "'sub' is undefined in template"},
+		{"unless exists @sub {\n sub = create subnet\n}\ncreate instance subnet=$sub", "'sub' is undefined in template"},
+		{"sub = create subnet\noutput subnet = $sub\noutput region = eu-west-1", ""},
+		{"output subnet = $sub", "'sub' is undefined in template"},
+		{"sub = create subnet\noutput subnet = $sub\noutput subnet = [$sub]", "output 'subnet' has already been declared"},
+		{"for n in [a,b] {\n sub = create subnet name=$n\n output subnet = $sub\n}", "output 'subnet' cannot be declared in a loop"},
 	}
 
 	for i, tcase := range tcases {
@@ -156,7 +190,7 @@ func TestResolveMissingHolesPass(t *testing.T) {
 	create instance name={redis.prod} id={redis.prod} count=3`)
 
 	var count int
-	cenv := NewEnv().WithMissingHolesFunc(func(in string, paramPaths []string, optional bool) string {
+	cenv := NewEnv().WithMissingHolesFunc(func(in string, paramPaths []string, optional bool, _ *env.Param) string {
 		count++
 		switch in {
 		case "instance.subnet":
@@ -208,10 +242,76 @@ func TestResolveMissingHolesPass(t *testing.T) {
 	)
 }
 
+func TestResolveMissingHolesWithParamDeclarationsPass(t *testing.T) {
+	text := `param instance.type: string = t3.micro enum(t2.micro, t3.micro) description "Type of the instance"
+param instance.count: int = 2
+param vpc.cidr: cidr
+create instance type={instance.type} count={instance.count} name=redis
+create vpc cidr={vpc.cidr}`
+
+	var prompted []*env.Param
+	cenv := NewEnv().WithMissingHolesFunc(func(in string, paramPaths []string, optional bool, param *env.Param) string {
+		prompted = append(prompted, param)
+		if in == "vpc.cidr" {
+			return "10.0.0.0/16"
+		}
+		return ""
+	}).Build()
+
+	pass := newMultiPass(resolveHolesPass, resolveMissingHolesPass)
+	tpl, _, err := pass.compile(MustParse(text), cenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expPrompted := []*env.Param{
+		{Name: "instance.count", Type: "int", Default: 2},
+		{Name: "instance.type", Type: "string", Default: "t3.micro", Enum: []string{"t2.micro", "t3.micro"}, Description: "Type of the instance"},
+		{Name: "vpc.cidr", Type: "cidr"},
+	}
+	if got, want := prompted, expPrompted; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+	assertCmdParams(t, tpl,
+		map[string]interface{}{"type": "t3.micro", "count": 2, "name": "redis"},
+		map[string]interface{}{"cidr": "10.0.0.0/16"},
+	)
+
+	tcases := []struct {
+		fillers, defaults map[string]interface{}
+		expErr            string
+	}{
+		{fillers: map[string]interface{}{"vpc.cidr": "10.0.0.0/16"}},
+		{defaults: map[string]interface{}{"vpc.cidr": "10.0.0.0/16", "instance.type": "m4.large", "instance.count": "two"}},
+		{fillers: map[string]interface{}{"vpc.cidr": "10.0.0.0/16"}, defaults: map[string]interface{}{"vpc.cidr": "10.0.0"}},
+		{fillers: map[string]interface{}{"vpc.cidr": "10.0.0.0/16", "instance.type": "m4.large"}, expErr: "line 1, column 1: param 'instance.type': expecting one of t2.micro, t3.micro, got 'm4.large'"},
+		{fillers: map[string]interface{}{"vpc.cidr": "10.0.0.0/16", "instance.count": "two"}, expErr: "line 2, column 1: param 'instance.count': expecting an int, got 'two'"},
+		{fillers: map[string]interface{}{"vpc.cidr": "10.0.0"}, expErr: "line 3, column 1: param 'vpc.cidr': expecting a CIDR, got '10.0.0'"},
+	}
+	for i, tcase := range tcases {
+		cenv := NewEnv().Build()
+		cenv.Push(env.FILLERS, tcase.fillers)
+		cenv.Push(env.DEFAULTS, tcase.defaults)
+		tpl, _, err := pass.compile(MustParse(text), cenv)
+		if tcase.expErr != "" {
+			if err == nil || err.Error() != tcase.expErr {
+				t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		assertCmdParams(t, tpl,
+			map[string]interface{}{"type": "t3.micro", "count": 2, "name": "redis"},
+			map[string]interface{}{"cidr": "10.0.0.0/16"},
+		)
+	}
+}
+
 func TestResolveMissingSuggestedPass(t *testing.T) {
 	var count int
 	tpl := `create instance count=1 subnet=sub-1234 image=ami-1a17137a type=t2.nano name=my-instance securitygroup=@my-sec-group`
-	buildingEnv := NewEnv().WithMissingHolesFunc(func(in string, paramPaths []string, optional bool) string {
+	buildingEnv := NewEnv().WithMissingHolesFunc(func(in string, paramPaths []string, optional bool, _ *env.Param) string {
 		count++
 		if !optional {
 			t.Fatalf("unexepected required parameter %s: %v", in, paramPaths)
@@ -303,6 +403,109 @@ func TestResolveAliasPass(t *testing.T) {
 	assertCmdParams(t, tpl, map[string]interface{}{"subnet": "sub-12345", "ami": "ami-12345", "count": 3})
 }
 
+func TestResolveConditionsPass(t *testing.T) {
+	cenv := NewEnv().WithExistsFunc(func(entity, name string) (bool, error) {
+		return (entity == "securitygroup" && name == "my-sg") || (entity == "" && name == "my-vpc"), nil
+	}).Build()
+	tcases := []struct {
+		tpl    string
+		expect bool
+	}{
+		{"if exists securitygroup @my-sg {\n create instance\n}", true},
+		{"if exists @my-vpc {\n create instance\n}", true},
+		{"if exists @my-sg {\n create instance\n}", false},
+		{"if exists vpc @my-vpc {\n create instance\n}", false},
+	}
+
+	for i, tcase := range tcases {
+		tpl, _, err := resolveConditionsPass(MustParse(tcase.tpl), cenv)
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		got, err := tpl.Statements[0].Node.(*ast.IfNode).Condition.Eval()
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if want := tcase.expect; got != want {
+			t.Fatalf("%d: got %t, want %t", i+1, got, want)
+		}
+	}
+}
+
+func TestResolveIncludesPass(t *testing.T) {
+	files := map[string]string{
+		"/tpl/main.aws":                "include network/vpc.aws with cidr={main.cidr} name=prod",
+		"/tpl/network/vpc.aws":         "vpc = create vpc cidr={cidr} name={name}-vpc\ninclude 'subnet.aws' with vpc=$vpc",
+		"/tpl/network/subnet.aws":      "create subnet vpc={vpc} cidr={subnet.cidr}",
+		"/tpl/cycle.aws":               "create vpc\ninclude ./loop/cycle.aws",
+		"/tpl/loop/cycle.aws":          "include ../cycle.aws",
+		"https://host/tpl/main.aws":    "include sub/vpc.aws with cidr=10.0.0.0/16",
+		"https://host/tpl/sub/vpc.aws": "create vpc cidr={cidr}",
+		"https://host/tpl/secret.aws":  "create vpc name=env(SECRET)",
+	}
+	includeFunc := func(path string) ([]byte, string, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, path, fmt.Errorf("%s not found", path)
+		}
+		return []byte(content), path, nil
+	}
+
+	tcases := []struct {
+		path, tpl string
+		expect    string
+		expErr    string
+	}{
+		{
+			path:   "/tpl/main.aws",
+			tpl:    files["/tpl/main.aws"],
+			expect: "vpc = create vpc cidr={main.cidr} name=prod-vpc\ncreate subnet cidr={subnet.cidr} vpc=$vpc",
+		},
+		{
+			path:   "/tpl/other.aws",
+			tpl:    "for name in [a,b] {\n  include network/subnet.aws with vpc=vpc-1234 subnet.cidr=$name\n}",
+			expect: "for name in [a,b] {\n\tcreate subnet cidr=$name vpc=vpc-1234\n}",
+		},
+		{
+			path:   "https://host/tpl/main.aws",
+			tpl:    files["https://host/tpl/main.aws"],
+			expect: "create vpc cidr=10.0.0.0/16",
+		},
+		{
+			path:   "",
+			tpl:    "include /tpl/network/subnet.aws with vpc=@myvpc",
+			expect: "create subnet cidr={subnet.cidr} vpc=@myvpc",
+		},
+		{path: "/tpl/cycle.aws", tpl: files["/tpl/cycle.aws"], expErr: "cycle detected: /tpl/cycle.aws -> /tpl/loop/cycle.aws -> /tpl/cycle.aws"},
+		{path: "/tpl/main.aws", tpl: "include unknown.aws", expErr: "/tpl/unknown.aws not found"},
+		{path: "/tpl/main.aws", tpl: "include https://host/tpl/secret.aws", expErr: "include https://host/tpl/secret.aws: env: disabled in remote templates"},
+	}
+
+	for i, tcase := range tcases {
+		cenv := NewEnv().WithIncludeFunc(includeFunc).WithTemplatePath(tcase.path).Build()
+		tpl, _, err := resolveIncludesPass(MustParse(tcase.tpl), cenv)
+		if tcase.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.expErr) {
+				t.Fatalf("%d: got %v, expected %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := tpl.String(), tcase.expect; got != want {
+			t.Fatalf("%d: got\n%s\nwant\n%s", i+1, got, want)
+		}
+	}
+
+	t.Run("no loader", func(t *testing.T) {
+		_, _, err := resolveIncludesPass(MustParse("include vpc.aws"), NewEnv().Build())
+		if err == nil || !strings.Contains(err.Error(), "no template loader") {
+			t.Fatalf("expected loader error, got %v", err)
+		}
+	})
+}
+
 func TestResolveHolesPass(t *testing.T) {
 	tpl := MustParse("create instance count={instance.count} type={instance.type}")
 
@@ -351,6 +554,74 @@ func TestInlineVariableWithValue(t *testing.T) {
 	}
 }
 
+func TestResolveFunctionsPass(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awless-functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "x.sh"), []byte("#!/bin/sh"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("AWLESS_TEST_FUNCTIONS", "/home/test")
+	defer os.Unsetenv("AWLESS_TEST_FUNCTIONS")
+
+	tcases := []struct {
+		tpl, path  string
+		allowLocal bool
+		expParams  map[string]interface{}
+		expErr     string
+	}{
+		{tpl: "create vpc name=lower(MyVPC)", expParams: map[string]interface{}{"name": "myvpc"}},
+		{tpl: "create subnet cidr=cidrsubnet({vpc.cidr}, 8, 2)", expParams: map[string]interface{}{"cidr": "10.0.2.0/24"}},
+		{tpl: "create subnet cidr=cidrsubnet(10.0.0.0/16, 4, 15)", expParams: map[string]interface{}{"cidr": "10.0.240.0/20"}},
+		{tpl: "create subnet cidr=cidrsubnet('fd00:fd12:3456:7890::/56', 16, 162)", expParams: map[string]interface{}{"cidr": "fd00:fd12:3456:7800:a200::/72"}},
+		{tpl: "create instance name=join(-, [web, lower(PROD), 1])", expParams: map[string]interface{}{"name": "web-prod-1"}},
+		{tpl: "create instance userdata=base64(file(x.sh))", expParams: map[string]interface{}{"userdata": "IyEvYmluL3No"}},
+		{tpl: "home = env(AWLESS_TEST_FUNCTIONS)\ncreate instance name=$home", expParams: map[string]interface{}{"name": "/home/test"}},
+		{tpl: "create instance name=lower($inst)", expParams: map[string]interface{}{}},
+		{tpl: "create instance name=upper(web)", expErr: "create instance: unknown function 'upper'"},
+		{tpl: "create instance name=lower(a, b)", expErr: "lower: expecting 1 argument(s), got 2"},
+		{tpl: "create subnet cidr=cidrsubnet(10.0.0.0/16, 8, 256)", expErr: "network number 256 does not fit in 8 bits"},
+		{tpl: "create subnet cidr=cidrsubnet(10.0.0.0/16, 24, 1)", expErr: "cannot extend prefix of length 16 by 24 bits"},
+		{tpl: "create instance name=env(AWLESS_TEST_UNSET_VARIABLE)", expErr: "environment variable 'AWLESS_TEST_UNSET_VARIABLE' is not set"},
+		{tpl: "create instance name=lower(WEB)", path: "repo:create_instance", expParams: map[string]interface{}{"name": "web"}},
+		{tpl: "create instance name=env(AWLESS_TEST_FUNCTIONS)", path: "https://example.com/main.aws", expErr: "env: disabled in remote templates"},
+		{tpl: "create instance userdata=file(/etc/hostname)", path: "repo:create_instance", expErr: "file: disabled in remote templates"},
+		{tpl: "create instance name=env(AWLESS_TEST_FUNCTIONS)", path: "repo:create_instance", allowLocal: true, expParams: map[string]interface{}{"name": "/home/test"}},
+	}
+
+	for i, tcase := range tcases {
+		path := filepath.Join(dir, "main.aws")
+		if tcase.path != "" {
+			path = tcase.path
+		}
+		cenv := NewEnv().WithTemplatePath(path).WithLocalFunctionsAllowed(tcase.allowLocal).Build()
+		cenv.Push(env.FILLERS, map[string]interface{}{"vpc.cidr": "10.0.0.0/16"})
+		tpl := MustParse(tcase.tpl)
+		tpl, _, err := resolveHolesPass(tpl, cenv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tpl, _, err = inlineVariableValuePass(tpl, cenv); err != nil {
+			t.Fatal(err)
+		}
+		tpl, _, err = resolveFunctionsPass(tpl, cenv)
+		if tcase.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.expErr) {
+				t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := tpl.CommandNodesIterator()[0].ToDriverParams(), tcase.expParams; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %#v, want %#v", i+1, got, want)
+		}
+	}
+}
+
 func TestDefaultEnvWithNilFunc(t *testing.T) {
 	text := "create instance name={instance.name} subnet=@mysubnet"
 	env := NewEnv().Build()
@@ -368,6 +639,37 @@ func TestDefaultEnvWithNilFunc(t *testing.T) {
 	}
 }
 
+func TestCompileErrorsPosition(t *testing.T) {
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		return awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))()
+	}).Build()
+
+	tcases := []struct {
+		tpl    string
+		expErr string
+	}{
+		{
+			tpl:    "vpc = create vpc cidr=10.0.0.0/16\n\ncreate subnet vpc=$vpc cidr=10.0.0.0/24 unknown=param",
+			expErr: "line 3, column 41: create subnet: unexpected param(s): unknown",
+		},
+		{
+			tpl:    "create vpc cidr=10.0.0.0/16\nfor name in [a, b] {\n  create subnet vpc=$vpc cidr=10.0.0.0/24 name=$name\n}",
+			expErr: "line 3, column 3: using reference '$vpc' but 'vpc' is undefined in template",
+		},
+		{
+			tpl:    "create vpc cidr=10.0.0.0/16\ncreate subnet vpc=vpc-1 cidr=10.0.0.0/24 name=upper(sub)",
+			expErr: "line 2, column 1: create subnet: unknown function 'upper'",
+		},
+	}
+
+	for i, tcase := range tcases {
+		_, _, err := Compile(MustParse(tcase.tpl), cenv, NewRunnerCompileMode)
+		if err == nil || !strings.Contains(err.Error(), tcase.expErr) {
+			t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expErr)
+		}
+	}
+}
+
 func TestCmdErr(t *testing.T) {
 	tcases := []struct {
 		cmd    *ast.CommandNode
@@ -380,6 +682,7 @@ func TestCmdErr(t *testing.T) {
 		{&ast.CommandNode{Action: "create", Entity: "instance"}, errors.New("my error"), nil, errors.New("create instance: my error")},
 		{nil, "my error", nil, errors.New("my error")},
 		{&ast.CommandNode{Action: "create", Entity: "instance"}, "my error with %s %d", []interface{}{"Donald", 1}, errors.New("create instance: my error with Donald 1")},
+		{&ast.CommandNode{Action: "create", Entity: "instance", Pos: ast.Position{Line: 3, Column: 2}}, "my error", nil, &Error{Line: 3, Column: 2, Msg: "create instance: my error"}},
 	}
 	for i, tcase := range tcases {
 		if got, want := cmdErr(tcase.cmd, tcase.err, tcase.ifaces...), tcase.expErr; !reflect.DeepEqual(got, want) {
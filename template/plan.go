@@ -0,0 +1,103 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plan is a compiled and dry run template, with its holes and aliases
+// resolved, saved to be applied later exactly as planned
+type Plan struct {
+	Version  string    `json:"version"`
+	Created  time.Time `json:"created"`
+	Profile  string    `json:"profile"`
+	Region   string    `json:"region"`
+	Path     string    `json:"path,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Template string    `json:"template"`
+	// Resources holds the fingerprints, per ID, of the resources of
+	// the local graph referenced by the template when planned
+	Resources map[string]string `json:"resources,omitempty"`
+	// Exists holds the existence, per entity and name, of the resources
+	// of the template exists conditions as resolved when planned
+	Exists map[string]bool `json:"exists,omitempty"`
+}
+
+func ParsePlan(content []byte) (*Plan, error) {
+	plan := &Plan{}
+	if err := json.Unmarshal(content, plan); err != nil {
+		return nil, fmt.Errorf("invalid plan: %s", err)
+	}
+	if plan.Template == "" {
+		return nil, errors.New("invalid plan: empty template")
+	}
+	return plan, nil
+}
+
+func (p *Plan) Marshal() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// ExistsFunc resolves the exists conditions as when planned, failing
+// on the conditions not resolved when planned
+func (p *Plan) ExistsFunc() func(entity, name string) (bool, error) {
+	return func(entity, name string) (bool, error) {
+		exists, ok := p.Exists[existsKey(entity, name)]
+		if !ok {
+			return false, fmt.Errorf("existence of %s not resolved when planned", existsKey(entity, name))
+		}
+		return exists, nil
+	}
+}
+
+// RecordExistsFunc returns the lookup recording in exists the existence
+// it resolves, to be saved in a plan
+func RecordExistsFunc(lookup func(entity, name string) (bool, error), exists map[string]bool) func(entity, name string) (bool, error) {
+	return func(entity, name string) (bool, error) {
+		found, err := lookup(entity, name)
+		if err == nil {
+			exists[existsKey(entity, name)] = found
+		}
+		return found, err
+	}
+}
+
+func existsKey(entity, name string) string {
+	return strings.TrimSpace(fmt.Sprintf("%s @%s", entity, name))
+}
+
+// ParamValues returns the unique string values given as params to the commands
+// of the template, lists being flattened. They are the candidate references
+// to existing resources
+func (s *Template) ParamValues() (values []string) {
+	unique := make(map[string]struct{})
+	var add func(v interface{})
+	add = func(v interface{}) {
+		switch vv := v.(type) {
+		case string:
+			unique[vv] = struct{}{}
+		case []string:
+			for _, e := range vv {
+				add(e)
+			}
+		case []interface{}:
+			for _, e := range vv {
+				add(e)
+			}
+		}
+	}
+	for _, cmd := range s.CommandNodesIterator() {
+		for _, v := range cmd.ToDriverParams() {
+			add(v)
+		}
+	}
+	for v := range unique {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return
+}
@@ -0,0 +1,91 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wallix/awless/aws/spec"
+	"github.com/wallix/awless/template/env"
+)
+
+func TestPlanTemplateIsResolved(t *testing.T) {
+	tpl := MustParse("subnet = create subnet cidr={subnet.cidr} vpc=@prod-vpc name={subnet.name}\ncreate instance subnet=$subnet type=t2.micro securitygroup=[@web,sg-1234] name=web")
+	cenv := NewEnv().WithAliasFunc(func(p, v string) string {
+		return map[string]string{"prod-vpc": "vpc-1234", "web": "sg-5678"}[v]
+	}).WithLookupCommandFunc(func(tokens ...string) interface{} {
+		return awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))()
+	}).Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"subnet.cidr": "10.0.0.0/24", "subnet.name": "my subnet", "instance.count": 1, "instance.distro": "amazonlinux"})
+
+	compiled, _, err := Compile(tpl, cenv, TestCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := compiled.ParamValues(), []string{"10.0.0.0/24", "amazonlinux", "my subnet", "sg-1234", "sg-5678", "t2.micro", "vpc-1234", "web"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	plan := &Plan{Template: compiled.String()}
+	content, err := plan.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePlan(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsed, err := Parse(parsed.Template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reparsed.String(), compiled.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if got, want := len(reparsed.HoleNames()), 0; got != want {
+		t.Fatalf("got %d holes, want %d", got, want)
+	}
+
+	if _, err := ParsePlan([]byte(`{"profile": "default"}`)); err == nil {
+		t.Fatal("expected error got none")
+	}
+}
+
+func TestPlanAppliesExistsConditionsAsPlanned(t *testing.T) {
+	text := "unless exists vpc @prod-vpc {\n  create vpc cidr=10.0.0.0/16 name=prod-vpc\n}\nif exists subnet @sub-a {\n  create tag key=Created resource=sub-a value=now(2006)\n}"
+	lookup := func(tokens ...string) interface{} {
+		return awsspec.MockAWSSessionFactory.Build(strings.Join(tokens, ""))()
+	}
+	existing := map[string]bool{"prod-vpc": false, "sub-a": true}
+
+	plan := &Plan{Exists: make(map[string]bool)}
+	cenv := NewEnv().WithLookupCommandFunc(lookup).WithExistsFunc(RecordExistsFunc(func(entity, name string) (bool, error) {
+		return existing[name], nil
+	}, plan.Exists)).Build()
+	compiled, _, err := Compile(MustParse(text), cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Template = compiled.String()
+	if got, want := plan.Exists, map[string]bool{"vpc @prod-vpc": false, "subnet @sub-a": true}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	existing["prod-vpc"] = true
+	cenv = NewEnv().WithLookupCommandFunc(lookup).WithExistsFunc(plan.ExistsFunc()).Build()
+	recompiled, _, err := Compile(MustParse(plan.Template), cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := recompiled.String(), plan.Template; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if got, want := len(recompiled.ExpandedCommandNodesIterator()), 2; got != want {
+		t.Fatalf("got %d, want %d commands", got, want)
+	}
+
+	_, _, err = Compile(MustParse("if exists instance @web {\n  create tag key=k resource=web value=v\n}"), cenv, NewRunnerCompileMode)
+	if err == nil || !strings.Contains(err.Error(), "existence of instance @web not resolved when planned") {
+		t.Fatalf("got %v, want not planned error", err)
+	}
+}
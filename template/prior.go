@@ -0,0 +1,134 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wallix/awless/template/internal/ast"
+)
+
+// PriorStateFunc returns, given the entity and the params of an update command,
+// the values the updated params had on the resource before the command ran.
+// Params with an unknown prior value are omitted
+type PriorStateFunc func(entity string, params map[string]interface{}) (map[string]interface{}, error)
+
+// updateKeys holds, per entity whose updates are reverted restoring their
+// prior state, the params identifying the updated resource.
+// Security groups updates are reverted inverting their inbound or outbound action
+var updateKeys = map[string][]string{
+	"bucket":       {"name"},
+	"instance":     {"id"},
+	"record":       {"name", "type", "zone"},
+	"scalinggroup": {"name"},
+	"subnet":       {"id"},
+	"targetgroup":  {"id"},
+}
+
+// priorCoveredParams holds, per entity, the updated params restored by the prior
+// value of another param. The whole website configuration of a bucket is restored
+// from its prior public-website state
+var priorCoveredParams = map[string]map[string]string{
+	"bucket": {"enforce-https": "public-website", "index-suffix": "public-website", "redirect-hostname": "public-website"},
+	"record": {"value": "values"},
+}
+
+// IsCompletePriorState returns whether the prior state holds the prior values of
+// all the params updated on the resource, restoring it reverting the whole update
+func IsCompletePriorState(entity string, params, prior map[string]interface{}) bool {
+	keys, ok := updateKeys[entity]
+	if !ok || len(prior) == 0 {
+		return false
+	}
+	for param := range params {
+		if contains(keys, param) {
+			continue
+		}
+		if _, ok := prior[param]; ok {
+			continue
+		}
+		if covering, ok := priorCoveredParams[entity][param]; ok {
+			if _, ok := prior[covering]; ok {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// CapturePriorState snapshots the prior state of the resources updated by the
+// commands of the template, before it runs. Resources referenced by the template
+// variables are created by the template itself and are not captured.
+// Capture failures are returned once all commands are processed
+func (s *Template) CapturePriorState(fn PriorStateFunc) error {
+	var errs []string
+	for _, cmd := range s.CommandNodesIterator() {
+		keys, ok := updateKeys[cmd.Entity]
+		if cmd.Action != "update" || !ok || cmd.CmdSkipped || hasRefs(cmd, keys) {
+			continue
+		}
+		prior, err := fn(cmd.Entity, cmd.ToDriverParams())
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", locate(cmd.Pos, fmt.Sprintf("%s %s", cmd.Action, cmd.Entity)), err))
+			continue
+		}
+		for _, k := range keys {
+			delete(prior, k)
+		}
+		if len(prior) > 0 {
+			cmd.CmdPriorState = prior
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func hasRefs(cmd *ast.CommandNode, keys []string) bool {
+	for _, k := range keys {
+		if withRefs, ok := cmd.Params[k].(ast.WithRefs); ok && len(withRefs.GetRefs()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// revertUpdateParams returns the params of the update restoring the prior state of the resource
+func revertUpdateParams(cmd *ast.CommandNode) (params []string) {
+	for _, k := range updateKeys[cmd.Entity] {
+		if v, ok := cmd.Params[k]; ok {
+			params = append(params, fmt.Sprintf("%s=%s", k, v.String()))
+		}
+	}
+	var prior []string
+	for k, v := range cmd.CmdPriorState {
+		prior = append(prior, fmt.Sprintf("%s=%s", k, formatPriorValue(v)))
+	}
+	sort.Strings(prior)
+	return append(params, prior...)
+}
+
+func formatPriorValue(v interface{}) string {
+	switch vv := v.(type) {
+	case []string:
+		var elems []string
+		for _, e := range vv {
+			elems = append(elems, quoteParamIfNeeded(e))
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	case []interface{}:
+		var elems []string
+		for _, e := range vv {
+			elems = append(elems, formatPriorValue(e))
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	case float64: // prior states loaded from the JSON logs
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	default:
+		return quoteParamIfNeeded(vv)
+	}
+}
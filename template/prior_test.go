@@ -0,0 +1,43 @@
+package template
+
+import "testing"
+
+func TestUpdatesRevertibleOnlyWithCompletePriorState(t *testing.T) {
+	prior := map[string]map[string]interface{}{
+		"targetgroup": {"healthcheckpath": "/health"},
+		"instance":    {"type": "t2.micro"},
+		"bucket":      {"public-website": false},
+	}
+	priorStateFunc := func(entity string, params map[string]interface{}) (map[string]interface{}, error) {
+		captured := make(map[string]interface{})
+		for k, v := range prior[entity] {
+			if _, ok := params[k]; ok || k == "public-website" {
+				captured[k] = v
+			}
+		}
+		return captured, nil
+	}
+
+	tcases := []struct {
+		in         string
+		revertible bool
+	}{
+		{in: "update targetgroup healthcheckpath=/ id=arn:tg", revertible: true},
+		{in: "update targetgroup healthcheckpath=/ id=arn:tg stickiness=true", revertible: false},
+		{in: "update targetgroup deregistrationdelay=10 id=arn:tg", revertible: false},
+		{in: "update instance id=i-1 type=t2.large", revertible: true},
+		{in: "update instance id=i-1 lock=true type=t2.large", revertible: false},
+		{in: "update bucket index-suffix=index.html name=my-bucket public-website=true", revertible: true},
+		{in: "update bucket acl=public-read name=my-bucket public-website=true", revertible: false},
+	}
+
+	for i, tcase := range tcases {
+		tpl := MustParse(tcase.in)
+		if err := tpl.CapturePriorState(priorStateFunc); err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := IsRevertible(tpl), tcase.revertible; got != want {
+			t.Fatalf("%d: got %t, want %t", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package template
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	ReportOK      = "ok"
+	ReportKO      = "ko"
+	ReportSkipped = "skipped"
+)
+
+// Report is a machine readable summary of a template execution. Duration is
+// the wall clock time of the run when known, otherwise the sum of the commands
+// durations. Error is set when the template failed before running
+type Report struct {
+	ID           string           `json:"id"`
+	Path         string           `json:"path,omitempty"`
+	Message      string           `json:"message,omitempty"`
+	Profile      string           `json:"profile,omitempty"`
+	Locale       string           `json:"region,omitempty"`
+	Success      bool             `json:"success"`
+	Error        string           `json:"error,omitempty"`
+	Started      *time.Time       `json:"started,omitempty"`
+	Ended        *time.Time       `json:"ended,omitempty"`
+	Duration     float64          `json:"duration"`
+	RolledBackBy string           `json:"rolledBackBy,omitempty"`
+	Commands     []*CommandReport `json:"commands"`
+	Outputs      interface{}      `json:"outputs,omitempty"`
+}
+
+// CommandReport is the result of a command of a template execution.
+// Durations are in seconds. RevertID is the ID to give to `awless revert`
+// when the command can be reverted
+type CommandReport struct {
+	Command  string                 `json:"command"`
+	Line     int                    `json:"line,omitempty"`
+	Params   map[string]interface{} `json:"params"`
+	Status   string                 `json:"status"`
+	Result   interface{}            `json:"result,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Attempts int                    `json:"attempts,omitempty"`
+	Duration float64                `json:"duration"`
+	RevertID string                 `json:"revertID,omitempty"`
+}
+
+func NewReport(t *TemplateExecution) *Report {
+	report := &Report{
+		ID:           t.ID,
+		Path:         t.Path,
+		Message:      t.Message,
+		Profile:      t.Profile,
+		Locale:       t.Locale,
+		Success:      true,
+		RolledBackBy: t.RolledBackBy,
+		Commands:     []*CommandReport{},
+	}
+	if outputs := t.Outputs(); len(outputs) > 0 {
+		report.Outputs = outputs
+	}
+
+	var total time.Duration
+	for _, cmd := range t.CommandNodesIterator() {
+		cmdReport := &CommandReport{
+			Command:  fmt.Sprintf("%s %s", cmd.Action, cmd.Entity),
+			Line:     cmd.Pos.Line,
+			Params:   cmd.ToDriverParams(),
+			Status:   ReportOK,
+			Result:   cmd.CmdResult,
+			Attempts: cmd.CmdAttempts,
+			Duration: cmd.CmdDuration.Seconds(),
+		}
+		switch {
+		case cmd.CmdSkipped:
+			cmdReport.Status = ReportSkipped
+		case cmd.CmdErr != nil:
+			cmdReport.Status = ReportKO
+			cmdReport.Error = cmd.CmdErr.Error()
+			report.Success = false
+		}
+		if isRevertible(cmd) {
+			cmdReport.RevertID = t.ID
+		}
+		total += cmd.CmdDuration
+		report.Commands = append(report.Commands, cmdReport)
+	}
+	report.Duration = total.Seconds()
+	if !t.Started.IsZero() && !t.Ended.IsZero() {
+		started, ended := t.Started.UTC(), t.Ended.UTC()
+		report.Started, report.Ended = &started, &ended
+		report.Duration = ended.Sub(started).Seconds()
+	}
+
+	return report
+}
+
+// NewFailedReport reports a template execution that failed before running
+// (ex: compilation or dry run failure), its commands being skipped
+func NewFailedReport(t *TemplateExecution, err error) *Report {
+	report := NewReport(t)
+	report.Success = false
+	report.Error = err.Error()
+	for _, cmd := range report.Commands {
+		cmd.Status, cmd.Result, cmd.RevertID = ReportSkipped, nil, ""
+	}
+	return report
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite, each command being a test case
+func (r *Report) WriteJUnit(w io.Writer) error {
+	name := r.Path
+	if name == "" {
+		name = r.ID
+	}
+	suite := junitTestSuite{Name: name, Tests: len(r.Commands), Time: r.Duration, SystemErr: r.Error}
+	if r.Error != "" {
+		suite.Errors = 1
+	}
+	suite.Properties = []junitProperty{{Name: "id", Value: r.ID}, {Name: "profile", Value: r.Profile}, {Name: "region", Value: r.Locale}}
+	if r.RolledBackBy != "" {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "rolledBackBy", Value: r.RolledBackBy})
+	}
+
+	for i, cmd := range r.Commands {
+		tcase := junitTestCase{ClassName: name, Name: fmt.Sprintf("%d. %s", i+1, cmd.Command), Time: cmd.Duration}
+		if cmd.Line > 0 {
+			tcase.Name = fmt.Sprintf("%s (line %d)", tcase.Name, cmd.Line)
+		}
+		switch cmd.Status {
+		case ReportSkipped:
+			suite.Skipped++
+			tcase.Skipped = &junitSkipped{}
+		case ReportKO:
+			suite.Failures++
+			tcase.Failure = &junitFailure{Message: cmd.Error, Type: cmd.Command, Text: cmd.Error}
+		}
+		if cmd.Result != nil {
+			tcase.SystemOut = fmt.Sprint(cmd.Result)
+		}
+		if cmd.RevertID != "" {
+			tcase.Properties = []junitProperty{{Name: "revertID", Value: cmd.RevertID}}
+		}
+		suite.TestCases = append(suite.TestCases, tcase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr,omitempty"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+	SystemErr  string          `xml:"system-err,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName  string          `xml:"classname,attr"`
+	Name       string          `xml:"name,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitFailure   `xml:"failure,omitempty"`
+	Skipped    *junitSkipped   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct{}
@@ -0,0 +1,127 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	tpl := MustParse("create vpc cidr=10.0.0.0/16\ncreate subnet cidr=10.0.0.0/24 vpc=vpc-1234\ncheck instance id=i-1234 state=running timeout=10")
+	cmds := tpl.CommandNodesIterator()
+	cmds[0].CmdResult, cmds[0].CmdDuration = "vpc-1234", 2*time.Second
+	cmds[1].CmdErr, cmds[1].CmdDuration, cmds[1].CmdAttempts = errors.New("invalid cidr"), 500*time.Millisecond, 3
+	cmds[2].CmdSkipped = true
+	tplExec := &TemplateExecution{Template: tpl, Path: "infra.aws", Profile: "default", Locale: "eu-west-1"}
+	tplExec.ID = "01BQ6ZQ4S2X5P1BXEAN0XJ6JDR"
+
+	report := NewReport(tplExec)
+	if report.Success {
+		t.Fatal("expecting failed report")
+	}
+	if got, want := report.Duration, 2.5; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	expected := []*CommandReport{
+		{Command: "create vpc", Line: 1, Params: map[string]interface{}{"cidr": "10.0.0.0/16"}, Status: ReportOK, Result: "vpc-1234", Duration: 2, RevertID: "01BQ6ZQ4S2X5P1BXEAN0XJ6JDR"},
+		{Command: "create subnet", Line: 2, Params: map[string]interface{}{"cidr": "10.0.0.0/24", "vpc": "vpc-1234"}, Status: ReportKO, Error: "invalid cidr", Attempts: 3, Duration: 0.5},
+		{Command: "check instance", Line: 3, Params: map[string]interface{}{"id": "i-1234", "state": "running", "timeout": 10}, Status: ReportSkipped},
+	}
+	for i := range expected {
+		if got, want := report.Commands[i], expected[i]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %#v, want %#v", i+1, got, want)
+		}
+	}
+
+	var junit bytes.Buffer
+	if err := report.WriteJUnit(&junit); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		`<testsuite name="infra.aws" tests="3" failures="1" skipped="1" time="2.5">`,
+		`<property name="region" value="eu-west-1"></property>`,
+		`<testcase classname="infra.aws" name="1. create vpc (line 1)" time="2">`,
+		`<property name="revertID" value="01BQ6ZQ4S2X5P1BXEAN0XJ6JDR"></property>`,
+		`<system-out>vpc-1234</system-out>`,
+		`<failure message="invalid cidr" type="create subnet">invalid cidr</failure>`,
+		`<skipped></skipped>`,
+	} {
+		if !strings.Contains(junit.String(), exp) {
+			t.Fatalf("junit report: expecting %s in\n%s", exp, junit.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{`"revertID": "01BQ6ZQ4S2X5P1BXEAN0XJ6JDR"`, `"status": "ko"`, `"error": "invalid cidr"`, `"duration": 0.5`} {
+		if !strings.Contains(js.String(), exp) {
+			t.Fatalf("json report: expecting %s in\n%s", exp, js.String())
+		}
+	}
+}
+
+func TestReportDurations(t *testing.T) {
+	tpl := MustParse("create vpc cidr=10.0.0.0/16\ncreate vpc cidr=10.1.0.0/16")
+	cmds := tpl.CommandNodesIterator()
+	cmds[0].CmdResult, cmds[0].CmdDuration = "vpc-1", 2*time.Second
+	cmds[1].CmdResult, cmds[1].CmdDuration = "vpc-2", 2*time.Second
+	started := time.Date(2017, 9, 1, 10, 0, 0, 0, time.UTC)
+	tplExec := &TemplateExecution{Template: tpl, Started: started, Ended: started.Add(2500 * time.Millisecond)}
+
+	report := NewReport(tplExec)
+	if got, want := report.Duration, 2.5; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{`"started": "2017-09-01T10:00:00Z"`, `"ended": "2017-09-01T10:00:02.5Z"`, `"duration": 2.5`} {
+		if !strings.Contains(js.String(), exp) {
+			t.Fatalf("json report: expecting %s in\n%s", exp, js.String())
+		}
+	}
+}
+
+func TestFailedReport(t *testing.T) {
+	tpl := MustParse("create vpc cidr=10.0.0.0/16\ncreate subnet cidr=10.0.0.0/24 vpc=$unknown")
+	tplExec := &TemplateExecution{Template: tpl, Path: "infra.aws"}
+
+	report := NewFailedReport(tplExec, errors.New("dry run failed: invalid cidr"))
+	if report.Success {
+		t.Fatal("expecting failed report")
+	}
+	for i, cmd := range report.Commands {
+		if got, want := cmd.Status, ReportSkipped; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+	}
+
+	var junit bytes.Buffer
+	if err := report.WriteJUnit(&junit); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		`<testsuite name="infra.aws" tests="2" failures="0" errors="1" skipped="2" time="0">`,
+		`<system-err>dry run failed: invalid cidr</system-err>`,
+	} {
+		if !strings.Contains(junit.String(), exp) {
+			t.Fatalf("junit report: expecting %s in\n%s", exp, junit.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{`"success": false`, `"error": "dry run failed: invalid cidr"`} {
+		if !strings.Contains(js.String(), exp) {
+			t.Fatalf("json report: expecting %s in\n%s", exp, js.String())
+		}
+	}
+}
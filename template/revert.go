@@ -173,6 +173,8 @@ func (te *Template) Revert() (*Template, error) {
 						}
 						params = append(params, fmt.Sprintf("%s=%v", k, quoteParamIfNeeded(v)))
 					}
+				default:
+					params = revertUpdateParams(cmd)
 				}
 			}
 
@@ -251,7 +253,7 @@ func IsRevertible(t *Template) bool {
 }
 
 func isRevertible(cmd *ast.CommandNode) bool {
-	if cmd.CmdErr != nil {
+	if cmd.CmdErr != nil || cmd.CmdSkipped {
 		return false
 	}
 
@@ -296,6 +298,10 @@ func isRevertible(cmd *ast.CommandNode) bool {
 		return true
 	}
 
+	if _, ok := updateKeys[cmd.Entity]; ok && cmd.Action == "update" {
+		return IsCompletePriorState(cmd.Entity, cmd.ToDriverParams(), cmd.CmdPriorState)
+	}
+
 	if cmd.Entity == "appscalingpolicy" && cmd.Action == "create" {
 		return true
 	}
@@ -310,6 +316,12 @@ func isRevertible(cmd *ast.CommandNode) bool {
 		(cmd.Action == "create" && cmd.Entity == "tag") || (cmd.Action == "create" && cmd.Entity == "route")
 }
 
+// QuoteParamIfNeeded returns the param value as written in a template,
+// quoting it when it is not a simple string
+func QuoteParamIfNeeded(param interface{}) string {
+	return quoteParamIfNeeded(param)
+}
+
 func quoteParamIfNeeded(param interface{}) string {
 	input := fmt.Sprint(param)
 	if ast.SimpleStringValue.MatchString(input) {
@@ -322,3 +334,83 @@ func quoteParamIfNeeded(param interface{}) string {
 		}
 	}
 }
+
+// RevertCommands reverts the commands of the template at the given positions,
+// starting from 1 in the template order. A command whose result, typically a
+// created resource, is used by a revertible command not in the positions
+// can only be reverted along with it, after it
+func (te *Template) RevertCommands(positions []int) (*Template, error) {
+	cmds := te.CommandNodesIterator()
+	selected := make(map[*ast.CommandNode]bool)
+	for _, p := range positions {
+		if p < 1 || p > len(cmds) {
+			return nil, fmt.Errorf("revert: no command %d, expecting 1 to %d", p, len(cmds))
+		}
+		if !isRevertible(cmds[p-1]) {
+			return nil, fmt.Errorf("revert: command %d '%s' is not revertible", p, cmds[p-1])
+		}
+		selected[cmds[p-1]] = true
+	}
+
+	var dependents []string
+	for i, cmd := range cmds {
+		result, ok := cmd.CmdResult.(string)
+		if !selected[cmd] || !ok || result == "" {
+			continue
+		}
+		for j := i + 1; j < len(cmds); j++ {
+			if dep := cmds[j]; !selected[dep] && isRevertible(dep) && usesValue(dep, result) {
+				dependents = append(dependents, fmt.Sprintf("\n\t- command %d '%s' uses %s of command %d", j+1, dep, result, i+1))
+			}
+		}
+	}
+	if len(dependents) > 0 {
+		return nil, fmt.Errorf("revert: commands using resources of reverted commands must be reverted too:%s", strings.Join(dependents, ""))
+	}
+
+	partial := &Template{ID: te.ID, AST: &ast.AST{}}
+	for _, st := range te.Statements {
+		for _, expr := range extractExpressionNodes(st) {
+			if cmd, ok := expr.(*ast.CommandNode); ok && selected[cmd] {
+				partial.Statements = append(partial.Statements, st)
+				break
+			}
+		}
+	}
+	return partial.Revert()
+}
+
+func usesValue(cmd *ast.CommandNode, value string) bool {
+	for _, v := range cmd.ToDriverParams() {
+		switch vv := v.(type) {
+		case []interface{}:
+			for _, e := range vv {
+				if fmt.Sprint(e) == value {
+					return true
+				}
+			}
+		case []string:
+			for _, e := range vv {
+				if e == value {
+					return true
+				}
+			}
+		default:
+			if fmt.Sprint(vv) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RevertiblePositions returns the positions, starting from 1 in the
+// template order, of the commands of the template that can be reverted
+func (te *Template) RevertiblePositions() (positions []int) {
+	for i, cmd := range te.CommandNodesIterator() {
+		if isRevertible(cmd) {
+			positions = append(positions, i+1)
+		}
+	}
+	return
+}
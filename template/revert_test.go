@@ -2,6 +2,7 @@ package template
 
 import (
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -387,6 +388,117 @@ stop containertask cluster=cl deployment-name=dpname type=service`
 	})
 }
 
+func TestRevertUpdatesWithPriorState(t *testing.T) {
+	prior := map[string]map[string]interface{}{
+		"subnet":       {"public": false},
+		"instance":     {"type": "t2.micro"},
+		"record":       {"values": []string{"1.2.3.4", "5.6.7.8"}, "ttl": 300, "name": "ignored"},
+		"scalinggroup": {"max-size": 2000000, "min-size": 1},
+		"bucket":       {"public-website": true, "index-suffix": "home page.html"},
+	}
+	priorStateFunc := func(entity string, params map[string]interface{}) (map[string]interface{}, error) {
+		if entity == "targetgroup" {
+			return nil, errors.New("not synced")
+		}
+		return prior[entity], nil
+	}
+
+	tcases := []struct {
+		in, exp string
+	}{
+		{in: "update subnet id=subnet-1 public=true", exp: "update subnet id=subnet-1 public=false"},
+		{in: "update instance id=i-1 type=t2.large", exp: "update instance id=i-1 type=t2.micro"},
+		{in: "update record name=www.example.com ttl=60 type=A values=9.9.9.9 zone=Z123", exp: "update record name=www.example.com ttl=300 type=A values=[1.2.3.4,5.6.7.8] zone=Z123"},
+		{in: "update scalinggroup max-size=10 min-size=2 name=my-group", exp: "update scalinggroup max-size=2000000 min-size=1 name=my-group"},
+		{in: "update bucket name=my-bucket public-website=false", exp: "update bucket index-suffix='home page.html' name=my-bucket public-website=true"},
+		{in: "update targetgroup healthcheckpath=/ id=arn:tg", exp: ""},
+		{in: "vpc = create vpc\nupdate instance id=$vpc type=t2.large", exp: "delete vpc id=vpc-1"},
+	}
+
+	for i, tcase := range tcases {
+		tpl := MustParse(tcase.in)
+		err := tpl.CapturePriorState(priorStateFunc)
+		if strings.Contains(tcase.in, "targetgroup") {
+			if err == nil || !strings.Contains(err.Error(), "not synced") {
+				t.Fatalf("%d: got %v, want not synced error", i+1, err)
+			}
+		} else if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		for _, cmd := range tpl.CommandNodesIterator() {
+			cmd.CmdResult = "vpc-1"
+		}
+
+		tplExec := &TemplateExecution{Template: tpl}
+		b, err := tplExec.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		logged := &TemplateExecution{}
+		if err = logged.UnmarshalJSON(b); err != nil {
+			t.Fatal(err)
+		}
+
+		if tcase.exp == "" {
+			if IsRevertible(logged.Template) {
+				t.Fatalf("%d: expected template to be non revertible", i+1)
+			}
+			continue
+		}
+		reverted, err := logged.Revert()
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := reverted.String(), tcase.exp; got != want {
+			t.Fatalf("%d: got: %s\nwant: %s\n", i+1, got, want)
+		}
+	}
+}
+
+func TestRevertCommands(t *testing.T) {
+	tpl := MustParse(`create vpc cidr=10.0.0.0/16
+create subnet cidr=10.0.0.0/24 vpc=vpc-1
+create subnet cidr=10.0.1.0/24 vpc=vpc-1
+update vpc id=vpc-1
+attach routetable id=rtb-1 subnet=sub-2`)
+	results := []string{"vpc-1", "sub-1", "sub-2", "", "assoc-1"}
+	for i, cmd := range tpl.CommandNodesIterator() {
+		cmd.CmdResult = results[i]
+	}
+	if got, want := tpl.RevertiblePositions(), []int{1, 2, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	tcases := []struct {
+		positions []int
+		exp       string
+		expErr    string
+	}{
+		{positions: []int{2}, exp: "delete subnet id=sub-1"},
+		{positions: []int{3, 5}, exp: "detach routetable association=assoc-1\ndelete subnet id=sub-2"},
+		{positions: []int{1, 2, 3, 5}, exp: "detach routetable association=assoc-1\ndelete subnet id=sub-2\ndelete subnet id=sub-1\ndelete vpc id=vpc-1"},
+		{positions: []int{1}, expErr: "command 2 'create subnet cidr=10.0.0.0/24 vpc=vpc-1' uses vpc-1 of command 1"},
+		{positions: []int{3}, expErr: "command 5 'attach routetable id=rtb-1 subnet=sub-2' uses sub-2 of command 3"},
+		{positions: []int{4}, expErr: "command 4 'update vpc id=vpc-1' is not revertible"},
+		{positions: []int{6}, expErr: "no command 6, expecting 1 to 5"},
+	}
+	for i, tcase := range tcases {
+		reverted, err := tpl.RevertCommands(tcase.positions)
+		if tcase.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.expErr) {
+				t.Fatalf("%d: got %v, want error containing %s", i+1, err, tcase.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := reverted.String(), tcase.exp; got != want {
+			t.Fatalf("%d: got: %s\nwant: %s\n", i+1, got, want)
+		}
+	}
+}
+
 func TestCmdNodeIsRevertible(t *testing.T) {
 	tcases := []struct {
 		line, result string
@@ -395,6 +507,8 @@ func TestCmdNodeIsRevertible(t *testing.T) {
 		revertible   bool
 	}{
 		{line: "update vpc", result: "any", revertible: false},
+		{line: "update subnet", revertible: false},
+		{line: "update securitygroup", revertible: true},
 		{line: "delete vpc", result: "any", revertible: false},
 		{line: "create vpc", result: "any", err: errors.New("any"), revertible: false},
 		{line: "create vpc", revertible: false},
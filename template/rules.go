@@ -0,0 +1,312 @@
+package template
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wallix/awless/template/internal/ast"
+	"gopkg.in/yaml.v2"
+)
+
+// RuleWarning and RuleError are the severities of rules. The violations
+// of rules with error severity fail the run before the dry run
+const (
+	RuleWarning = "warning"
+	RuleError   = "error"
+)
+
+// RulesValidator validates compiled templates against policy as code rules
+type RulesValidator struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Rule applies its check to the commands matching one of its commands patterns
+// (ex: "create instance", "create *"), all commands if none. Params, Values and Ports
+// are the arguments of the check. Values are shell patterns
+type Rule struct {
+	Name     string   `yaml:"name"`
+	Check    string   `yaml:"check"`
+	Commands []string `yaml:"commands"`
+	Severity string   `yaml:"severity"`
+	Message  string   `yaml:"message"`
+	Params   []string `yaml:"params"`
+	Values   []string `yaml:"values"`
+	Ports    []int    `yaml:"ports"`
+}
+
+// RuleCommand is a command of a compiled template as given to the rules checks
+type RuleCommand struct {
+	Action, Entity string
+	Params         map[string]interface{}
+	// Unresolved holds the params whose values are only known at run time,
+	// absent from Params
+	Unresolved []string
+	// Tags holds the keys of the tags created by the template
+	// on the resource created by the command
+	Tags []string
+}
+
+// RuleCheck returns a message for each violation of the rule by the command
+type RuleCheck func(rule *Rule, cmd *RuleCommand) []string
+
+// RuleChecks are the checks available to rules, by name
+var RuleChecks = map[string]RuleCheck{
+	"required_params":  checkRequiredParams,
+	"allowed_values":   checkAllowedValues,
+	"forbidden_values": checkForbiddenValues,
+	"required_tags":    checkRequiredTags,
+	"no_open_ingress":  checkNoOpenIngress,
+}
+
+// RuleViolation is a violation of a rule by a command of a template
+type RuleViolation struct {
+	Rule *Rule
+	Pos  ast.Position
+	Msg  string
+}
+
+func (v *RuleViolation) Error() string {
+	msg := fmt.Sprintf("%s: %s", v.Rule.Name, v.Msg)
+	if v.Rule.Message != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, v.Rule.Message)
+	}
+	return locate(v.Pos, msg)
+}
+
+func (v *RuleViolation) IsError() bool {
+	return v.Rule.Severity == RuleError
+}
+
+func ParseRules(content []byte) (*RulesValidator, error) {
+	v := &RulesValidator{}
+	if err := yaml.UnmarshalStrict(content, v); err != nil {
+		return nil, fmt.Errorf("invalid rules: %s", err)
+	}
+	for i, rule := range v.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if _, ok := RuleChecks[rule.Check]; !ok {
+			var checks []string
+			for name := range RuleChecks {
+				checks = append(checks, name)
+			}
+			sort.Strings(checks)
+			return nil, fmt.Errorf("invalid rules: %s: unknown check '%s', expecting one of %s", rule.Name, rule.Check, strings.Join(checks, ", "))
+		}
+		switch rule.Severity {
+		case "":
+			rule.Severity = RuleWarning
+		case RuleWarning, RuleError:
+		default:
+			return nil, fmt.Errorf("invalid rules: %s: invalid severity '%s', expecting %s or %s", rule.Name, rule.Severity, RuleWarning, RuleError)
+		}
+		for _, p := range append(rule.Commands, rule.Values...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid rules: %s: invalid pattern '%s'", rule.Name, p)
+			}
+		}
+	}
+	return v, nil
+}
+
+// Execute checks the commands as they would run, once per item of the loops.
+// A rule with error severity fails on the params it checks whose value is
+// only known at run time (ex: references to created resources)
+func (v *RulesValidator) Execute(t *Template) (errs []error) {
+	tags := createdTags(t)
+	reported := make(map[string]bool)
+	report := func(rule *Rule, cmd *ast.CommandNode, msg string) {
+		violation := &RuleViolation{Rule: rule, Pos: cmd.Pos, Msg: fmt.Sprintf("%s %s: %s", cmd.Action, cmd.Entity, msg)}
+		if !reported[violation.Error()] {
+			reported[violation.Error()] = true
+			errs = append(errs, violation)
+		}
+	}
+
+	visitExpandedCommands(t.Statements, func(cmd *ast.CommandNode, ident string) {
+		ruleCmd := &RuleCommand{Action: cmd.Action, Entity: cmd.Entity, Params: cmd.ToDriverParams()}
+		for k, v := range cmd.Params {
+			if !isResolved(v) {
+				delete(ruleCmd.Params, k)
+				ruleCmd.Unresolved = append(ruleCmd.Unresolved, k)
+			}
+		}
+		sort.Strings(ruleCmd.Unresolved)
+		if ident != "" {
+			ruleCmd.Tags = tags[ident]
+		}
+		for _, rule := range v.Rules {
+			if !matchAny(rule.Commands, fmt.Sprintf("%s %s", cmd.Action, cmd.Entity)) {
+				continue
+			}
+			if checked, ok := ruleCheckedParams[rule.Check]; ok && rule.Severity == RuleError {
+				for _, p := range checked(rule, ruleCmd) {
+					if contains(ruleCmd.Unresolved, p) {
+						report(rule, cmd, fmt.Sprintf("cannot check %s: value unknown before run", p))
+					}
+				}
+			}
+			for _, msg := range RuleChecks[rule.Check](rule, ruleCmd) {
+				report(rule, cmd, msg)
+			}
+		}
+	})
+	return
+}
+
+// ruleCheckedParams returns, per check, the params whose values the check depends on
+var ruleCheckedParams = map[string]func(rule *Rule, cmd *RuleCommand) []string{
+	"allowed_values":   func(rule *Rule, cmd *RuleCommand) []string { return rule.Params },
+	"forbidden_values": func(rule *Rule, cmd *RuleCommand) []string { return rule.Params },
+	"no_open_ingress": func(rule *Rule, cmd *RuleCommand) []string {
+		if cmd.Action != "update" || cmd.Entity != "securitygroup" {
+			return nil
+		}
+		return []string{"inbound", "cidr", "portrange", "protocol"}
+	},
+}
+
+func isResolved(v ast.CompositeValue) bool {
+	if withRefs, ok := v.(ast.WithRefs); ok && len(withRefs.GetRefs()) > 0 {
+		return false
+	}
+	if withFuncs, ok := v.(ast.WithFunctions); ok && len(withFuncs.GetFunctions()) > 0 {
+		return false
+	}
+	return v.Value() != nil
+}
+
+// createdTags returns the keys of the tags created by the template per referenced resource
+func createdTags(t *Template) map[string][]string {
+	tags := make(map[string][]string)
+	for _, cmd := range t.CommandNodesIterator() {
+		if cmd.Action != "create" || cmd.Entity != "tag" {
+			continue
+		}
+		resource, ok := cmd.Params["resource"].(ast.WithRefs)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(cmd.ToDriverParams()["key"])
+		for _, ref := range resource.GetRefs() {
+			tags[ref] = append(tags[ref], key)
+		}
+	}
+	return tags
+}
+
+// checkRequiredParams requires the rule params on the commands. It can only check
+// the params the drivers expose (ex: encryption can be required on databases but
+// not on volumes, whose creation has no encrypted param)
+func checkRequiredParams(rule *Rule, cmd *RuleCommand) (violations []string) {
+	for _, p := range rule.Params {
+		if _, ok := cmd.Params[p]; !ok && !contains(cmd.Unresolved, p) {
+			violations = append(violations, fmt.Sprintf("missing param '%s'", p))
+		}
+	}
+	return
+}
+
+func checkAllowedValues(rule *Rule, cmd *RuleCommand) (violations []string) {
+	for _, p := range rule.Params {
+		for _, v := range ruleValues(cmd.Params[p]) {
+			if !matchAny(rule.Values, v) {
+				violations = append(violations, fmt.Sprintf("%s '%s' not allowed, expecting %s", p, v, strings.Join(rule.Values, ", ")))
+			}
+		}
+	}
+	return
+}
+
+func checkForbiddenValues(rule *Rule, cmd *RuleCommand) (violations []string) {
+	for _, p := range rule.Params {
+		for _, v := range ruleValues(cmd.Params[p]) {
+			if matchAny(rule.Values, v) {
+				violations = append(violations, fmt.Sprintf("%s '%s' forbidden", p, v))
+			}
+		}
+	}
+	return
+}
+
+// checkRequiredTags requires the tags of the rule params on the resources created by the commands.
+// The Name tag is set by the name param
+func checkRequiredTags(rule *Rule, cmd *RuleCommand) (violations []string) {
+	for _, tag := range rule.Params {
+		if _, hasName := cmd.Params["name"]; tag == "Name" && (hasName || contains(cmd.Unresolved, "name")) {
+			continue
+		}
+		if !contains(cmd.Tags, tag) {
+			violations = append(violations, fmt.Sprintf("missing tag '%s' (ex: create tag resource=$myresource key=%s value=...)", tag, tag))
+		}
+	}
+	return
+}
+
+// checkNoOpenIngress forbids authorizing inbound traffic from the CIDRs of the
+// rule values (default 0.0.0.0/0 and ::/0) on the ports of the rule (default all)
+func checkNoOpenIngress(rule *Rule, cmd *RuleCommand) []string {
+	if cmd.Action != "update" || cmd.Entity != "securitygroup" || fmt.Sprint(cmd.Params["inbound"]) != "authorize" {
+		return nil
+	}
+	cidrs := rule.Values
+	if len(cidrs) == 0 {
+		cidrs = []string{"0.0.0.0/0", "::/0"}
+	}
+	cidr, ok := cmd.Params["cidr"].(string)
+	if !ok || !matchAny(cidrs, cidr) {
+		return nil
+	}
+	from, to := 0, 65535
+	if ports, ok := cmd.Params["portrange"]; ok && !strings.Contains(fmt.Sprint(cmd.Params["protocol"]), "any") {
+		from, to = parsePortRange(fmt.Sprint(ports))
+	}
+	if len(rule.Ports) == 0 {
+		return []string{fmt.Sprintf("inbound traffic open to %s", cidr)}
+	}
+	var violations []string
+	for _, port := range rule.Ports {
+		if port >= from && port <= to {
+			violations = append(violations, fmt.Sprintf("inbound traffic on port %d open to %s", port, cidr))
+		}
+	}
+	return violations
+}
+
+func parsePortRange(ports string) (from, to int) {
+	from, to = 0, 65535
+	if strings.Contains(ports, "any") {
+		return
+	}
+	splits := strings.SplitN(ports, "-", 2)
+	var err error
+	if from, err = strconv.Atoi(splits[0]); err != nil {
+		return 0, 65535
+	}
+	to = from
+	if len(splits) == 2 {
+		if to, err = strconv.Atoi(splits[1]); err != nil {
+			return 0, 65535
+		}
+	}
+	return
+}
+
+func ruleValues(v interface{}) (values []string) {
+	switch vv := v.(type) {
+	case nil:
+	case []interface{}:
+		for _, e := range vv {
+			values = append(values, fmt.Sprint(e))
+		}
+	case []string:
+		values = vv
+	default:
+		values = append(values, fmt.Sprint(vv))
+	}
+	return
+}
@@ -4,27 +4,53 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/wallix/awless/logger"
 	"github.com/wallix/awless/template/env"
 )
 
+const (
+	StopOnError     = "stop"
+	ContinueOnError = "continue"
+	RollbackOnError = "rollback"
+)
+
 type Runner struct {
 	Template                               *Template
 	Locale, Profile, Message, TemplatePath string
+	AllowLocalFunctions                    bool
 	Log                                    *logger.Logger
 	Fillers                                []map[string]interface{}
+	Defaults                               map[string]interface{}
 	AliasFunc                              func(paramPath, alias string) string
-	MissingHolesFunc                       func(string, []string, bool) string
+	ExistsFunc                             func(entity, name string) (bool, error)
+	MissingHolesFunc                       func(string, []string, bool, *env.Param) string
 	CmdLookuper                            func(tokens ...string) interface{}
+	IncludeFunc                            func(path string) ([]byte, string, error)
 	Validators                             []Validator
+	PriorStateFunc                         PriorStateFunc
 	ParamsSuggested                        int
+	Workers                                int
+	OnError                                string
 
 	BeforeRun func(*TemplateExecution) (bool, error)
 	AfterRun  func(*TemplateExecution) error
+	// BeforeRollback, when set, is called with the rollback execution before
+	// it runs, the rollback being cancelled on error
+	BeforeRollback func(*TemplateExecution) error
+	// AfterFailure, when set, is called with the template execution failing
+	// before running: on compilation, rules validation, dry run or BeforeRun errors
+	AfterFailure func(*TemplateExecution, error)
 }
 
 func (ru *Runner) Run() error {
+	switch ru.OnError {
+	case "", StopOnError, ContinueOnError, RollbackOnError:
+	default:
+		return fmt.Errorf("invalid on error mode '%s': expecting %s, %s or %s", ru.OnError, StopOnError, ContinueOnError, RollbackOnError)
+	}
+
 	tplExec := &TemplateExecution{
 		Template: ru.Template,
 		Path:     ru.TemplatePath,
@@ -33,25 +59,39 @@ func (ru *Runner) Run() error {
 		Source:   ru.Template.String(),
 	}
 	tplExec.SetMessage(ru.Message)
+	if tplExec.IsOneLiner() {
+		tplExec.Template.clearPositions()
+	}
 
-	cenv := NewEnv().WithAliasFunc(ru.AliasFunc).WithMissingHolesFunc(ru.MissingHolesFunc).
-		WithLookupCommandFunc(ru.CmdLookuper).WithLog(ru.Log).WithParamsMode(ru.ParamsSuggested).Build()
+	cenv := NewEnv().WithAliasFunc(ru.AliasFunc).WithExistsFunc(ru.ExistsFunc).WithMissingHolesFunc(ru.MissingHolesFunc).
+		WithLookupCommandFunc(ru.CmdLookuper).WithIncludeFunc(ru.IncludeFunc).WithTemplatePath(ru.TemplatePath).
+		WithLocalFunctionsAllowed(ru.AllowLocalFunctions).WithLog(ru.Log).WithParamsMode(ru.ParamsSuggested).Build()
 	cenv.Push(env.FILLERS, ru.Fillers...)
+	cenv.Push(env.DEFAULTS, ru.Defaults)
 
-	var err error
-	tplExec.Template, cenv, err = Compile(tplExec.Template, cenv, NewRunnerCompileMode)
+	compiled, cenv, err := Compile(tplExec.Template, cenv, NewRunnerCompileMode)
 	if err != nil {
-		return err
+		return ru.failed(tplExec, err)
 	}
+	tplExec.Template = compiled
 
 	tplExec.Fillers = cenv.Get(env.PROCESSED_FILLERS)
 
 	errs := tplExec.Template.Validate(ru.Validators...)
 	if len(errs) > 0 {
+		var failed int
 		for _, err := range errs {
-			logger.Warning(err)
+			if violation, ok := err.(*RuleViolation); ok && violation.IsError() {
+				logger.Error(err)
+				failed++
+			} else {
+				logger.Warning(err)
+			}
 		}
 		fmt.Fprintln(os.Stderr)
+		if failed > 0 {
+			return ru.failed(tplExec, fmt.Errorf("template violates %d rule(s) with error severity", failed))
+		}
 	}
 
 	if tplExec.IsOneLiner() {
@@ -61,6 +101,7 @@ func (ru *Runner) Run() error {
 	}
 
 	renv := NewRunEnv(cenv)
+	renv.SetWorkers(ru.Workers)
 	if _, err = tplExec.Template.DryRun(renv); err != nil {
 		switch t := err.(type) {
 		case *Errors:
@@ -71,22 +112,42 @@ func (ru *Runner) Run() error {
 		default:
 			logger.Error(err)
 		}
+		ru.failed(tplExec, fmt.Errorf("dry run failed: %s", err))
 		return errors.New("Dry run failed")
 	}
 
 	ok, err := ru.BeforeRun(tplExec)
 	if err != nil {
-		return err
+		return ru.failed(tplExec, err)
 	}
 
 	if ok {
+		if ru.PriorStateFunc != nil {
+			if err := tplExec.Template.CapturePriorState(ru.PriorStateFunc); err != nil {
+				logger.Warningf("cannot capture prior state of updated resources: %s", err)
+			}
+		}
+		renv.SetContinueOnError(ru.OnError == ContinueOnError)
+		tplExec.Started = time.Now()
 		tplExec.Template, err = tplExec.Template.Run(renv)
+		tplExec.Ended = time.Now()
 		if err != nil {
 			logger.Errorf("Running template error: %s", err)
 		}
+
+		var rollbackExec *TemplateExecution
+		if ru.OnError == RollbackOnError && tplExec.Stats().KOCount > 0 {
+			rollbackExec = ru.rollback(tplExec)
+		}
 		if err := ru.AfterRun(tplExec); err != nil {
 			return err
 		}
+		if rollbackExec != nil {
+			rollbackExec.SetMessage(fmt.Sprintf("Rollback %s: %s", tplExec.ID, tplExec.Message))
+			if err := ru.AfterRun(rollbackExec); err != nil {
+				return err
+			}
+		}
 	}
 
 	if tplExec.Stats().KOCount > 0 {
@@ -95,3 +156,54 @@ func (ru *Runner) Run() error {
 
 	return nil
 }
+
+func (ru *Runner) failed(tplExec *TemplateExecution, err error) error {
+	if ru.AfterFailure != nil {
+		ru.AfterFailure(tplExec, err)
+	}
+	return err
+}
+
+// rollback runs the revert of the successfully executed commands of a failed
+// template execution, returning the rollback execution if any
+func (ru *Runner) rollback(tplExec *TemplateExecution) *TemplateExecution {
+	if !IsRevertible(tplExec.Template) {
+		logger.Info("Nothing to rollback")
+		return nil
+	}
+	reverted, err := tplExec.Template.Revert()
+	if err != nil {
+		logger.Errorf("Cannot rollback template: %s", err)
+		return nil
+	}
+
+	logger.Info("Rolling back executed commands ...")
+	rollbackExec := &TemplateExecution{
+		Template:   reverted,
+		Locale:     ru.Locale,
+		Profile:    ru.Profile,
+		Source:     reverted.String(),
+		RollbackOf: tplExec.ID,
+	}
+
+	cenv := NewEnv().WithAliasFunc(ru.AliasFunc).WithExistsFunc(ru.ExistsFunc).WithMissingHolesFunc(ru.MissingHolesFunc).
+		WithLookupCommandFunc(ru.CmdLookuper).WithLog(ru.Log).WithParamsMode(env.REQUIRED_PARAMS_ONLY).Build()
+	compiled, _, err := Compile(reverted, cenv, NewRunnerCompileMode)
+	if err != nil {
+		logger.Errorf("Cannot rollback template: %s", err)
+		return nil
+	}
+	rollbackExec.Template = compiled
+	if ru.BeforeRollback != nil {
+		if err := ru.BeforeRollback(rollbackExec); err != nil {
+			logger.Errorf("Cannot rollback template: %s", err)
+			return nil
+		}
+	}
+	if rollbackExec.Template, err = compiled.Run(NewRunEnv(cenv)); err != nil {
+		logger.Errorf("Running rollback error: %s", err)
+	}
+	tplExec.RolledBackBy = rollbackExec.ID
+
+	return rollbackExec
+}
@@ -0,0 +1,113 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/wallix/awless/template/env"
+	"github.com/wallix/awless/template/internal/ast"
+)
+
+var metaParams = []string{"retry", "backoff", "timeout"}
+
+const defaultBackoff = 2 * time.Second
+
+// runPolicy defines how a command is run: a command failing with a transient
+// error (ex: throttling, server error, timeout) is run again up to retry times,
+// waiting backoff before the first retry and doubling it for each subsequent retry.
+// A run exceeding timeout is considered failed. As the drivers calls cannot be
+// cancelled, a timed out call keeps running and may still change the resource after
+// the command is reported failed: creations cannot have both a timeout and retries
+type runPolicy struct {
+	retry            int
+	backoff, timeout time.Duration
+}
+
+// retryableError is implemented by the drivers errors telling whether they are
+// transient, the command being worth retrying. Other errors are not retried
+type retryableError interface {
+	Retryable() bool
+}
+
+type timeoutError time.Duration
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s (the call is not cancelled and may still complete)", time.Duration(e))
+}
+
+func (e timeoutError) Retryable() bool { return true }
+
+func isRetryable(err error) bool {
+	retryable, ok := err.(retryableError)
+	return ok && retryable.Retryable()
+}
+
+func newRunPolicy(meta map[string]ast.CompositeValue) (*runPolicy, error) {
+	policy := &runPolicy{backoff: defaultBackoff}
+	for key, value := range meta {
+		if value.Value() == nil {
+			return policy, fmt.Errorf("%s: expecting a literal value, got '%s'", key, value)
+		}
+		str := fmt.Sprint(value.Value())
+		switch key {
+		case "retry":
+			retry, err := strconv.Atoi(str)
+			if err != nil || retry < 0 {
+				return policy, fmt.Errorf("retry: expecting a positive integer, got '%s'", str)
+			}
+			policy.retry = retry
+		case "backoff", "timeout":
+			d, err := time.ParseDuration(str)
+			if err != nil || d <= 0 {
+				return policy, fmt.Errorf("%s: expecting a positive duration (ex: 5s, 2m), got '%s'", key, str)
+			}
+			if key == "backoff" {
+				policy.backoff = d
+			} else {
+				policy.timeout = d
+			}
+		}
+	}
+	return policy, nil
+}
+
+func (p *runPolicy) run(renv env.Running, n *ast.CommandNode) (result interface{}, err error) {
+	wait := p.backoff
+	for attempt := 1; ; attempt++ {
+		if p.retry > 0 {
+			n.CmdAttempts = attempt
+		}
+		if result, err = p.runOnce(renv, n); err == nil || attempt > p.retry || !isRetryable(err) {
+			return
+		}
+		renv.Log().Warningf("%s %s: attempt %d/%d failed: %s. Retrying in %s", n.Action, n.Entity, attempt, p.retry+1, err, wait)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// runOnce runs the command, giving up after the timeout if any.
+// The AWS call itself is not cancelled and keeps running in the background
+func (p *runPolicy) runOnce(renv env.Running, n *ast.CommandNode) (interface{}, error) {
+	if p.timeout <= 0 {
+		return n.Run(renv, n.ToDriverParams())
+	}
+
+	type runResult struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan runResult, 1)
+	go func() {
+		result, err := n.Run(renv, n.ToDriverParams())
+		done <- runResult{result, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.result, res.err
+	case <-time.After(p.timeout):
+		return nil, timeoutError(p.timeout)
+	}
+}
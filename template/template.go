@@ -19,7 +19,10 @@ package template
 import (
 	"crypto/rand"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
@@ -57,63 +60,375 @@ func (s *Template) DryRun(renv env.Running) (tpl *Template, err error) {
 }
 
 func (s *Template) Run(renv env.Running) (*Template, error) {
-	vars := map[string]interface{}{}
-
 	current := &Template{AST: &ast.AST{}}
 	current.ID = ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
 
-	for _, sts := range s.Statements {
+	r := &statementsRunner{renv: renv, current: current, declared: make(map[string]*runNode)}
+	_, err := r.runStatements(s.Statements)
+	r.flush()
+
+	return current, err
+}
+
+// statementsRunner schedules the statements of a template as nodes depending on
+// the nodes declaring the references they use. Pending nodes are run when flushed,
+// concurrently up to the running env workers limit, and appended to the current
+// template in the template order, so that the current template only holds the
+// executed commands, loops being expanded, and can be reverted in reverse order
+type statementsRunner struct {
+	renv     env.Running
+	current  *Template
+	pending  []*runNode
+	declared map[string]*runNode
+}
+
+type runNode struct {
+	stmt  *ast.Statement
+	cmd   *ast.CommandNode
+	value *ast.ValueNode
+	deps  map[string]*runNode
+	// after holds the nodes to wait for, without referencing their results
+	after    []*runNode
+	literals []string
+	result   interface{}
+	ran      bool
+	failed   bool
+	done     chan struct{}
+}
+
+func (r *statementsRunner) runStatements(statements []*ast.Statement) (bool, error) {
+	for _, sts := range statements {
 		clone := sts.Clone()
-		current.Statements = append(current.Statements, clone)
 		switch n := clone.Node.(type) {
-		case *ast.CommandNode:
-			if stop := processCmdNode(renv, n, vars); stop {
-				return current, nil
+		case *ast.ForNode:
+			if len(n.Items.GetRefs()) > 0 {
+				if stop := r.flush(); stop {
+					return true, nil
+				}
+				n.Items.ProcessRefs(r.resolvedVars())
+				if err := n.Items.ResolveFunctions(newFunctionsEvaluator(r.renv)); err != nil {
+					return true, statementErr(clone, "%s", err)
+				}
+			}
+			items, err := n.Iterate()
+			if err != nil {
+				return true, statementErr(clone, "%s", err)
+			}
+			for _, item := range items {
+				body := make([]*ast.Statement, len(n.Statements))
+				for i, st := range n.Statements {
+					body[i] = st.Clone()
+					for _, expr := range extractExpressionNodes(body[i]) {
+						if withRefs, ok := expr.(ast.WithRefs); ok {
+							withRefs.ProcessRefs(map[string]interface{}{n.Ident: item})
+						}
+					}
+				}
+				if stop, err := r.runStatements(body); stop || err != nil {
+					return stop, err
+				}
+			}
+		case *ast.IfNode:
+			var refs []string
+			for _, operand := range n.Condition.Operands() {
+				refs = append(refs, operand.GetRefs()...)
+			}
+			if len(refs) > 0 {
+				if stop := r.flush(); stop {
+					return true, nil
+				}
+				n.Condition.ProcessRefs(r.resolvedVars())
+				for _, operand := range n.Condition.Operands() {
+					if err := operand.ResolveFunctions(newFunctionsEvaluator(r.renv)); err != nil {
+						return true, statementErr(clone, "%s", err)
+					}
+				}
+			}
+			holds, err := n.Condition.Eval()
+			if err != nil {
+				return true, statementErr(clone, "%s", err)
+			}
+			if holds == n.Unless {
+				r.skipStatements(n.Statements)
+				continue
 			}
+			if stop, err := r.runStatements(n.Statements); stop || err != nil {
+				return stop, err
+			}
+		case *ast.CommandNode:
+			r.schedule(clone, n, nil, "")
+		case *ast.OutputNode:
+			r.schedule(clone, nil, n.Value, "")
 		case *ast.DeclarationNode:
-			ident := n.Ident
-			expr := n.Expr
-			switch n := expr.(type) {
+			switch expr := n.Expr.(type) {
 			case *ast.CommandNode:
-				if stop := processCmdNode(renv, n, vars); stop {
-					return current, nil
-				}
-				vars[ident] = n.Result()
+				r.schedule(clone, expr, nil, n.Ident)
+			case *ast.ValueNode:
+				r.schedule(clone, nil, expr, n.Ident)
 			default:
-				return current, fmt.Errorf("unknown type of node: %T", expr)
+				return true, fmt.Errorf("unknown type of node: %T", n.Expr)
+			}
+		case *ast.CommentNode, *ast.ParamNode:
+		default:
+			return true, fmt.Errorf("unknown type of node: %T", clone.Node)
+		}
+	}
+
+	return false, nil
+}
+
+// skipStatements schedules the commands of a branch not taken,
+// flagged as skipped so that they appear in the template execution
+func (r *statementsRunner) skipStatements(statements []*ast.Statement) {
+	visitStatements(statements, func(st *ast.Statement) {
+		var cmd *ast.CommandNode
+		clone := st.Clone()
+		switch n := clone.Node.(type) {
+		case *ast.CommandNode:
+			cmd = n
+		case *ast.DeclarationNode:
+			cmd, _ = n.Expr.(*ast.CommandNode)
+		}
+		if cmd == nil {
+			return
+		}
+		cmd.CmdSkipped = true
+		r.schedule(clone, cmd, nil, "")
+	})
+}
+
+func (r *statementsRunner) schedule(st *ast.Statement, cmd *ast.CommandNode, value *ast.ValueNode, ident string) {
+	n := &runNode{stmt: st, cmd: cmd, value: value, deps: make(map[string]*runNode), done: make(chan struct{})}
+	var refs []string
+	if cmd != nil && !cmd.CmdSkipped {
+		refs = cmd.GetRefs()
+	} else if value != nil {
+		refs = value.GetRefs()
+	}
+	for _, ref := range refs {
+		if dep, ok := r.declared[ref]; ok {
+			n.deps[ref] = dep
+		}
+	}
+	if cmd != nil && !cmd.CmdSkipped {
+		n.literals = literalValues(cmd)
+		for _, p := range r.pending {
+			if mustRunAfter(n, p) {
+				n.after = append(n.after, p)
 			}
+		}
+	}
+	if ident != "" {
+		r.declared[ident] = n
+	}
+	r.pending = append(r.pending, n)
+}
+
+// mustRunAfter returns true if the command of n cannot run concurrently with
+// the earlier command of p, whatever their references: check commands wait for
+// the previous commands and are waited for by the next ones, deletions run in
+// order, and a deletion and another command sharing a literal value (ex: an ID)
+// run in the template order
+func mustRunAfter(n, p *runNode) bool {
+	if p.cmd == nil || p.cmd.CmdSkipped {
+		return false
+	}
+	if n.cmd.Action == "check" || p.cmd.Action == "check" {
+		return true
+	}
+	if n.cmd.Action != "delete" && p.cmd.Action != "delete" {
+		return false
+	}
+	if n.cmd.Action == "delete" && p.cmd.Action == "delete" {
+		return true
+	}
+	for _, lit := range n.literals {
+		if contains(p.literals, lit) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalValues returns the values of the params of the command known before
+// the run (ex: identifiers, names), params with unresolved references being left out
+func literalValues(cmd *ast.CommandNode) (literals []string) {
+	for _, param := range cmd.Params {
+		if withRefs, ok := param.(ast.WithRefs); ok && len(withRefs.GetRefs()) > 0 {
+			continue
+		}
+		switch vv := param.Value().(type) {
+		case nil:
+		case []interface{}:
+			for _, e := range vv {
+				literals = append(literals, fmt.Sprint(e))
+			}
+		case []string:
+			literals = append(literals, vv...)
 		default:
-			return current, fmt.Errorf("unknown type of node: %T", clone.Node)
+			literals = append(literals, fmt.Sprint(vv))
 		}
 	}
+	return
+}
 
-	return current, nil
+func (r *statementsRunner) resolvedVars() map[string]interface{} {
+	vars := make(map[string]interface{})
+	for ident, n := range r.declared {
+		vars[ident] = n.result
+	}
+	return vars
+}
+
+// flush runs the pending nodes and returns true if one of them failed,
+// in which case the nodes not yet started are discarded, unless the running
+// env continues on error
+func (r *statementsRunner) flush() (stop bool) {
+	nodes := r.pending
+	r.pending = nil
+
+	continueOnError := r.renv.ContinueOnError()
+	if workers := r.renv.Workers(); workers > 1 {
+		stop = runConcurrently(r.renv, nodes, workers, continueOnError)
+	} else {
+		for _, n := range nodes {
+			if failed := n.run(r.renv); failed && !continueOnError {
+				stop = true
+				break
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		if n.ran {
+			r.current.Statements = append(r.current.Statements, n.stmt)
+		}
+	}
+	return
+}
+
+func runConcurrently(renv env.Running, nodes []*runNode, workers int, continueOnError bool) bool {
+	var (
+		wg      sync.WaitGroup
+		stopped int32
+	)
+	sem := make(chan struct{}, workers)
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *runNode) {
+			defer wg.Done()
+			defer close(n.done)
+			for _, dep := range n.deps {
+				<-dep.done
+			}
+			for _, prev := range n.after {
+				<-prev.done
+			}
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if atomic.LoadInt32(&stopped) == 1 {
+				return
+			}
+			if failed := n.run(renv); failed && !continueOnError {
+				atomic.StoreInt32(&stopped, 1)
+			}
+		}(n)
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&stopped) == 1
+}
+
+// run runs the node and returns true if it failed. A node referencing
+// a failed node is not run and considered failed
+func (n *runNode) run(renv env.Running) bool {
+	n.ran = true
+	var refs []string
+	for ref := range n.deps {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	vars := make(map[string]interface{})
+	for _, ref := range refs {
+		dep := n.deps[ref]
+		if dep.failed {
+			n.failed = true
+			if n.cmd != nil {
+				n.cmd.CmdErr = fmt.Errorf("not run: referenced '$%s' failed", ref)
+				if !renv.IsDryRun() {
+					logCmdStatus(renv, n.cmd)
+				}
+			}
+			return true
+		}
+		vars[ref] = dep.result
+	}
+	if n.value != nil {
+		n.value.ProcessRefs(vars)
+		if err := n.value.ResolveFunctions(newFunctionsEvaluator(renv)); err != nil {
+			renv.Log().Errorf("%s", statementErr(n.stmt, "%s", err))
+			n.failed = true
+			return true
+		}
+		n.result = n.value.Value.Value()
+		return false
+	}
+	n.failed = processCmdNode(renv, n.cmd, vars)
+	n.result = n.cmd.Result()
+	return n.failed
 }
 
 func processCmdNode(renv env.Running, n *ast.CommandNode, vars map[string]interface{}) bool {
+	if n.CmdSkipped {
+		if !renv.IsDryRun() {
+			renv.Log().Infof("%s %s %s", color.New(color.FgYellow).Sprint("SKIP"), n.Action, n.Entity)
+		}
+		return false
+	}
 	n.ProcessRefs(vars)
+	if err := n.ResolveFunctions(newFunctionsEvaluator(renv)); err != nil {
+		n.CmdErr = prefixError(err, locate(n.Pos, fmt.Sprintf("%s %s", n.Action, n.Entity)))
+		if !renv.IsDryRun() {
+			logCmdStatus(renv, n)
+		}
+		return true
+	}
 	if renv.IsDryRun() {
 		n.CmdResult, n.CmdErr = n.Command.Run(renv, n.ToDriverParams())
-		n.CmdErr = prefixError(n.CmdErr, fmt.Sprintf("dry run: %s %s", n.Action, n.Entity))
+		n.CmdErr = prefixError(n.CmdErr, "dry run: "+locate(n.Pos, fmt.Sprintf("%s %s", n.Action, n.Entity)))
 	} else {
-		n.CmdResult, n.CmdErr = n.Run(renv, n.ToDriverParams())
-		var res, status string
-		if n.CmdResult != nil {
-			res = " (" + color.New(color.FgCyan).Sprint(n.CmdResult) + ") "
-		}
-		if n.CmdErr != nil {
-			status = color.New(color.FgRed).Sprint("KO")
+		policy, err := newRunPolicy(n.Meta)
+		if err != nil {
+			n.CmdErr = err
 		} else {
-			status = color.New(color.FgGreen).Sprint("OK")
-		}
-		renv.Log().Infof("%s %s %s%s", status, n.Action, n.Entity, res)
-		if n.CmdErr != nil {
-			renv.Log().MultiLineError(n.CmdErr)
+			start := time.Now()
+			n.CmdResult, n.CmdErr = policy.run(renv, n)
+			n.CmdDuration = time.Since(start)
 		}
+		logCmdStatus(renv, n)
 	}
 	return n.CmdErr != nil
 }
 
+func logCmdStatus(renv env.Running, n *ast.CommandNode) {
+	var res, status string
+	if n.CmdResult != nil {
+		res = " (" + color.New(color.FgCyan).Sprint(n.CmdResult) + ") "
+	}
+	if n.CmdErr != nil {
+		status = color.New(color.FgRed).Sprint("KO")
+	} else {
+		status = color.New(color.FgGreen).Sprint("OK")
+	}
+	var pos string
+	if n.CmdErr != nil && n.Pos.IsValid() {
+		pos = fmt.Sprintf(" (line %d, column %d)", n.Pos.Line, n.Pos.Column)
+	}
+	renv.Log().Infof("%s %s %s%s%s", status, n.Action, n.Entity, res, pos)
+	if n.CmdErr != nil {
+		renv.Log().MultiLineError(n.CmdErr)
+	}
+}
+
 func prefixError(err error, prefix string) error {
 	if err == nil {
 		return err
@@ -121,6 +436,19 @@ func prefixError(err error, prefix string) error {
 	return fmt.Errorf("%s: %s", prefix, err.Error())
 }
 
+// Outputs returns the resolved values of the output statements
+func (s *Template) Outputs() map[string]interface{} {
+	outputs := make(map[string]interface{})
+	visitStatements(s.Statements, func(st *ast.Statement) {
+		if out, ok := st.Node.(*ast.OutputNode); ok {
+			if val := out.Value.Value.Value(); val != nil {
+				outputs[out.Ident] = val
+			}
+		}
+	})
+	return outputs
+}
+
 func (s *Template) Validate(rules ...Validator) (all []error) {
 	for _, rule := range rules {
 		errs := rule.Execute(s)
@@ -155,6 +483,41 @@ func (t *Template) UniqueDefinitions(apis map[string]string) (res []string) {
 	return
 }
 
+// Params returns the params declared in the template header, in order of declaration
+func (t *Template) Params() (res []*env.Param) {
+	for _, st := range t.Statements {
+		if n, ok := st.Node.(*ast.ParamNode); ok {
+			res = append(res, toEnvParam(n))
+		}
+	}
+	return
+}
+
+// HoleNames returns the sorted names of the holes of the template
+func (t *Template) HoleNames() (res []string) {
+	unique := make(map[string]struct{})
+	t.visitHoles(func(h ast.WithHoles) {
+		for name := range h.GetHoles() {
+			unique[name] = struct{}{}
+		}
+	})
+	for name := range unique {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return
+}
+
+func (t *Template) paramDeclarations() map[string]*ast.Statement {
+	declared := make(map[string]*ast.Statement)
+	for _, st := range t.Statements {
+		if n, ok := st.Node.(*ast.ParamNode); ok {
+			declared[n.Name] = st
+		}
+	}
+	return declared
+}
+
 func (s *Template) visitHoles(fn func(n ast.WithHoles)) {
 	for _, n := range s.expressionNodesIterator() {
 		if h, ok := n.(ast.WithHoles); ok {
@@ -180,81 +543,151 @@ func (s *Template) visitCommandNodesE(fn func(n *ast.CommandNode) error) error {
 }
 
 func (s *Template) CommandNodesIterator() (nodes []*ast.CommandNode) {
-	for _, sts := range s.Statements {
-		switch nn := sts.Node.(type) {
-		case *ast.CommandNode:
-			nodes = append(nodes, nn)
-		case *ast.DeclarationNode:
-			expr := sts.Node.(*ast.DeclarationNode).Expr
-			switch expr.(type) {
-			case *ast.CommandNode:
-				nodes = append(nodes, expr.(*ast.CommandNode))
-			}
+	for _, expr := range s.expressionNodesIterator() {
+		if cmd, ok := expr.(*ast.CommandNode); ok {
+			nodes = append(nodes, cmd)
 		}
 	}
 	return
 }
 
 func (s *Template) WithRefsIterator() (nodes []ast.WithRefs) {
-	for _, sts := range s.Statements {
-		switch nn := sts.Node.(type) {
-		case ast.WithRefs:
-			nodes = append(nodes, nn)
-		case *ast.DeclarationNode:
-			expr := sts.Node.(*ast.DeclarationNode).Expr
-			switch nnn := expr.(type) {
-			case *ast.CommandNode:
-				nodes = append(nodes, nnn)
-			}
+	for _, expr := range s.expressionNodesIterator() {
+		if withRefs, ok := expr.(ast.WithRefs); ok {
+			nodes = append(nodes, withRefs)
 		}
 	}
 	return
 }
 
 func (s *Template) CommandNodesReverseIterator() (nodes []*ast.CommandNode) {
-	for i := len(s.Statements) - 1; i >= 0; i-- {
-		sts := s.Statements[i]
-		switch sts.Node.(type) {
-		case *ast.CommandNode:
-			nodes = append(nodes, sts.Node.(*ast.CommandNode))
-		case *ast.DeclarationNode:
-			expr := sts.Node.(*ast.DeclarationNode).Expr
-			switch expr.(type) {
-			case *ast.CommandNode:
-				nodes = append(nodes, expr.(*ast.CommandNode))
-			}
-		}
+	cmds := s.CommandNodesIterator()
+	for i := len(cmds) - 1; i >= 0; i-- {
+		nodes = append(nodes, cmds[i])
 	}
 	return
 }
 
+// ExpandedCommandNodesIterator returns the commands as they would run, the commands
+// of loops once per item and without the branches known not to run
+func (s *Template) ExpandedCommandNodesIterator() (nodes []*ast.CommandNode) {
+	visitExpandedCommands(s.Statements, func(cmd *ast.CommandNode, ident string) {
+		nodes = append(nodes, cmd)
+	})
+	return
+}
+
 func (s *Template) declarationNodesIterator() (nodes []*ast.DeclarationNode) {
-	for _, sts := range s.Statements {
-		switch n := sts.Node.(type) {
-		case *ast.DeclarationNode:
+	visitStatements(s.Statements, func(st *ast.Statement) {
+		if n, ok := st.Node.(*ast.DeclarationNode); ok {
 			nodes = append(nodes, n)
 		}
-	}
+	})
 	return
 }
 
 func (s *Template) expressionNodesIterator() (nodes []ast.ExpressionNode) {
 	for _, st := range s.Statements {
-		if expr := extractExpressionNode(st); expr != nil {
-			nodes = append(nodes, expr)
-		}
+		nodes = append(nodes, extractExpressionNodes(st)...)
 	}
 	return
 }
 
-func extractExpressionNode(st *ast.Statement) ast.ExpressionNode {
+// extractExpressionNodes returns the expression of a statement or, for blocks,
+// the iterated value or compared values followed by the expressions of the block.
+// The expressions of the blocks known at compilation not to run are left out
+func extractExpressionNodes(st *ast.Statement) (nodes []ast.ExpressionNode) {
 	switch n := st.Node.(type) {
+	case *ast.ForNode:
+		nodes = append(nodes, n.Items)
+		for _, child := range n.Statements {
+			nodes = append(nodes, extractExpressionNodes(child)...)
+		}
+	case *ast.IfNode:
+		for _, operand := range n.Condition.Operands() {
+			nodes = append(nodes, operand)
+		}
+		if n.Skipped() {
+			break
+		}
+		for _, child := range n.Statements {
+			nodes = append(nodes, extractExpressionNodes(child)...)
+		}
 	case *ast.DeclarationNode:
-		return n.Expr
+		nodes = append(nodes, n.Expr)
+	case *ast.OutputNode:
+		nodes = append(nodes, n.Value)
 	case ast.ExpressionNode:
-		return n
+		nodes = append(nodes, n)
+	}
+	return
+}
+
+// clearPositions forgets the template source positions,
+// meaningless when the template is a command line
+func (s *Template) clearPositions() {
+	visitStatements(s.Statements, func(st *ast.Statement) {
+		st.Pos = ast.Position{}
+	})
+	s.visitCommandNodes(func(n *ast.CommandNode) {
+		n.Pos, n.ParamsPos = ast.Position{}, nil
+	})
+}
+
+// visitStatements visits statements in order, descending into loop and condition bodies
+func visitStatements(statements []*ast.Statement, fn func(*ast.Statement)) {
+	for _, st := range statements {
+		fn(st)
+		switch n := st.Node.(type) {
+		case *ast.ForNode:
+			visitStatements(n.Statements, fn)
+		case *ast.IfNode:
+			visitStatements(n.Statements, fn)
+		}
+	}
+}
+
+// visitExpandedCommands visits the commands as they would run: the body of a loop
+// once per item with the loop variable resolved, leaving out the branches whose
+// condition is known not to hold. The body of a loop over a value unresolved before
+// the run, or of a condition that cannot be evaluated yet, is visited once as is
+func visitExpandedCommands(statements []*ast.Statement, fn func(cmd *ast.CommandNode, ident string)) {
+	for _, st := range statements {
+		switch n := st.Node.(type) {
+		case *ast.ForNode:
+			items, err := n.Iterate()
+			if err != nil {
+				visitExpandedCommands(n.Statements, fn)
+				continue
+			}
+			for _, item := range items {
+				body := make([]*ast.Statement, len(n.Statements))
+				for i, child := range n.Statements {
+					body[i] = child.Clone()
+					for _, expr := range extractExpressionNodes(body[i]) {
+						if withRefs, ok := expr.(ast.WithRefs); ok {
+							withRefs.ProcessRefs(map[string]interface{}{n.Ident: item})
+						}
+						if withFuncs, ok := expr.(ast.WithFunctions); ok {
+							withFuncs.ResolveFunctions(evalPureFunction)
+						}
+					}
+				}
+				visitExpandedCommands(body, fn)
+			}
+		case *ast.IfNode:
+			if holds, err := n.Condition.Eval(); err == nil && holds == n.Unless {
+				continue
+			}
+			visitExpandedCommands(n.Statements, fn)
+		case *ast.CommandNode:
+			fn(n, "")
+		case *ast.DeclarationNode:
+			if cmd, ok := n.Expr.(*ast.CommandNode); ok {
+				fn(cmd, n.Ident)
+			}
+		}
 	}
-	return nil
 }
 
 type Errors struct {
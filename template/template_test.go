@@ -0,0 +1,760 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wallix/awless/logger"
+	"github.com/wallix/awless/template/env"
+	"github.com/wallix/awless/template/params"
+)
+
+type mockCommandWithID struct {
+	entity string
+	count  int
+}
+
+func (c *mockCommandWithID) ParamsSpec() params.Spec {
+	return params.NewSpec(params.AllOf(params.Opt("cidr", "fail", "id", "key", "name", "resource", "value", "vpc")))
+}
+func (c *mockCommandWithID) Run(renv env.Running, p map[string]interface{}) (interface{}, error) {
+	if _, ok := p["fail"]; ok && !renv.IsDryRun() {
+		return nil, errors.New("failure")
+	}
+	c.count++
+	return fmt.Sprintf("%s-%d", c.entity, c.count), nil
+}
+func (c *mockCommandWithID) ExtractResult(i interface{}) string { return fmt.Sprint(i) }
+
+func TestRunExpandLoops(t *testing.T) {
+	cmds := make(map[string]*mockCommandWithID)
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		key := strings.Join(tokens, "")
+		if _, ok := cmds[key]; !ok {
+			cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+		}
+		return cmds[key]
+	}).Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"zones": []interface{}{"eu-west-1a", "eu-west-1b"}})
+
+	tpl := MustParse(`vpc = create vpc cidr=10.0.0.0/16
+cidrs = [10.0.0.0/24, 10.0.1.0/24]
+for cidr in $cidrs {
+  sub = create subnet cidr=$cidr vpc=$vpc
+  for zone in {zones} {
+    create tag resource=$sub key=zone value=$zone
+  }
+}`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `vpc = create vpc cidr=10.0.0.0/16
+sub = create subnet cidr=10.0.0.0/24 vpc=vpc-1
+create tag key=zone resource=subnet-1 value=eu-west-1a
+create tag key=zone resource=subnet-1 value=eu-west-1b
+sub = create subnet cidr=10.0.1.0/24 vpc=vpc-1
+create tag key=zone resource=subnet-2 value=eu-west-1a
+create tag key=zone resource=subnet-2 value=eu-west-1b`
+	if got, want := executed.String(), exp; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	reverted, err := executed.Revert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = `delete tag key=zone resource=subnet-2 value=eu-west-1b
+delete tag key=zone resource=subnet-2 value=eu-west-1a
+delete subnet id=subnet-2
+delete tag key=zone resource=subnet-1 value=eu-west-1b
+delete tag key=zone resource=subnet-1 value=eu-west-1a
+delete subnet id=subnet-1
+delete vpc id=vpc-1`
+	if got, want := reverted.String(), exp; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	t.Run("unresolved loop values", func(t *testing.T) {
+		tpl := MustParse("for name in $names {\n  create user name=$name\n}")
+		if _, err := tpl.Run(NewRunEnv(NewEnv().Build())); err == nil || !strings.Contains(err.Error(), "cannot iterate") {
+			t.Fatalf("expected iterate error, got %v", err)
+		}
+	})
+}
+
+func TestRunFunctions(t *testing.T) {
+	cmds := make(map[string]*mockCommandWithID)
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		key := strings.Join(tokens, "")
+		if _, ok := cmds[key]; !ok {
+			cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+		}
+		return cmds[key]
+	}).Build()
+
+	tpl := MustParse(`vpc = create vpc cidr=10.0.0.0/16 name=lower(MyVPC)
+for i in [1, 2] {
+  create subnet cidr=cidrsubnet(10.0.0.0/16, 8, $i) name=join(-, [$vpc, $i])
+}`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `vpc = create vpc cidr=10.0.0.0/16 name=myvpc
+create subnet cidr=10.0.1.0/24 name=vpc-1-1
+create subnet cidr=10.0.2.0/24 name=vpc-1-2`
+	if got, want := executed.String(), exp; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunSkipBranches(t *testing.T) {
+	cmds := make(map[string]*mockCommandWithID)
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		key := strings.Join(tokens, "")
+		if _, ok := cmds[key]; !ok {
+			cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+		}
+		return cmds[key]
+	}).WithExistsFunc(func(entity, name string) (bool, error) {
+		return name == "existing-vpc", nil
+	}).Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"env": "prod"})
+
+	tpl := MustParse(`unless exists vpc @existing-vpc {
+  create vpc name=existing-vpc
+}
+unless exists vpc @new-vpc {
+  vpc = create vpc name=new-vpc
+  if $vpc == vpc-1 {
+    create subnet vpc=$vpc
+  }
+}
+if {env} != prod {
+  create subnet name=dev
+}`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var skipped []string
+	for _, cmd := range executed.CommandNodesIterator() {
+		if cmd.CmdSkipped {
+			skipped = append(skipped, cmd.String())
+		}
+	}
+	if got, want := skipped, []string{"create vpc name=existing-vpc", "create subnet name=dev"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	tplExec := &TemplateExecution{Template: executed}
+	stats := tplExec.Stats()
+	if got, want := stats.OKCount, 2; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+	if got, want := stats.SkippedCount, 2; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+
+	b, err := tplExec.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshaled := &TemplateExecution{}
+	if err = unmarshaled.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := unmarshaled.Stats().SkippedCount, 2; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+
+	reverted, err := executed.Revert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reverted.String(), "delete subnet id=subnet-1\ndelete vpc id=vpc-1"; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCompileSkipsBranchesOfAbsentResources(t *testing.T) {
+	cmds := make(map[string]*mockCommandWithID)
+	lookup := func(tokens ...string) interface{} {
+		key := strings.Join(tokens, "")
+		if _, ok := cmds[key]; !ok {
+			cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+		}
+		return cmds[key]
+	}
+	var prompted []string
+	cenv := NewEnv().WithLookupCommandFunc(lookup).WithAliasFunc(func(p, v string) string {
+		return ""
+	}).WithExistsFunc(func(entity, name string) (bool, error) {
+		return false, nil
+	}).WithMissingHolesFunc(func(hole string, paths []string, optional bool, p *env.Param) string {
+		prompted = append(prompted, hole)
+		return ""
+	}).Build()
+
+	tpl := MustParse(`if exists subnet @mysub {
+  create tag resource=@mysub key=env value={tag.value}
+}
+unless exists subnet @mysub {
+  create subnet name=mysub
+}`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompted) > 0 {
+		t.Fatalf("got prompted holes %q, want none", prompted)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped []string
+	for _, cmd := range executed.CommandNodesIterator() {
+		if cmd.CmdSkipped {
+			skipped = append(skipped, cmd.String())
+		}
+	}
+	if got, want := skipped, []string{"create tag key=env resource=@mysub value={tag.value}"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	failing := NewEnv().WithLookupCommandFunc(lookup).WithExistsFunc(func(entity, name string) (bool, error) {
+		return false, errors.New("cannot load local graphs")
+	}).Build()
+	if _, _, err = Compile(MustParse("if exists subnet @mysub {\n create subnet name=mysub\n}"), failing, NewRunnerCompileMode); err == nil || !strings.Contains(err.Error(), "cannot load local graphs") {
+		t.Fatalf("got %v, want lookup error", err)
+	}
+}
+
+func TestRunResolvesFunctionsWithTemplatePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awless-functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "name.txt"), []byte("web"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(tokens ...string) interface{} {
+		return &mockCommandWithID{entity: strings.TrimPrefix(strings.Join(tokens, ""), "create")}
+	}
+	tpl := "for f in [name.txt] {\n  create instance name=file($f)\n}"
+
+	cenv := NewEnv().WithLookupCommandFunc(lookup).WithTemplatePath(filepath.Join(dir, "main.aws")).Build()
+	compiled, _, err := Compile(MustParse(tpl), cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := executed.String(), "create instance name=web"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	cenv = NewEnv().WithLookupCommandFunc(lookup).WithTemplatePath("repo:create_instance").Build()
+	if compiled, _, err = Compile(MustParse(tpl), cenv, NewRunnerCompileMode); err != nil {
+		t.Fatal(err)
+	}
+	if executed, err = compiled.Run(NewRunEnv(cenv)); err != nil {
+		t.Fatal(err)
+	}
+	if cmdErr := executed.CommandNodesIterator()[0].Err(); cmdErr == nil || !strings.Contains(cmdErr.Error(), "file: disabled in remote templates") {
+		t.Fatalf("got %v, want disabled function error", cmdErr)
+	}
+}
+
+type concurrentCommand struct {
+	entity  string
+	tracker *concurrencyTracker
+}
+
+type concurrencyTracker struct {
+	mu               sync.Mutex
+	running, maximum int
+	started          []string
+	ids              map[string]int
+}
+
+func (c *concurrentCommand) ParamsSpec() params.Spec {
+	return params.NewSpec(params.AllOf(params.Opt("fail", "name", "vpc")))
+}
+func (c *concurrentCommand) Run(renv env.Running, p map[string]interface{}) (interface{}, error) {
+	t := c.tracker
+	t.mu.Lock()
+	t.running++
+	if t.running > t.maximum {
+		t.maximum = t.running
+	}
+	t.started = append(t.started, fmt.Sprint(p["name"]))
+	t.ids[c.entity]++
+	id := fmt.Sprintf("%s-%d", c.entity, t.ids[c.entity])
+	t.mu.Unlock()
+
+	if !renv.IsDryRun() {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.mu.Lock()
+	t.running--
+	t.mu.Unlock()
+	if _, ok := p["fail"]; ok {
+		return nil, errors.New("failure")
+	}
+	return id, nil
+}
+func (c *concurrentCommand) ExtractResult(i interface{}) string { return fmt.Sprint(i) }
+
+func TestRunConcurrently(t *testing.T) {
+	tracker := &concurrencyTracker{ids: make(map[string]int)}
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		return &concurrentCommand{entity: strings.TrimPrefix(strings.Join(tokens, ""), "create"), tracker: tracker}
+	}).Build()
+
+	tpl := MustParse(`vpc = create vpc name=vpc
+for name in [one,two,three,four,five] {
+  create instance name=$name
+}
+create subnet name=sub vpc=$vpc`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renv := NewRunEnv(cenv)
+	renv.SetWorkers(3)
+	executed, err := compiled.Run(renv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `vpc = create vpc name=vpc
+create instance name=one
+create instance name=two
+create instance name=three
+create instance name=four
+create instance name=five
+create subnet name=sub vpc=vpc-1`
+	if got, want := executed.String(), exp; got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if got, want := tracker.maximum, 3; got != want {
+		t.Fatalf("got %d, want %d concurrent commands", got, want)
+	}
+	var vpcIndex, subIndex int
+	for i, name := range tracker.started {
+		switch name {
+		case "vpc":
+			vpcIndex = i
+		case "sub":
+			subIndex = i
+		}
+	}
+	if vpcIndex > subIndex {
+		t.Fatalf("subnet started before its vpc: %v", tracker.started)
+	}
+
+	reverted, err := executed.Revert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(reverted.String(), "\n")
+	if got, want := lines[0], "delete subnet id=subnet-1"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got, want := lines[len(lines)-1], "delete vpc id=vpc-1"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	t.Run("checks and deletions ordering", func(t *testing.T) {
+		tracker := &concurrencyTracker{ids: make(map[string]int)}
+		cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+			return &concurrentCommand{entity: strings.Join(tokens, ""), tracker: tracker}
+		}).Build()
+		tpl := MustParse(`create instance name=one
+delete instance name=one
+delete instance name=two
+check instance name=two
+create instance name=three
+create instance name=four`)
+		compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renv := NewRunEnv(cenv)
+		renv.SetWorkers(3)
+		if _, err := compiled.Run(renv); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := tracker.started[:4], []string{"one", "one", "two", "two"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := tracker.maximum, 2; got != want {
+			t.Fatalf("got %d, want %d concurrent commands", got, want)
+		}
+	})
+
+	t.Run("stop on failure", func(t *testing.T) {
+		tracker := &concurrencyTracker{ids: make(map[string]int)}
+		cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+			return &concurrentCommand{entity: strings.TrimPrefix(strings.Join(tokens, ""), "create"), tracker: tracker}
+		}).Build()
+		tpl := MustParse(`vpc = create vpc name=vpc fail=true
+create subnet name=sub vpc=$vpc
+create instance name=other`)
+		compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renv := NewRunEnv(cenv)
+		renv.SetWorkers(2)
+		executed, err := compiled.Run(renv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := executed.String(), "vpc = create vpc fail=true name=vpc\ncreate instance name=other"; got != want {
+			t.Fatalf("got\n%s\nwant\n%s", got, want)
+		}
+		if cmd := executed.CommandNodesIterator()[0]; cmd.Err() == nil {
+			t.Fatal("expected command error")
+		}
+	})
+}
+
+type flakyCommand struct {
+	failures     int
+	delay        time.Duration
+	runs         int
+	timeoutParam bool
+	permanent    bool
+}
+
+type transientError string
+
+func (e transientError) Error() string   { return string(e) }
+func (e transientError) Retryable() bool { return true }
+
+func (c *flakyCommand) ParamsSpec() params.Spec {
+	if c.timeoutParam {
+		return params.NewSpec(params.AllOf(params.Opt("name", "timeout")))
+	}
+	return params.NewSpec(params.AllOf(params.Opt("name")))
+}
+func (c *flakyCommand) Run(env.Running, map[string]interface{}) (interface{}, error) {
+	c.runs++
+	time.Sleep(c.delay)
+	if c.runs <= c.failures && c.permanent {
+		return nil, errors.New("access denied")
+	}
+	if c.runs <= c.failures {
+		return nil, transientError("throttling")
+	}
+	return fmt.Sprintf("id-%d", c.runs), nil
+}
+func (c *flakyCommand) ExtractResult(i interface{}) string { return fmt.Sprint(i) }
+
+func TestRunPolicies(t *testing.T) {
+	tcases := []struct {
+		tpl          string
+		cmd          *flakyCommand
+		expLine      string
+		expParams    []string
+		expAttempts  int
+		expErr       string
+		expCompilErr string
+	}{
+		{
+			tpl:         "create instance name=web retry=2 backoff=1ms",
+			cmd:         &flakyCommand{failures: 2},
+			expLine:     "create instance backoff=1ms name=web retry=2",
+			expParams:   []string{"name"},
+			expAttempts: 3,
+		},
+		{
+			tpl:         "create instance name=web retry=1 backoff=1ms",
+			cmd:         &flakyCommand{failures: 2},
+			expLine:     "create instance backoff=1ms name=web retry=1",
+			expParams:   []string{"name"},
+			expAttempts: 2,
+			expErr:      "throttling",
+		},
+		{
+			tpl:         "create instance name=web retry=2 backoff=1ms",
+			cmd:         &flakyCommand{failures: 2, permanent: true},
+			expLine:     "create instance backoff=1ms name=web retry=2",
+			expParams:   []string{"name"},
+			expAttempts: 1,
+			expErr:      "access denied",
+		},
+		{
+			tpl:         "update instance name=web retry=1 backoff=1ms timeout=5ms",
+			cmd:         &flakyCommand{failures: 1, delay: 10 * time.Millisecond},
+			expLine:     "update instance backoff=1ms name=web retry=1 timeout=5ms",
+			expParams:   []string{"name"},
+			expAttempts: 2,
+			expErr:      "timed out after 5ms",
+		},
+		{
+			tpl:       "create instance name=web timeout=5ms",
+			cmd:       &flakyCommand{delay: 50 * time.Millisecond},
+			expLine:   "create instance name=web timeout=5ms",
+			expParams: []string{"name"},
+			expErr:    "timed out after 5ms",
+		},
+		{
+			tpl:       "create instance name=web timeout=180",
+			cmd:       &flakyCommand{timeoutParam: true},
+			expLine:   "create instance name=web timeout=180",
+			expParams: []string{"name", "timeout"},
+		},
+		{
+			tpl:       "create instance name=web retry=0",
+			cmd:       &flakyCommand{},
+			expLine:   "create instance name=web retry=0",
+			expParams: []string{"name"},
+		},
+		{tpl: "create instance name=web retry=many", cmd: &flakyCommand{}, expCompilErr: "retry: expecting a positive integer"},
+		{tpl: "create instance name=web backoff=3", cmd: &flakyCommand{}, expCompilErr: "backoff: expecting a positive duration"},
+		{tpl: "create instance name=web retry=2 timeout=5s", cmd: &flakyCommand{}, expCompilErr: "timeout: cannot be combined with retry on a creation"},
+		{tpl: "create instance name=web retry=2 timeout=180", cmd: &flakyCommand{timeoutParam: true}, expLine: "create instance name=web retry=2 timeout=180", expParams: []string{"name", "timeout"}, expAttempts: 1},
+	}
+
+	for i, tcase := range tcases {
+		cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} { return tcase.cmd }).Build()
+		compiled, _, err := Compile(MustParse(tcase.tpl), cenv, NewRunnerCompileMode)
+		if tcase.expCompilErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.expCompilErr) {
+				t.Fatalf("%d: got %v, want %s", i+1, err, tcase.expCompilErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		cmd := compiled.CommandNodesIterator()[0]
+		var keys []string
+		for k := range cmd.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if got, want := keys, tcase.expParams; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: got %v, want %v", i+1, got, want)
+		}
+
+		executed, err := compiled.Run(NewRunEnv(cenv))
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := executed.String(), tcase.expLine; got != want {
+			t.Fatalf("%d: got %s, want %s", i+1, got, want)
+		}
+		cmd = executed.CommandNodesIterator()[0]
+		if got, want := cmd.CmdAttempts, tcase.expAttempts; got != want {
+			t.Fatalf("%d: got %d, want %d", i+1, got, want)
+		}
+		if tcase.expErr == "" && cmd.CmdErr != nil {
+			t.Fatalf("%d: %s", i+1, cmd.CmdErr)
+		}
+		if tcase.expErr != "" && (cmd.CmdErr == nil || !strings.Contains(cmd.CmdErr.Error(), tcase.expErr)) {
+			t.Fatalf("%d: got %v, want %s", i+1, cmd.CmdErr, tcase.expErr)
+		}
+
+		b, err := (&TemplateExecution{Template: executed}).MarshalJSON()
+		if err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		unmarshaled := &TemplateExecution{}
+		if err = unmarshaled.UnmarshalJSON(b); err != nil {
+			t.Fatalf("%d: %s", i+1, err)
+		}
+		if got, want := unmarshaled.CommandNodesIterator()[0].CmdAttempts, tcase.expAttempts; got != want {
+			t.Fatalf("%d: got %d, want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestRunOnErrorModes(t *testing.T) {
+	newEnv := func() env.Compiling {
+		cmds := make(map[string]*mockCommandWithID)
+		return NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+			key := strings.Join(tokens, "")
+			if _, ok := cmds[key]; !ok {
+				cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+			}
+			return cmds[key]
+		}).Build()
+	}
+	text := `vpc = create vpc cidr=10.0.0.0/16
+sub = create subnet vpc=$vpc fail=true
+create tag resource=$sub key=name value=sub
+create instance name=web`
+
+	t.Run("continue", func(t *testing.T) {
+		cenv := newEnv()
+		compiled, _, err := Compile(MustParse(text), cenv, NewRunnerCompileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renv := NewRunEnv(cenv)
+		renv.SetContinueOnError(true)
+		executed, err := compiled.Run(renv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmds := executed.CommandNodesIterator()
+		if got, want := len(cmds), 4; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+		if got, want := fmt.Sprint(cmds[2].CmdErr), "not run: referenced '$sub' failed"; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+		if got, want := cmds[3].CmdResult, "instance-1"; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		stats := (&TemplateExecution{Template: executed}).Stats()
+		if got, want := stats.OKCount, 2; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+		if got, want := stats.KOCount, 2; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		cenv := newEnv()
+		compiled, _, err := Compile(MustParse(text), cenv, NewRunnerCompileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		executed, err := compiled.Run(NewRunEnv(cenv))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(executed.CommandNodesIterator()), 2; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+
+		tplExec := &TemplateExecution{Template: executed}
+		runner := &Runner{Log: logger.DiscardLogger, CmdLookuper: func(tokens ...string) interface{} {
+			return &mockCommandWithID{entity: strings.TrimPrefix(strings.Join(tokens, ""), "delete")}
+		}}
+		rollbackExec := runner.rollback(tplExec)
+		if rollbackExec == nil {
+			t.Fatal("expected rollback execution")
+		}
+		if got, want := rollbackExec.String(), "delete vpc id=vpc-1"; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+		if got, want := rollbackExec.RollbackOf, executed.ID; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+		if got, want := tplExec.RolledBackBy, rollbackExec.ID; got != want || got == "" {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+
+		var checked string
+		runner.BeforeRollback = func(rollbackExec *TemplateExecution) error {
+			checked = rollbackExec.String()
+			return errors.New("denied by policy")
+		}
+		tplExec = &TemplateExecution{Template: executed}
+		if rollbackExec = runner.rollback(tplExec); rollbackExec != nil {
+			t.Fatalf("got %s, want no rollback execution", rollbackExec)
+		}
+		if got, want := checked, "delete vpc id=vpc-1"; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+		if tplExec.RolledBackBy != "" {
+			t.Fatalf("got %s, want not rolled back", tplExec.RolledBackBy)
+		}
+	})
+}
+
+func TestRunOutputs(t *testing.T) {
+	cmds := make(map[string]*mockCommandWithID)
+	cenv := NewEnv().WithLookupCommandFunc(func(tokens ...string) interface{} {
+		key := strings.Join(tokens, "")
+		if _, ok := cmds[key]; !ok {
+			cmds[key] = &mockCommandWithID{entity: strings.TrimPrefix(key, "create")}
+		}
+		return cmds[key]
+	}).Build()
+	cenv.Push(env.FILLERS, map[string]interface{}{"vpc.cidr": "10.0.0.0/16"})
+
+	tpl := MustParse(`vpc = create vpc cidr={vpc.cidr}
+output vpc = $vpc
+subnets = [10.0.0.0/24, 10.0.1.0/24]
+for cidr in $subnets {
+  sub = create subnet cidr=$cidr vpc=$vpc
+}
+last = create subnet cidr=10.0.2.0/24 vpc=$vpc
+output subnets = [$last, {vpc.cidr}]
+output cidrs = $subnets`)
+
+	compiled, _, err := Compile(tpl, cenv, NewRunnerCompileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, err := compiled.Run(NewRunEnv(cenv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"vpc":     "vpc-1",
+		"subnets": []interface{}{"subnet-3", "10.0.0.0/16"},
+		"cidrs":   []interface{}{"10.0.0.0/24", "10.0.1.0/24"},
+	}
+	if got, want := executed.Outputs(), exp; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	b, err := (&TemplateExecution{Template: executed}).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshaled := &TemplateExecution{}
+	if err = unmarshaled.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := unmarshaled.Outputs(), exp; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+	if got, want := len(unmarshaled.CommandNodesIterator()), 4; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+}
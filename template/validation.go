@@ -33,7 +33,7 @@ func (v *UniqueNameValidator) Execute(t *Template) (errs []error) {
 			if len(resources) > 0 {
 				for _, r := range resources {
 					var buf bytes.Buffer
-					buf.WriteString(fmt.Sprintf("'%s' name already used for %s %s", name, r.Type(), r.Id()))
+					buf.WriteString(locate(cmd.PosOf("name"), fmt.Sprintf("'%s' name already used for %s %s", name, r.Type(), r.Id())))
 					if state, ok := r.Properties()["State"].(string); ok {
 						buf.WriteString(fmt.Sprintf(" (state: '%s')", state))
 					}
@@ -54,7 +54,7 @@ func (v *ParamIsSetValidator) Execute(t *Template) (errs []error) {
 		if cmd.Action == v.Action && cmd.Entity == v.Entity {
 			_, hasParam := cmd.Params[v.Param]
 			if !hasParam {
-				errs = append(errs, fmt.Errorf(v.WarningMessage))
+				errs = append(errs, errors.New(locate(cmd.Pos, v.WarningMessage)))
 			}
 		}
 	}
@@ -1,6 +1,8 @@
 package template_test
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/wallix/awless/cloud"
@@ -28,7 +30,7 @@ func TestValidation(t *testing.T) {
 		if got, want := len(errs), 1; got != want {
 			t.Fatalf("got %d, want %d", got, want)
 		}
-		exp := "'instance1_name' name already used for instance inst_1 (state: 'terminated')"
+		exp := "line 1, column 17: 'instance1_name' name already used for instance inst_1 (state: 'terminated')"
 		if got, want := errs[0].Error(), exp; got != want {
 			t.Fatalf("got %q, want %q", got, want)
 		}
@@ -45,7 +47,7 @@ func TestValidation(t *testing.T) {
 		if got, want := len(errs), 1; got != want {
 			t.Fatalf("got %d, want %d", got, want)
 		}
-		exp := "no keypair set"
+		exp := "line 2, column 3: no keypair set"
 		if got, want := errs[0].Error(), exp; got != want {
 			t.Fatalf("got %q, want %q", got, want)
 		}
@@ -62,3 +64,127 @@ func TestValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestRulesValidator(t *testing.T) {
+	rules, err := template.ParseRules([]byte(`
+rules:
+  - name: mandatory-tags
+    check: required_tags
+    commands: [create instance, create volume]
+    params: [Name, Env]
+  - name: instance-types
+    check: allowed_values
+    commands: [create instance]
+    params: [type]
+    values: [t2.*, t3.micro]
+    severity: error
+  - name: no-public-ssh
+    check: no_open_ingress
+    ports: [22]
+    severity: error
+    message: use the bastion
+  - name: encrypted-databases
+    check: required_params
+    commands: [create database]
+    params: [encrypted]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := template.MustParse(`inst = create instance name=web type=m4.large subnet=sub-1234 image=ami-1234 count=1
+create tag resource=$inst key=Env value=prod
+vol = create volume availabilityzone=eu-west-1a size=10
+update securitygroup id=sg-1234 inbound=authorize protocol=tcp cidr=0.0.0.0/0 portrange=20-30
+update securitygroup id=sg-1234 inbound=authorize protocol=tcp cidr=0.0.0.0/0 portrange=443
+update securitygroup id=sg-1234 inbound=authorize protocol=tcp cidr=10.0.0.0/16 portrange=22
+create database type=db.t2.micro id=my-db engine=postgres password=secret-pass size=10 username=admin`)
+
+	var got []string
+	var errorsCount int
+	for _, err := range tpl.Validate(rules) {
+		got = append(got, err.Error())
+		if violation, ok := err.(*template.RuleViolation); ok && violation.IsError() {
+			errorsCount++
+		}
+	}
+	exp := []string{
+		"line 1, column 8: instance-types: create instance: type 'm4.large' not allowed, expecting t2.*, t3.micro",
+		"line 3, column 7: mandatory-tags: create volume: missing tag 'Name' (ex: create tag resource=$myresource key=Name value=...)",
+		"line 3, column 7: mandatory-tags: create volume: missing tag 'Env' (ex: create tag resource=$myresource key=Env value=...)",
+		"line 4, column 1: no-public-ssh: update securitygroup: inbound traffic on port 22 open to 0.0.0.0/0 (use the bastion)",
+		"line 7, column 1: encrypted-databases: create database: missing param 'encrypted'",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(exp, "\n"))
+	}
+	if got, want := errorsCount, 2; got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+}
+
+func TestRulesValidatorOnLoopsAndReferences(t *testing.T) {
+	rules, err := template.ParseRules([]byte(`
+rules:
+  - name: no-public-ssh
+    check: no_open_ingress
+    ports: [22]
+    severity: error
+  - name: instance-types
+    check: allowed_values
+    commands: [create instance]
+    params: [type]
+    values: [t2.*]
+  - name: subnets
+    check: required_params
+    commands: [create instance]
+    params: [subnet]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := template.MustParse(`sub = create subnet cidr=10.0.0.0/24 vpc=vpc-1234
+for t in [t2.micro, m4.large] {
+  create instance name=web type=$t subnet=$sub image=ami-1234 count=1
+}
+sg = create securitygroup vpc=vpc-1234 description=web name=web
+for c in [10.0.0.0/16, 0.0.0.0/0] {
+  update securitygroup id=$sg inbound=authorize protocol=tcp cidr=$c portrange=22
+}
+update securitygroup id=$sg inbound=authorize protocol=tcp cidr=$sub portrange=22`)
+
+	var got []string
+	for _, err := range tpl.Validate(rules) {
+		got = append(got, err.Error())
+	}
+	exp := []string{
+		"line 3, column 3: instance-types: create instance: type 'm4.large' not allowed, expecting t2.*",
+		"line 7, column 3: no-public-ssh: update securitygroup: inbound traffic on port 22 open to 0.0.0.0/0",
+		"line 9, column 1: no-public-ssh: update securitygroup: cannot check cidr: value unknown before run",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(exp, "\n"))
+	}
+}
+
+func TestParseInvalidRules(t *testing.T) {
+	tcases := []struct {
+		content string
+		expErr  string
+	}{
+		{content: "rules:\n  - check: unknown", expErr: "rule 1: unknown check 'unknown', expecting one of allowed_values, forbidden_values"},
+		{content: "rules:\n  - name: types\n    check: allowed_values\n    severity: fatal", expErr: "types: invalid severity 'fatal'"},
+		{content: "rules:\n  - check: allowed_values\n    values: ['[']", expErr: "rule 1: invalid pattern '['"},
+		{content: "rules:\n  - check: allowed_values\n    param: type", expErr: "field param not found"},
+	}
+	for i, tcase := range tcases {
+		_, err := template.ParseRules([]byte(tcase.content))
+		if err == nil {
+			t.Fatalf("%d: expected error got none", i+1)
+		}
+		if !strings.Contains(err.Error(), tcase.expErr) {
+			t.Fatalf("%d: got %s, want %s", i+1, err, tcase.expErr)
+		}
+	}
+}